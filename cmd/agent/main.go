@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"log"
 	"os/signal"
 	"syscall"
@@ -11,6 +12,7 @@ import (
 
 	"horizonx/internal/agent"
 	"horizonx/internal/config"
+	"horizonx/internal/domain"
 	"horizonx/internal/logger"
 	"horizonx/internal/metrics"
 )
@@ -47,6 +49,44 @@ func main() {
 		log.Fatal(err)
 	}
 
+	// Run jobs as soon as the server pushes them, polling stays as fallback
+	jWorker.UsePush(ws.IsConnected)
+	ws.On("job_available", func(json.RawMessage) {
+		jWorker.Notify()
+	})
+	ws.On("job_cancel", func(payload json.RawMessage) {
+		var evt domain.EventJobCancelled
+		if err := json.Unmarshal(payload, &evt); err != nil {
+			appLog.Error("invalid job cancel payload", "error", err)
+			return
+		}
+
+		if !jWorker.Cancel(evt.JobID) {
+			appLog.Debug("cancelled job is not running on this agent", "job_id", evt.JobID)
+		}
+	})
+
+	// Container logs are requested on demand and streamed back over the websocket
+	containerLogs := agent.NewContainerLogs(ctx, ws.Send, appLog)
+	ws.On("container_logs", func(payload json.RawMessage) {
+		var req domain.ContainerLogsRequest
+		if err := json.Unmarshal(payload, &req); err != nil {
+			appLog.Error("invalid container logs payload", "error", err)
+			return
+		}
+
+		containerLogs.Handle(req)
+	})
+	ws.On("container_logs_stop", func(payload json.RawMessage) {
+		var req domain.ContainerLogsStop
+		if err := json.Unmarshal(payload, &req); err != nil {
+			appLog.Error("invalid container logs stop payload", "error", err)
+			return
+		}
+
+		containerLogs.Stop(req.RequestID)
+	})
+
 	g, gCtx := errgroup.WithContext(ctx)
 
 	// WebSocket connection
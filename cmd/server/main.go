@@ -13,21 +13,30 @@ import (
 	"horizonx/internal/adapters/http/validator"
 	"horizonx/internal/adapters/postgres"
 	"horizonx/internal/adapters/ws/agentws"
+	agentSubscribers "horizonx/internal/adapters/ws/agentws/subscribers"
 	"horizonx/internal/adapters/ws/userws"
 	"horizonx/internal/adapters/ws/userws/subscribers"
 	"horizonx/internal/application/account"
+	"horizonx/internal/application/agentevent"
 	"horizonx/internal/application/application"
 	"horizonx/internal/application/auth"
+	"horizonx/internal/application/containerlog"
 	"horizonx/internal/application/deployment"
+	"horizonx/internal/application/gitcredential"
+	"horizonx/internal/application/gitpoll"
 	"horizonx/internal/application/job"
 	logSvc "horizonx/internal/application/log"
 	"horizonx/internal/application/metrics"
+	"horizonx/internal/application/registrycredential"
 	"horizonx/internal/application/role"
+	"horizonx/internal/application/schedule"
 	"horizonx/internal/application/server"
 	"horizonx/internal/application/user"
+	"horizonx/internal/application/webhook"
 	"horizonx/internal/config"
 	"horizonx/internal/event"
 	"horizonx/internal/logger"
+	"horizonx/internal/secret"
 	"horizonx/internal/workers"
 )
 
@@ -42,6 +51,14 @@ func main() {
 		panic("FATAL: JWT_SECRET is mandatory for Server!")
 	}
 
+	secretBox, err := secret.LoadBox(cfg.SecretKey, cfg.SecretKeyFile, cfg.SecretKeyPrevious, cfg.SecretKeyPreviousFile)
+	if err != nil {
+		panic("FATAL: " + err.Error())
+	}
+	if secretBox == nil {
+		log.Warn("no SECRET_KEY configured, git credentials are disabled and env vars are stored unencrypted")
+	}
+
 	dbPool, err := postgres.InitDB(cfg.DatabaseURL)
 	if err != nil {
 		log.Error("failed to init DB", "error", err)
@@ -52,6 +69,9 @@ func main() {
 
 	bus := event.New()
 
+	// The user hub backs the container log service, so it is created first
+	wsUserhub := userws.NewHub(ctx, log)
+
 	// Repositories
 	logRepo := postgres.NewLogRepository(dbPool)
 	serverRepo := postgres.NewServerRepository(dbPool)
@@ -59,8 +79,13 @@ func main() {
 	userRepo := postgres.NewUserRepository(dbPool)
 	jobRepo := postgres.NewJobRepository(dbPool)
 	metricsRepo := postgres.NewMetricsRepository(dbPool)
-	applicationRepo := postgres.NewApplicationRepository(dbPool)
+	applicationRepo := postgres.NewApplicationRepository(dbPool, secretBox)
 	deploymentRepo := postgres.NewDeploymentRepository(dbPool)
+	agentEventRepo := postgres.NewAgentEventRepository(dbPool)
+	scheduleRepo := postgres.NewScheduleRepository(dbPool)
+	gitCredentialRepo := postgres.NewGitCredentialRepository(dbPool)
+	gitPollRepo := postgres.NewGitPollRepository(dbPool)
+	registryCredentialRepo := postgres.NewRegistryCredentialRepository(dbPool)
 
 	// Services
 	logService := logSvc.NewService(logRepo, bus)
@@ -69,18 +94,31 @@ func main() {
 	roleService := role.NewService(roleRepo)
 	accountService := account.NewService(userRepo)
 	userService := user.NewService(userRepo)
-	jobService := job.NewService(jobRepo, logService, bus)
 	metricsService := metrics.NewService(metricsRepo, bus, log)
 	deploymentService := deployment.NewService(deploymentRepo, logService, bus)
-	applicationService := application.NewService(applicationRepo, serverService, jobService, deploymentService, bus)
+	gitCredentialService := gitcredential.NewService(gitCredentialRepo, secretBox)
+	registryCredentialService := registrycredential.NewService(registryCredentialRepo, secretBox)
+	jobService := job.NewService(jobRepo, logService, job.NewSecretResolver(applicationRepo, gitCredentialService, registryCredentialService), bus)
+	applicationService := application.NewService(applicationRepo, serverService, jobService, deploymentService, gitCredentialService, registryCredentialService, logService, bus)
+	webhookService := webhook.NewService(applicationRepo, applicationService, secretBox, log)
+	gitPollService := gitpoll.NewService(gitPollRepo, applicationService, serverService, jobService, deploymentService, log)
+	agentEventService := agentevent.NewService(agentEventRepo)
+	scheduleService := schedule.NewService(scheduleRepo, applicationService, cfg.TimeZone)
+	containerLogService := containerlog.NewService(applicationService, serverService, wsUserhub, bus, log)
 
 	// Event Listeners
 	applicationListener := application.NewListener(applicationService, log)
 	applicationListener.Register(bus)
 
+	rollbackListener := application.NewRollbackListener(applicationService, deploymentService, log)
+	rollbackListener.Register(bus)
+
 	deploymentListener := deployment.NewListener(deploymentService, log)
 	deploymentListener.Register(bus)
 
+	containerLogListener := containerlog.NewListener(containerLogService, log)
+	containerLogListener.Register(bus)
+
 	// HTTP Handlers
 	jsonDecoder := request.NewJSONDecoder()
 	jsonWriter := response.NewJSONWriter(log)
@@ -95,19 +133,26 @@ func main() {
 	metricsHandler := http.NewMetricsHandler(metricsService, jsonDecoder, jsonWriter, validator)
 	deploymentHandler := http.NewDeploymentHandler(deploymentService, jsonDecoder, jsonWriter, validator)
 	applicationHandler := http.NewApplicationHandler(applicationService, jsonDecoder, jsonWriter, validator)
+	scheduleHandler := http.NewScheduleHandler(scheduleService, jsonDecoder, jsonWriter, validator)
+	containerLogHandler := http.NewContainerLogHandler(containerLogService, jsonWriter, validator)
+	gitCredentialHandler := http.NewGitCredentialHandler(gitCredentialService, jsonDecoder, jsonWriter, validator)
+	registryCredentialHandler := http.NewRegistryCredentialHandler(registryCredentialService, jsonDecoder, jsonWriter, validator)
+	webhookHandler := http.NewWebhookHandler(webhookService, jsonWriter)
+	gitPollHandler := http.NewGitPollHandler(gitPollService, jsonDecoder, jsonWriter)
 
 	// WebSocket Handlers
-	wsUserhub := userws.NewHub(ctx, log)
+	wsUserhub.OnChannelEmpty(containerLogService.ChannelEmpty)
 	wsUserHandler := userws.NewHandler(wsUserhub, log, cfg.JWTSecret, cfg.AllowedOrigins)
 
 	wsAgentRouter := agentws.NewRouter(ctx, log)
-	wsAgentHandler := agentws.NewHandler(wsAgentRouter, log, serverService)
+	wsAgentHandler := agentws.NewHandler(wsAgentRouter, log, serverService, containerLogService)
 
 	go wsUserhub.Run()
 	go wsAgentRouter.Run()
 
 	// Register event subscribers
 	subscribers.Register(bus, wsUserhub)
+	agentSubscribers.Register(bus, wsAgentRouter)
 
 	router := http.NewRouter(cfg, &http.RouterDeps{
 		WsUser:  wsUserHandler,
@@ -122,9 +167,19 @@ func main() {
 		Metrics:     metricsHandler,
 		Application: applicationHandler,
 		Deployment:  deploymentHandler,
+		Schedule:    scheduleHandler,
 
-		RoleService:   roleService,
-		ServerService: serverService,
+		ContainerLog:       containerLogHandler,
+		GitCredential:      gitCredentialHandler,
+		RegistryCredential: registryCredentialHandler,
+		Webhook:            webhookHandler,
+		GitPoll:            gitPollHandler,
+
+		RoleService:       roleService,
+		ServerService:     serverService,
+		AgentEventService: agentEventService,
+
+		Writer: jsonWriter,
 	})
 
 	// Worker Manager
@@ -134,8 +189,13 @@ func main() {
 		Server:      serverService,
 		Metrics:     metricsService,
 		Application: applicationService,
+		AgentEvent:  agentEventService,
+		Schedule:    scheduleService,
+		GitPoll:     gitPollService,
 	})
-	wManager.Start(ctx)
+	if err := wManager.Start(ctx); err != nil {
+		panic("FATAL: " + err.Error())
+	}
 
 	// HTTP Server
 	srv := http.NewServer(router, cfg.Address)
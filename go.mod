@@ -13,6 +13,7 @@ require (
 	github.com/jackc/pgx/v5 v5.5.4
 	golang.org/x/crypto v0.45.0
 	golang.org/x/sync v0.18.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -135,6 +135,30 @@ func (h *ApplicationHandler) Store(w http.ResponseWriter, r *http.Request) {
 
 	app, err := h.svc.Create(r.Context(), req)
 	if err != nil {
+		if errors.Is(err, domain.ErrGitCredentialNotFound) {
+			h.writer.WriteValidationError(w, map[string]string{
+				"git_credential_id": "git credential not found",
+			})
+			return
+		}
+		if errors.Is(err, domain.ErrComposeProjectTaken) {
+			h.writer.WriteValidationError(w, map[string]string{
+				"compose_project_name": err.Error(),
+			})
+			return
+		}
+		if errors.Is(err, domain.ErrRegistryCredentialNotFound) {
+			h.writer.WriteValidationError(w, map[string]string{
+				"registry_credential_id": "registry credential not found",
+			})
+			return
+		}
+		if errors.Is(err, domain.ErrInvalidApplicationSource) {
+			h.writer.WriteValidationError(w, map[string]string{
+				"source": err.Error(),
+			})
+			return
+		}
 		h.writer.Write(w, http.StatusInternalServerError, &response.Response{
 			Message: "failed to create application",
 		})
@@ -178,6 +202,30 @@ func (h *ApplicationHandler) Update(w http.ResponseWriter, r *http.Request) {
 			})
 			return
 		}
+		if errors.Is(err, domain.ErrGitCredentialNotFound) {
+			h.writer.WriteValidationError(w, map[string]string{
+				"git_credential_id": "git credential not found",
+			})
+			return
+		}
+		if errors.Is(err, domain.ErrComposeProjectTaken) {
+			h.writer.WriteValidationError(w, map[string]string{
+				"compose_project_name": err.Error(),
+			})
+			return
+		}
+		if errors.Is(err, domain.ErrRegistryCredentialNotFound) {
+			h.writer.WriteValidationError(w, map[string]string{
+				"registry_credential_id": "registry credential not found",
+			})
+			return
+		}
+		if errors.Is(err, domain.ErrInvalidApplicationSource) {
+			h.writer.WriteValidationError(w, map[string]string{
+				"source": err.Error(),
+			})
+			return
+		}
 		h.writer.Write(w, http.StatusInternalServerError, &response.Response{
 			Message: "failed to update application",
 		})
@@ -233,17 +281,47 @@ func (h *ApplicationHandler) Deploy(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	deployment, err := h.svc.Deploy(r.Context(), appID, userCtx.ID)
+	// The body is optional, an empty one deploys the branch head.
+	var req domain.ApplicationDeployRequest
+	if r.ContentLength != 0 {
+		if err := h.decoder.Decode(r, &req); err != nil {
+			h.writer.Write(w, http.StatusBadRequest, &response.Response{
+				Message: err.Error(),
+			})
+			return
+		}
+	}
+
+	if errs := h.validator.Validate(&req); len(errs) > 0 {
+		h.writer.WriteValidationError(w, errs)
+		return
+	}
+
+	deployment, err := h.svc.Deploy(r.Context(), appID, &userCtx.ID, req)
 	if err != nil {
-		if errors.Is(err, domain.ErrApplicationNotFound) {
+		switch {
+		case errors.Is(err, domain.ErrApplicationNotFound):
 			h.writer.Write(w, http.StatusNotFound, &response.Response{
 				Message: "application not found",
 			})
-			return
+		case errors.Is(err, domain.ErrDeploymentNotFound):
+			h.writer.Write(w, http.StatusNotFound, &response.Response{
+				Message: "deployment not found",
+			})
+		case errors.Is(err, domain.ErrDeploymentNoCommit), errors.Is(err, domain.ErrDeploymentNoImage),
+			errors.Is(err, domain.ErrInvalidApplicationSource):
+			h.writer.Write(w, http.StatusUnprocessableEntity, &response.Response{
+				Message: err.Error(),
+			})
+		case errors.Is(err, domain.ErrSecretsDisabled):
+			h.writer.Write(w, http.StatusServiceUnavailable, &response.Response{
+				Message: err.Error(),
+			})
+		default:
+			h.writer.Write(w, http.StatusInternalServerError, &response.Response{
+				Message: err.Error(),
+			})
 		}
-		h.writer.Write(w, http.StatusInternalServerError, &response.Response{
-			Message: err.Error(),
-		})
 		return
 	}
 
@@ -269,6 +347,12 @@ func (h *ApplicationHandler) Start(w http.ResponseWriter, r *http.Request) {
 			})
 			return
 		}
+		if errors.Is(err, domain.ErrDeploymentInProgress) {
+			h.writer.Write(w, http.StatusConflict, &response.Response{
+				Message: err.Error(),
+			})
+			return
+		}
 		h.writer.Write(w, http.StatusBadRequest, &response.Response{
 			Message: err.Error(),
 		})
@@ -296,6 +380,12 @@ func (h *ApplicationHandler) Stop(w http.ResponseWriter, r *http.Request) {
 			})
 			return
 		}
+		if errors.Is(err, domain.ErrDeploymentInProgress) {
+			h.writer.Write(w, http.StatusConflict, &response.Response{
+				Message: err.Error(),
+			})
+			return
+		}
 		h.writer.Write(w, http.StatusBadRequest, &response.Response{
 			Message: err.Error(),
 		})
@@ -323,6 +413,12 @@ func (h *ApplicationHandler) Restart(w http.ResponseWriter, r *http.Request) {
 			})
 			return
 		}
+		if errors.Is(err, domain.ErrDeploymentInProgress) {
+			h.writer.Write(w, http.StatusConflict, &response.Response{
+				Message: err.Error(),
+			})
+			return
+		}
 		h.writer.Write(w, http.StatusBadRequest, &response.Response{
 			Message: err.Error(),
 		})
@@ -415,6 +511,12 @@ func (h *ApplicationHandler) UpdateEnvVar(w http.ResponseWriter, r *http.Request
 			})
 			return
 		}
+		if errors.Is(err, domain.ErrEnvVarNotFound) {
+			h.writer.Write(w, http.StatusNotFound, &response.Response{
+				Message: "environment variable not found",
+			})
+			return
+		}
 		h.writer.Write(w, http.StatusInternalServerError, &response.Response{
 			Message: "failed to update environment variable",
 		})
@@ -450,6 +552,12 @@ func (h *ApplicationHandler) DeleteEnvVar(w http.ResponseWriter, r *http.Request
 			})
 			return
 		}
+		if errors.Is(err, domain.ErrEnvVarNotFound) {
+			h.writer.Write(w, http.StatusNotFound, &response.Response{
+				Message: "environment variable not found",
+			})
+			return
+		}
 		h.writer.Write(w, http.StatusInternalServerError, &response.Response{
 			Message: "failed to delete environment variable",
 		})
@@ -461,6 +569,61 @@ func (h *ApplicationHandler) DeleteEnvVar(w http.ResponseWriter, r *http.Request
 	})
 }
 
+// RevealEnvVar answers with the plain value of a preview env var, every
+// call is recorded in the application logs.
+func (h *ApplicationHandler) RevealEnvVar(w http.ResponseWriter, r *http.Request) {
+	userCtx, ok := middleware.GetUser(r.Context())
+	if !ok {
+		h.writer.Write(w, http.StatusUnauthorized, &response.Response{
+			Message: "unauthorized",
+		})
+		return
+	}
+
+	appID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		h.writer.Write(w, http.StatusBadRequest, &response.Response{
+			Message: "invalid application id",
+		})
+		return
+	}
+
+	key := r.PathValue("key")
+	if key == "" {
+		h.writer.Write(w, http.StatusBadRequest, &response.Response{
+			Message: "key is required",
+		})
+		return
+	}
+
+	env, err := h.svc.RevealEnvVar(r.Context(), appID, key, userCtx.ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrApplicationNotFound):
+			h.writer.Write(w, http.StatusNotFound, &response.Response{
+				Message: "application not found",
+			})
+		case errors.Is(err, domain.ErrEnvVarNotFound):
+			h.writer.Write(w, http.StatusNotFound, &response.Response{
+				Message: "environment variable not found",
+			})
+		case errors.Is(err, domain.ErrEnvVarNotRevealable):
+			h.writer.Write(w, http.StatusForbidden, &response.Response{
+				Message: err.Error(),
+			})
+		default:
+			h.writer.Write(w, http.StatusInternalServerError, &response.Response{
+				Message: "failed to reveal environment variable",
+			})
+		}
+		return
+	}
+
+	h.writer.Write(w, http.StatusOK, &response.Response{
+		Data: env,
+	})
+}
+
 func (h *ApplicationHandler) ReportHealth(w http.ResponseWriter, r *http.Request) {
 	defer r.Body.Close()
 
@@ -0,0 +1,87 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"horizonx/internal/adapters/http/response"
+	"horizonx/internal/adapters/http/validator"
+	"horizonx/internal/domain"
+)
+
+type ContainerLogHandler struct {
+	svc domain.ContainerLogService
+
+	writer    response.ResponseWriter
+	validator validator.Validator
+}
+
+func NewContainerLogHandler(
+	svc domain.ContainerLogService,
+	w response.ResponseWriter,
+	v validator.Validator,
+) *ContainerLogHandler {
+	return &ContainerLogHandler{
+		svc:       svc,
+		writer:    w,
+		validator: v,
+	}
+}
+
+// Index returns the latest container output of an application. With follow
+// set, new lines are pushed to the websocket channel named in the response.
+func (h *ContainerLogHandler) Index(w http.ResponseWriter, r *http.Request) {
+	appID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		h.writer.Write(w, http.StatusBadRequest, &response.Response{
+			Message: "invalid application id",
+		})
+		return
+	}
+
+	q := r.URL.Query()
+
+	opts := domain.ContainerLogOptions{
+		Tail:    GetInt(q, "tail", 0),
+		Since:   GetString(q, "since", ""),
+		Service: GetString(q, "service", ""),
+		Follow:  GetBool(q, "follow"),
+	}
+
+	if errs := h.validator.Validate(&opts); len(errs) > 0 {
+		h.writer.WriteValidationError(w, errs)
+		return
+	}
+
+	logs, err := h.svc.Get(r.Context(), appID, opts)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrApplicationNotFound):
+			h.writer.Write(w, http.StatusNotFound, &response.Response{
+				Message: "application not found",
+			})
+		case errors.Is(err, domain.ErrAgentOffline):
+			h.writer.Write(w, http.StatusServiceUnavailable, &response.Response{
+				Message: "server agent is offline",
+			})
+		case errors.Is(err, domain.ErrContainerLogsTimeout):
+			h.writer.Write(w, http.StatusGatewayTimeout, &response.Response{
+				Message: err.Error(),
+			})
+		case errors.Is(err, domain.ErrContainerLogsFailed):
+			h.writer.Write(w, http.StatusBadGateway, &response.Response{
+				Message: err.Error(),
+			})
+		default:
+			h.writer.Write(w, http.StatusInternalServerError, &response.Response{
+				Message: "failed to get container logs",
+			})
+		}
+		return
+	}
+
+	h.writer.Write(w, http.StatusOK, &response.Response{
+		Data: logs,
+	})
+}
@@ -0,0 +1,188 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"horizonx/internal/adapters/http/middleware"
+	"horizonx/internal/adapters/http/request"
+	"horizonx/internal/adapters/http/response"
+	"horizonx/internal/adapters/http/validator"
+	"horizonx/internal/domain"
+)
+
+type GitCredentialHandler struct {
+	svc domain.GitCredentialService
+
+	decoder   request.RequestDecoder
+	writer    response.ResponseWriter
+	validator validator.Validator
+}
+
+func NewGitCredentialHandler(
+	svc domain.GitCredentialService,
+	d request.RequestDecoder,
+	w response.ResponseWriter,
+	v validator.Validator,
+) *GitCredentialHandler {
+	return &GitCredentialHandler{
+		svc:       svc,
+		decoder:   d,
+		writer:    w,
+		validator: v,
+	}
+}
+
+func (h *GitCredentialHandler) Index(w http.ResponseWriter, r *http.Request) {
+	credentials, err := h.svc.List(r.Context())
+	if err != nil {
+		h.writer.Write(w, http.StatusInternalServerError, &response.Response{
+			Message: "failed to list git credentials",
+		})
+		return
+	}
+
+	h.writer.Write(w, http.StatusOK, &response.Response{
+		Data: credentials,
+	})
+}
+
+func (h *GitCredentialHandler) Show(w http.ResponseWriter, r *http.Request) {
+	credentialID, ok := h.parseID(w, r)
+	if !ok {
+		return
+	}
+
+	credential, err := h.svc.GetByID(r.Context(), credentialID)
+	if err != nil {
+		h.writeError(w, err, "failed to get git credential")
+		return
+	}
+
+	h.writer.Write(w, http.StatusOK, &response.Response{
+		Data: credential,
+	})
+}
+
+func (h *GitCredentialHandler) Store(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	userCtx, ok := middleware.GetUser(r.Context())
+	if !ok {
+		h.writer.Write(w, http.StatusUnauthorized, &response.Response{
+			Message: "unauthorized",
+		})
+		return
+	}
+
+	var req domain.GitCredentialCreateRequest
+	if err := h.decoder.Decode(r, &req); err != nil {
+		h.writer.Write(w, http.StatusBadRequest, &response.Response{
+			Message: err.Error(),
+		})
+		return
+	}
+
+	if errs := h.validator.Validate(&req); len(errs) > 0 {
+		h.writer.WriteValidationError(w, errs)
+		return
+	}
+
+	credential, err := h.svc.Create(r.Context(), req, userCtx.ID)
+	if err != nil {
+		h.writeError(w, err, "failed to create git credential")
+		return
+	}
+
+	h.writer.Write(w, http.StatusCreated, &response.Response{
+		Message: "git credential created successfully",
+		Data:    credential,
+	})
+}
+
+func (h *GitCredentialHandler) Update(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	credentialID, ok := h.parseID(w, r)
+	if !ok {
+		return
+	}
+
+	var req domain.GitCredentialUpdateRequest
+	if err := h.decoder.Decode(r, &req); err != nil {
+		h.writer.Write(w, http.StatusBadRequest, &response.Response{
+			Message: err.Error(),
+		})
+		return
+	}
+
+	if errs := h.validator.Validate(&req); len(errs) > 0 {
+		h.writer.WriteValidationError(w, errs)
+		return
+	}
+
+	credential, err := h.svc.Update(r.Context(), credentialID, req)
+	if err != nil {
+		h.writeError(w, err, "failed to update git credential")
+		return
+	}
+
+	h.writer.Write(w, http.StatusOK, &response.Response{
+		Message: "git credential updated successfully",
+		Data:    credential,
+	})
+}
+
+func (h *GitCredentialHandler) Destroy(w http.ResponseWriter, r *http.Request) {
+	credentialID, ok := h.parseID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.svc.Delete(r.Context(), credentialID); err != nil {
+		h.writeError(w, err, "failed to delete git credential")
+		return
+	}
+
+	h.writer.Write(w, http.StatusOK, &response.Response{
+		Message: "git credential deleted successfully",
+	})
+}
+
+func (h *GitCredentialHandler) parseID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	credentialID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		h.writer.Write(w, http.StatusBadRequest, &response.Response{
+			Message: "invalid git credential id",
+		})
+		return 0, false
+	}
+
+	return credentialID, true
+}
+
+func (h *GitCredentialHandler) writeError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, domain.ErrGitCredentialNotFound):
+		h.writer.Write(w, http.StatusNotFound, &response.Response{
+			Message: "git credential not found",
+		})
+	case errors.Is(err, domain.ErrGitCredentialExists), errors.Is(err, domain.ErrGitCredentialInUse):
+		h.writer.Write(w, http.StatusConflict, &response.Response{
+			Message: err.Error(),
+		})
+	case errors.Is(err, domain.ErrInvalidGitCredential):
+		h.writer.Write(w, http.StatusUnprocessableEntity, &response.Response{
+			Message: err.Error(),
+		})
+	case errors.Is(err, domain.ErrSecretsDisabled):
+		h.writer.Write(w, http.StatusServiceUnavailable, &response.Response{
+			Message: err.Error(),
+		})
+	default:
+		h.writer.Write(w, http.StatusInternalServerError, &response.Response{
+			Message: fallback,
+		})
+	}
+}
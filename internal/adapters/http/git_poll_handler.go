@@ -0,0 +1,61 @@
+package http
+
+import (
+	"net/http"
+
+	"horizonx/internal/adapters/http/middleware"
+	"horizonx/internal/adapters/http/request"
+	"horizonx/internal/adapters/http/response"
+	"horizonx/internal/domain"
+)
+
+type GitPollHandler struct {
+	svc domain.GitPollService
+
+	decoder request.RequestDecoder
+	writer  response.ResponseWriter
+}
+
+func NewGitPollHandler(
+	svc domain.GitPollService,
+	d request.RequestDecoder,
+	w response.ResponseWriter,
+) *GitPollHandler {
+	return &GitPollHandler{
+		svc:     svc,
+		decoder: d,
+		writer:  w,
+	}
+}
+
+// ReportHeads takes the branch heads an agent read for a poll job.
+func (h *GitPollHandler) ReportHeads(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	serverID, valid := middleware.GetServerID(r.Context())
+	if !valid {
+		h.writer.Write(w, http.StatusUnauthorized, &response.Response{
+			Message: "invalid credentials",
+		})
+		return
+	}
+
+	var req []domain.GitRemoteHead
+	if err := h.decoder.Decode(r, &req); err != nil {
+		h.writer.Write(w, http.StatusBadRequest, &response.Response{
+			Message: err.Error(),
+		})
+		return
+	}
+
+	if err := h.svc.HandleHeads(r.Context(), serverID, req); err != nil {
+		h.writer.Write(w, http.StatusInternalServerError, &response.Response{
+			Message: "failed to handle git heads",
+		})
+		return
+	}
+
+	h.writer.Write(w, http.StatusOK, &response.Response{
+		Message: "git heads reported",
+	})
+}
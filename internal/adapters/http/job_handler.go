@@ -60,13 +60,17 @@ func (h *JobHandler) Index(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	for _, job := range result.Data {
+		job.RedactSecrets()
+	}
+
 	h.writer.Write(w, http.StatusOK, &response.Response{
 		Data: result.Data,
 		Meta: result.Meta,
 	})
 }
 
-func (h *JobHandler) Pending(w http.ResponseWriter, r *http.Request) {
+func (h *JobHandler) Claim(w http.ResponseWriter, r *http.Request) {
 	serverID, ok := middleware.GetServerID(r.Context())
 	if !ok {
 		h.writer.Write(w, http.StatusUnauthorized, &response.Response{
@@ -75,10 +79,30 @@ func (h *JobHandler) Pending(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	jobs, err := h.svc.GetPending(r.Context(), serverID)
+	// Agents before the claim limit send no body.
+	req := domain.JobClaimRequest{
+		Limit:      domain.JobClaimLimit,
+		LightLimit: domain.JobClaimLimit,
+	}
+	if r.ContentLength != 0 {
+		req = domain.JobClaimRequest{}
+		if err := h.decoder.Decode(r, &req); err != nil {
+			h.writer.Write(w, http.StatusBadRequest, &response.Response{
+				Message: err.Error(),
+			})
+			return
+		}
+
+		if errs := h.validator.Validate(&req); len(errs) > 0 {
+			h.writer.WriteValidationError(w, errs)
+			return
+		}
+	}
+
+	jobs, err := h.svc.Claim(r.Context(), serverID, req.Limit, req.LightLimit)
 	if err != nil {
 		h.writer.Write(w, http.StatusInternalServerError, &response.Response{
-			Message: "failed to get pending jobs",
+			Message: "failed to claim jobs",
 		})
 		return
 	}
@@ -111,15 +135,55 @@ func (h *JobHandler) Show(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	job.RedactSecrets()
+
 	h.writer.Write(w, http.StatusOK, &response.Response{
 		Data: job,
 	})
 }
 
-func (h *JobHandler) Start(w http.ResponseWriter, r *http.Request) {
-	paramID := r.PathValue("id")
+func (h *JobHandler) Attempts(w http.ResponseWriter, r *http.Request) {
+	jobID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		h.writer.Write(w, http.StatusBadRequest, &response.Response{
+			Message: "invalid job id",
+		})
+		return
+	}
 
-	jobID, err := strconv.ParseInt(paramID, 10, 64)
+	jobs, err := h.svc.ListAttempts(r.Context(), jobID)
+	if err != nil {
+		if errors.Is(err, domain.ErrJobNotFound) {
+			h.writer.Write(w, http.StatusNotFound, &response.Response{
+				Message: "job not found",
+			})
+			return
+		}
+		h.writer.Write(w, http.StatusInternalServerError, &response.Response{
+			Message: "failed to get job attempts",
+		})
+		return
+	}
+
+	for _, job := range jobs {
+		job.RedactSecrets()
+	}
+
+	h.writer.Write(w, http.StatusOK, &response.Response{
+		Data: jobs,
+	})
+}
+
+func (h *JobHandler) Heartbeat(w http.ResponseWriter, r *http.Request) {
+	serverID, ok := middleware.GetServerID(r.Context())
+	if !ok {
+		h.writer.Write(w, http.StatusUnauthorized, &response.Response{
+			Message: "invalid credentials",
+		})
+		return
+	}
+
+	jobID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
 	if err != nil {
 		h.writer.Write(w, http.StatusBadRequest, &response.Response{
 			Message: "invalid job id",
@@ -127,7 +191,7 @@ func (h *JobHandler) Start(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	job, err := h.svc.Start(r.Context(), jobID)
+	job, err := h.svc.Heartbeat(r.Context(), jobID, serverID)
 	if err != nil {
 		if errors.Is(err, domain.ErrJobNotFound) {
 			h.writer.Write(w, http.StatusNotFound, &response.Response{
@@ -136,8 +200,15 @@ func (h *JobHandler) Start(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
+		if errors.Is(err, domain.ErrInvalidJobState) {
+			h.writer.Write(w, http.StatusConflict, &response.Response{
+				Message: "job lease is no longer held by this agent",
+			})
+			return
+		}
+
 		h.writer.Write(w, http.StatusInternalServerError, &response.Response{
-			Message: "failed to start job",
+			Message: "failed to extend job lease",
 		})
 		return
 	}
@@ -150,6 +221,14 @@ func (h *JobHandler) Start(w http.ResponseWriter, r *http.Request) {
 func (h *JobHandler) Finish(w http.ResponseWriter, r *http.Request) {
 	defer r.Body.Close()
 
+	serverID, ok := middleware.GetServerID(r.Context())
+	if !ok {
+		h.writer.Write(w, http.StatusUnauthorized, &response.Response{
+			Message: "invalid credentials",
+		})
+		return
+	}
+
 	paramID := r.PathValue("id")
 
 	var req domain.JobFinishRequest
@@ -173,7 +252,7 @@ func (h *JobHandler) Finish(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	job, err := h.svc.Finish(r.Context(), jobID, req.Status)
+	job, err := h.svc.Finish(r.Context(), jobID, serverID, req.Status)
 	if err != nil {
 		if errors.Is(err, domain.ErrJobNotFound) {
 			h.writer.Write(w, http.StatusNotFound, &response.Response{
@@ -182,6 +261,13 @@ func (h *JobHandler) Finish(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
+		if errors.Is(err, domain.ErrInvalidJobState) {
+			h.writer.Write(w, http.StatusConflict, &response.Response{
+				Message: "job is not held by this agent",
+			})
+			return
+		}
+
 		h.writer.Write(w, http.StatusInternalServerError, &response.Response{
 			Message: "failed to finish job",
 		})
@@ -192,3 +278,48 @@ func (h *JobHandler) Finish(w http.ResponseWriter, r *http.Request) {
 		Data: job,
 	})
 }
+
+func (h *JobHandler) Cancel(w http.ResponseWriter, r *http.Request) {
+	userCtx, ok := middleware.GetUser(r.Context())
+	if !ok {
+		h.writer.Write(w, http.StatusUnauthorized, &response.Response{
+			Message: "unauthorized",
+		})
+		return
+	}
+
+	jobID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		h.writer.Write(w, http.StatusBadRequest, &response.Response{
+			Message: "invalid job id",
+		})
+		return
+	}
+
+	job, err := h.svc.Cancel(r.Context(), jobID, userCtx.ID)
+	if err != nil {
+		if errors.Is(err, domain.ErrJobNotFound) {
+			h.writer.Write(w, http.StatusNotFound, &response.Response{
+				Message: "job not found",
+			})
+			return
+		}
+
+		if errors.Is(err, domain.ErrInvalidJobState) {
+			h.writer.Write(w, http.StatusConflict, &response.Response{
+				Message: "only queued or running jobs can be cancelled",
+			})
+			return
+		}
+
+		h.writer.Write(w, http.StatusInternalServerError, &response.Response{
+			Message: "failed to cancel job",
+		})
+		return
+	}
+
+	h.writer.Write(w, http.StatusOK, &response.Response{
+		Message: "job cancelled",
+		Data:    job,
+	})
+}
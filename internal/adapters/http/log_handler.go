@@ -1,6 +1,8 @@
 package http
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 
 	"horizonx/internal/adapters/http/request"
@@ -9,6 +11,8 @@ import (
 	"horizonx/internal/domain"
 )
 
+const maxLogBatchBytes = 8 << 20
+
 type LogHandler struct {
 	svc domain.LogService
 
@@ -104,3 +108,54 @@ func (h *LogHandler) Store(w http.ResponseWriter, r *http.Request) {
 		Message: "log created successfully",
 	})
 }
+
+// StoreBatch accepts log lines as a JSON array or as NDJSON.
+func (h *LogHandler) StoreBatch(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxLogBatchBytes)
+
+	reqs, err := request.DecodeBatch[domain.LogEmitRequest](r, domain.LogBatchLimit)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, request.ErrBatchTooLarge) {
+			status = http.StatusRequestEntityTooLarge
+		}
+		h.writer.Write(w, status, &response.Response{
+			Message: err.Error(),
+		})
+		return
+	}
+
+	logs := make([]*domain.Log, 0, len(reqs))
+	for i := range reqs {
+		req := &reqs[i]
+		if errs := h.validator.Validate(req); len(errs) > 0 {
+			h.writer.WriteValidationError(w, errs)
+			return
+		}
+
+		logs = append(logs, &domain.Log{
+			Timestamp:     req.Timestamp,
+			Level:         req.Level,
+			Source:        req.Source,
+			Action:        req.Action,
+			TraceID:       req.TraceID,
+			JobID:         req.JobID,
+			ServerID:      req.ServerID,
+			ApplicationID: req.ApplicationID,
+			DeploymentID:  req.DeploymentID,
+			Message:       req.Message,
+			Context:       req.Context,
+		})
+	}
+
+	if err := h.svc.CreateBatch(r.Context(), logs); err != nil {
+		h.writer.Write(w, http.StatusInternalServerError, &response.Response{
+			Message: "failed to create logs",
+		})
+		return
+	}
+
+	h.writer.Write(w, http.StatusCreated, &response.Response{
+		Message: fmt.Sprintf("%d logs created successfully", len(logs)),
+	})
+}
@@ -3,6 +3,7 @@ package http
 import (
 	"errors"
 	"net/http"
+	"strconv"
 
 	"horizonx/internal/adapters/http/request"
 	"horizonx/internal/adapters/http/response"
@@ -145,3 +146,119 @@ func (h *MetricsHandler) NetSpeedHistory(w http.ResponseWriter, r *http.Request)
 		Data: data,
 	})
 }
+
+func (h *MetricsHandler) AppLatest(w http.ResponseWriter, r *http.Request) {
+	appID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		h.writer.Write(w, http.StatusNotFound, &response.Response{
+			Message: "application not found",
+		})
+		return
+	}
+
+	metrics, err := h.svc.AppLatest(appID)
+	if err != nil {
+		if errors.Is(err, domain.ErrMetricsNotFound) {
+			h.writer.Write(w, http.StatusNotFound, &response.Response{
+				Message: "metrics not found",
+			})
+			return
+		}
+
+		h.writer.Write(w, http.StatusInternalServerError, &response.Response{
+			Message: "failed to get latest application metrics",
+		})
+		return
+	}
+
+	h.writer.Write(w, http.StatusOK, &response.Response{
+		Data: metrics,
+	})
+}
+
+func (h *MetricsHandler) AppCPUUsageHistory(w http.ResponseWriter, r *http.Request) {
+	appID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		h.writer.Write(w, http.StatusNotFound, &response.Response{
+			Message: "application not found",
+		})
+		return
+	}
+
+	data, err := h.svc.AppCPUUsageHistory(appID)
+	if err != nil {
+		if errors.Is(err, domain.ErrMetricsNotFound) {
+			h.writer.Write(w, http.StatusNotFound, &response.Response{
+				Message: "cpu usage history not found",
+			})
+			return
+		}
+
+		h.writer.Write(w, http.StatusInternalServerError, &response.Response{
+			Message: "failed to get cpu usage history",
+		})
+		return
+	}
+
+	h.writer.Write(w, http.StatusOK, &response.Response{
+		Data: data,
+	})
+}
+
+func (h *MetricsHandler) AppMemoryUsageHistory(w http.ResponseWriter, r *http.Request) {
+	appID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		h.writer.Write(w, http.StatusNotFound, &response.Response{
+			Message: "application not found",
+		})
+		return
+	}
+
+	data, err := h.svc.AppMemoryUsageHistory(appID)
+	if err != nil {
+		if errors.Is(err, domain.ErrMetricsNotFound) {
+			h.writer.Write(w, http.StatusNotFound, &response.Response{
+				Message: "memory usage history not found",
+			})
+			return
+		}
+
+		h.writer.Write(w, http.StatusInternalServerError, &response.Response{
+			Message: "failed to get memory usage history",
+		})
+		return
+	}
+
+	h.writer.Write(w, http.StatusOK, &response.Response{
+		Data: data,
+	})
+}
+
+func (h *MetricsHandler) AppNetSpeedHistory(w http.ResponseWriter, r *http.Request) {
+	appID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		h.writer.Write(w, http.StatusNotFound, &response.Response{
+			Message: "application not found",
+		})
+		return
+	}
+
+	data, err := h.svc.AppNetSpeedHistory(appID)
+	if err != nil {
+		if errors.Is(err, domain.ErrMetricsNotFound) {
+			h.writer.Write(w, http.StatusNotFound, &response.Response{
+				Message: "net speed history not found",
+			})
+			return
+		}
+
+		h.writer.Write(w, http.StatusInternalServerError, &response.Response{
+			Message: "failed to get net speed history",
+		})
+		return
+	}
+
+	h.writer.Write(w, http.StatusOK, &response.Response{
+		Data: data,
+	})
+}
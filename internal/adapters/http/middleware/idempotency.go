@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"horizonx/internal/adapters/http/response"
+	"horizonx/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// Idempotent skips agent requests whose event ID was already processed. It
+// must run after Agent so the server ID is known. Requests without the
+// header are passed through untouched.
+//
+// The event ID is only recorded once the handler answered with a 2xx. A
+// crash, a panic or a dropped connection leaves it unrecorded and the
+// agent's replay is handled again, so handlers must accept a repeat.
+func Idempotent(svc domain.AgentEventService, writer response.ResponseWriter) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			raw := r.Header.Get(domain.AgentEventIDHeader)
+			if raw == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			eventID, err := uuid.Parse(raw)
+			if err != nil {
+				writer.Write(w, http.StatusBadRequest, &response.Response{
+					Message: "invalid event id",
+				})
+				return
+			}
+
+			serverID, ok := GetServerID(r.Context())
+			if !ok {
+				writer.Write(w, http.StatusUnauthorized, &response.Response{
+					Message: "unauthorized",
+				})
+				return
+			}
+
+			processed, err := svc.Processed(r.Context(), eventID)
+			if err != nil {
+				writer.Write(w, http.StatusInternalServerError, &response.Response{
+					Message: "failed to look up event",
+				})
+				return
+			}
+
+			if processed {
+				writer.Write(w, http.StatusOK, &response.Response{
+					Message: "event already processed",
+				})
+				return
+			}
+
+			rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(rec, r)
+
+			// Failing to record only means a replay is handled once more.
+			if rec.status >= 200 && rec.status < 300 {
+				_ = svc.Record(context.WithoutCancel(r.Context()), serverID, eventID)
+			}
+		})
+	}
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
@@ -0,0 +1,184 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"horizonx/internal/adapters/http/middleware"
+	"horizonx/internal/adapters/http/request"
+	"horizonx/internal/adapters/http/response"
+	"horizonx/internal/adapters/http/validator"
+	"horizonx/internal/domain"
+)
+
+type RegistryCredentialHandler struct {
+	svc domain.RegistryCredentialService
+
+	decoder   request.RequestDecoder
+	writer    response.ResponseWriter
+	validator validator.Validator
+}
+
+func NewRegistryCredentialHandler(
+	svc domain.RegistryCredentialService,
+	d request.RequestDecoder,
+	w response.ResponseWriter,
+	v validator.Validator,
+) *RegistryCredentialHandler {
+	return &RegistryCredentialHandler{
+		svc:       svc,
+		decoder:   d,
+		writer:    w,
+		validator: v,
+	}
+}
+
+func (h *RegistryCredentialHandler) Index(w http.ResponseWriter, r *http.Request) {
+	credentials, err := h.svc.List(r.Context())
+	if err != nil {
+		h.writer.Write(w, http.StatusInternalServerError, &response.Response{
+			Message: "failed to list registry credentials",
+		})
+		return
+	}
+
+	h.writer.Write(w, http.StatusOK, &response.Response{
+		Data: credentials,
+	})
+}
+
+func (h *RegistryCredentialHandler) Show(w http.ResponseWriter, r *http.Request) {
+	credentialID, ok := h.parseID(w, r)
+	if !ok {
+		return
+	}
+
+	credential, err := h.svc.GetByID(r.Context(), credentialID)
+	if err != nil {
+		h.writeError(w, err, "failed to get registry credential")
+		return
+	}
+
+	h.writer.Write(w, http.StatusOK, &response.Response{
+		Data: credential,
+	})
+}
+
+func (h *RegistryCredentialHandler) Store(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	userCtx, ok := middleware.GetUser(r.Context())
+	if !ok {
+		h.writer.Write(w, http.StatusUnauthorized, &response.Response{
+			Message: "unauthorized",
+		})
+		return
+	}
+
+	var req domain.RegistryCredentialCreateRequest
+	if err := h.decoder.Decode(r, &req); err != nil {
+		h.writer.Write(w, http.StatusBadRequest, &response.Response{
+			Message: err.Error(),
+		})
+		return
+	}
+
+	if errs := h.validator.Validate(&req); len(errs) > 0 {
+		h.writer.WriteValidationError(w, errs)
+		return
+	}
+
+	credential, err := h.svc.Create(r.Context(), req, userCtx.ID)
+	if err != nil {
+		h.writeError(w, err, "failed to create registry credential")
+		return
+	}
+
+	h.writer.Write(w, http.StatusCreated, &response.Response{
+		Message: "registry credential created successfully",
+		Data:    credential,
+	})
+}
+
+func (h *RegistryCredentialHandler) Update(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	credentialID, ok := h.parseID(w, r)
+	if !ok {
+		return
+	}
+
+	var req domain.RegistryCredentialUpdateRequest
+	if err := h.decoder.Decode(r, &req); err != nil {
+		h.writer.Write(w, http.StatusBadRequest, &response.Response{
+			Message: err.Error(),
+		})
+		return
+	}
+
+	if errs := h.validator.Validate(&req); len(errs) > 0 {
+		h.writer.WriteValidationError(w, errs)
+		return
+	}
+
+	credential, err := h.svc.Update(r.Context(), credentialID, req)
+	if err != nil {
+		h.writeError(w, err, "failed to update registry credential")
+		return
+	}
+
+	h.writer.Write(w, http.StatusOK, &response.Response{
+		Message: "registry credential updated successfully",
+		Data:    credential,
+	})
+}
+
+func (h *RegistryCredentialHandler) Destroy(w http.ResponseWriter, r *http.Request) {
+	credentialID, ok := h.parseID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.svc.Delete(r.Context(), credentialID); err != nil {
+		h.writeError(w, err, "failed to delete registry credential")
+		return
+	}
+
+	h.writer.Write(w, http.StatusOK, &response.Response{
+		Message: "registry credential deleted successfully",
+	})
+}
+
+func (h *RegistryCredentialHandler) parseID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	credentialID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		h.writer.Write(w, http.StatusBadRequest, &response.Response{
+			Message: "invalid registry credential id",
+		})
+		return 0, false
+	}
+
+	return credentialID, true
+}
+
+func (h *RegistryCredentialHandler) writeError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, domain.ErrRegistryCredentialNotFound):
+		h.writer.Write(w, http.StatusNotFound, &response.Response{
+			Message: "registry credential not found",
+		})
+	case errors.Is(err, domain.ErrRegistryCredentialExists), errors.Is(err, domain.ErrRegistryCredentialInUse):
+		h.writer.Write(w, http.StatusConflict, &response.Response{
+			Message: err.Error(),
+		})
+	case errors.Is(err, domain.ErrSecretsDisabled):
+		h.writer.Write(w, http.StatusServiceUnavailable, &response.Response{
+			Message: err.Error(),
+		})
+	default:
+		h.writer.Write(w, http.StatusInternalServerError, &response.Response{
+			Message: fallback,
+		})
+	}
+}
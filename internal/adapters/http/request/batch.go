@@ -0,0 +1,80 @@
+package request
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+var ErrBatchTooLarge = errors.New("batch too large")
+
+// DecodeBatch reads either a JSON array or newline delimited JSON objects
+// from the request body. At most limit items are accepted.
+func DecodeBatch[T any](r *http.Request, limit int) ([]T, error) {
+	defer r.Body.Close()
+
+	body := bufio.NewReader(r.Body)
+
+	first, err := peekNonSpace(body)
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, ErrInvalidBody
+		}
+		return nil, err
+	}
+
+	dec := json.NewDecoder(body)
+	dec.DisallowUnknownFields()
+
+	if first == '[' {
+		var items []T
+		if err := dec.Decode(&items); err != nil {
+			return nil, ErrInvalidBody
+		}
+		if len(items) > limit {
+			return nil, fmt.Errorf("%w: max %d items", ErrBatchTooLarge, limit)
+		}
+		return items, nil
+	}
+
+	var items []T
+	for {
+		var item T
+		if err := dec.Decode(&item); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, ErrInvalidBody
+		}
+
+		items = append(items, item)
+		if len(items) > limit {
+			return nil, fmt.Errorf("%w: max %d items", ErrBatchTooLarge, limit)
+		}
+	}
+
+	if len(items) == 0 {
+		return nil, ErrInvalidBody
+	}
+
+	return items, nil
+}
+
+func peekNonSpace(r *bufio.Reader) (byte, error) {
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+
+		switch b {
+		case ' ', '\t', '\r', '\n':
+			continue
+		}
+
+		return b, r.UnreadByte()
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"net/http"
 
 	"horizonx/internal/adapters/http/middleware"
+	"horizonx/internal/adapters/http/response"
 	"horizonx/internal/adapters/ws/agentws"
 	"horizonx/internal/adapters/ws/userws"
 	"horizonx/internal/config"
@@ -24,9 +25,19 @@ type RouterDeps struct {
 	Metrics     *MetricsHandler
 	Application *ApplicationHandler
 	Deployment  *DeploymentHandler
+	Schedule    *ScheduleHandler
 
-	RoleService   domain.RoleService
-	ServerService domain.ServerService
+	ContainerLog       *ContainerLogHandler
+	GitCredential      *GitCredentialHandler
+	RegistryCredential *RegistryCredentialHandler
+	Webhook            *WebhookHandler
+	GitPoll            *GitPollHandler
+
+	RoleService       domain.RoleService
+	ServerService     domain.ServerService
+	AgentEventService domain.AgentEventService
+
+	Writer response.ResponseWriter
 }
 
 func NewRouter(cfg *config.Config, deps *RouterDeps) http.Handler {
@@ -42,6 +53,8 @@ func NewRouter(cfg *config.Config, deps *RouterDeps) http.Handler {
 	agentStack := middleware.New()
 	agentStack.Use(middleware.Agent(deps.ServerService))
 
+	agentEventStack := agentStack.Extend(middleware.Idempotent(deps.AgentEventService, deps.Writer))
+
 	metricsReadStack := userStack.Extend(middleware.Permission(deps.RoleService, domain.PermMetricsRead))
 
 	serverReadStack := userStack.Extend(middleware.Permission(deps.RoleService, domain.PermServerRead))
@@ -52,6 +65,7 @@ func NewRouter(cfg *config.Config, deps *RouterDeps) http.Handler {
 
 	appReadStack := userStack.Extend(middleware.Permission(deps.RoleService, domain.PermAppRead))
 	appWriteStack := userStack.Extend(middleware.Permission(deps.RoleService, domain.PermAppWrite))
+	appSecretsReadStack := userStack.Extend(middleware.Permission(deps.RoleService, domain.PermAppSecretsRead))
 
 	// HEALTH
 	mux.HandleFunc("GET /health", func(w http.ResponseWriter, r *http.Request) {
@@ -67,21 +81,28 @@ func NewRouter(cfg *config.Config, deps *RouterDeps) http.Handler {
 	mux.Handle("POST /auth/login", http.HandlerFunc(deps.Auth.Login))
 	mux.Handle("POST /auth/logout", userStack.ThenFunc(deps.Auth.Logout))
 
+	// GIT WEBHOOKS
+	mux.Handle("POST /webhooks/git/{application_id}", http.HandlerFunc(deps.Webhook.Receive))
+
 	// AGENT ENDPOINTS
-	mux.Handle("POST /agent/logs", agentStack.ThenFunc(deps.Log.Store))
-	mux.Handle("GET /agent/jobs", agentStack.ThenFunc(deps.Job.Pending))
-	mux.Handle("POST /agent/jobs/{id}/start", agentStack.ThenFunc(deps.Job.Start))
-	mux.Handle("POST /agent/jobs/{id}/finish", agentStack.ThenFunc(deps.Job.Finish))
+	mux.Handle("POST /agent/logs", agentEventStack.ThenFunc(deps.Log.Store))
+	mux.Handle("POST /agent/logs/batch", agentEventStack.ThenFunc(deps.Log.StoreBatch))
+	mux.Handle("POST /agent/jobs/claim", agentStack.ThenFunc(deps.Job.Claim))
+	mux.Handle("POST /agent/jobs/{id}/heartbeat", agentStack.ThenFunc(deps.Job.Heartbeat))
+	mux.Handle("POST /agent/jobs/{id}/finish", agentEventStack.ThenFunc(deps.Job.Finish))
 	mux.Handle("POST /agent/metrics", agentStack.ThenFunc(deps.Metrics.Ingest))
 	mux.Handle("POST /agent/applications/health", agentStack.ThenFunc(deps.Application.ReportHealth))
-	mux.Handle("POST /agent/deployments/{id}/commit-info", agentStack.ThenFunc(deps.Deployment.UpdateCommitInfo))
+	mux.Handle("POST /agent/applications/git-heads", agentStack.ThenFunc(deps.GitPoll.ReportHeads))
+	mux.Handle("POST /agent/deployments/{id}/commit-info", agentEventStack.ThenFunc(deps.Deployment.UpdateCommitInfo))
 
 	// LOGS
 	mux.Handle("GET /logs", userStack.ThenFunc(deps.Log.Index))
 
 	// JOBS
-	mux.Handle("GET /jobs", userStack.ThenFunc(deps.Job.Index))
-	mux.Handle("GET /jobs/{id}", userStack.ThenFunc(deps.Job.Show))
+	mux.Handle("GET /jobs", appReadStack.ThenFunc(deps.Job.Index))
+	mux.Handle("GET /jobs/{id}", appReadStack.ThenFunc(deps.Job.Show))
+	mux.Handle("GET /jobs/{id}/attempts", appReadStack.ThenFunc(deps.Job.Attempts))
+	mux.Handle("POST /jobs/{id}/cancel", appWriteStack.ThenFunc(deps.Job.Cancel))
 
 	// SERVERS
 	mux.Handle("GET /servers", serverReadStack.ThenFunc(deps.Server.Index))
@@ -117,14 +138,49 @@ func NewRouter(cfg *config.Config, deps *RouterDeps) http.Handler {
 	mux.Handle("POST /applications/{id}/stop", appWriteStack.ThenFunc(deps.Application.Stop))
 	mux.Handle("POST /applications/{id}/restart", appWriteStack.ThenFunc(deps.Application.Restart))
 
+	// APPLICATION WEBHOOK
+	mux.Handle("POST /applications/{id}/webhook", appWriteStack.ThenFunc(deps.Webhook.Enable))
+	mux.Handle("DELETE /applications/{id}/webhook", appWriteStack.ThenFunc(deps.Webhook.Disable))
+
+	// APPLICATION METRICS
+	mux.Handle("GET /applications/{id}/metrics/latest", metricsReadStack.ThenFunc(deps.Metrics.AppLatest))
+	mux.Handle("GET /applications/{id}/metrics/cpu-usage-history", metricsReadStack.ThenFunc(deps.Metrics.AppCPUUsageHistory))
+	mux.Handle("GET /applications/{id}/metrics/memory-usage-history", metricsReadStack.ThenFunc(deps.Metrics.AppMemoryUsageHistory))
+	mux.Handle("GET /applications/{id}/metrics/net-speed-history", metricsReadStack.ThenFunc(deps.Metrics.AppNetSpeedHistory))
+
 	// DEPLOYMENTS
 	mux.Handle("GET /applications/{id}/deployments", appReadStack.ThenFunc(deps.Deployment.Index))
 	mux.Handle("GET /applications/{id}/deployments/{deployment_id}", appReadStack.ThenFunc(deps.Deployment.Show))
 
+	// CONTAINER LOGS
+	mux.Handle("GET /applications/{id}/container-logs", appReadStack.ThenFunc(deps.ContainerLog.Index))
+
+	// SCHEDULES
+	mux.Handle("GET /applications/{id}/schedules", appWriteStack.ThenFunc(deps.Schedule.Index))
+	mux.Handle("POST /applications/{id}/schedules", appWriteStack.ThenFunc(deps.Schedule.Store))
+	mux.Handle("GET /applications/{id}/schedules/{schedule_id}", appWriteStack.ThenFunc(deps.Schedule.Show))
+	mux.Handle("PUT /applications/{id}/schedules/{schedule_id}", appWriteStack.ThenFunc(deps.Schedule.Update))
+	mux.Handle("DELETE /applications/{id}/schedules/{schedule_id}", appWriteStack.ThenFunc(deps.Schedule.Destroy))
+
+	// GIT CREDENTIALS
+	mux.Handle("GET /git-credentials", appReadStack.ThenFunc(deps.GitCredential.Index))
+	mux.Handle("POST /git-credentials", appWriteStack.ThenFunc(deps.GitCredential.Store))
+	mux.Handle("GET /git-credentials/{id}", appReadStack.ThenFunc(deps.GitCredential.Show))
+	mux.Handle("PUT /git-credentials/{id}", appWriteStack.ThenFunc(deps.GitCredential.Update))
+	mux.Handle("DELETE /git-credentials/{id}", appWriteStack.ThenFunc(deps.GitCredential.Destroy))
+
+	// REGISTRY CREDENTIALS
+	mux.Handle("GET /registry-credentials", appReadStack.ThenFunc(deps.RegistryCredential.Index))
+	mux.Handle("POST /registry-credentials", appWriteStack.ThenFunc(deps.RegistryCredential.Store))
+	mux.Handle("GET /registry-credentials/{id}", appReadStack.ThenFunc(deps.RegistryCredential.Show))
+	mux.Handle("PUT /registry-credentials/{id}", appWriteStack.ThenFunc(deps.RegistryCredential.Update))
+	mux.Handle("DELETE /registry-credentials/{id}", appWriteStack.ThenFunc(deps.RegistryCredential.Destroy))
+
 	// ENVIRONMENT VARIABLES
 	mux.Handle("POST /applications/{id}/env", appWriteStack.ThenFunc(deps.Application.AddEnvVar))
 	mux.Handle("PUT /applications/{id}/env/{key}", appWriteStack.ThenFunc(deps.Application.UpdateEnvVar))
 	mux.Handle("DELETE /applications/{id}/env/{key}", appWriteStack.ThenFunc(deps.Application.DeleteEnvVar))
+	mux.Handle("POST /applications/{id}/env/{key}/reveal", appSecretsReadStack.ThenFunc(deps.Application.RevealEnvVar))
 
 	return globalMw.Apply(mux)
 }
@@ -0,0 +1,214 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"horizonx/internal/adapters/http/middleware"
+	"horizonx/internal/adapters/http/request"
+	"horizonx/internal/adapters/http/response"
+	"horizonx/internal/adapters/http/validator"
+	"horizonx/internal/domain"
+)
+
+type ScheduleHandler struct {
+	svc domain.ScheduleService
+
+	decoder   request.RequestDecoder
+	writer    response.ResponseWriter
+	validator validator.Validator
+}
+
+func NewScheduleHandler(
+	svc domain.ScheduleService,
+	d request.RequestDecoder,
+	w response.ResponseWriter,
+	v validator.Validator,
+) *ScheduleHandler {
+	return &ScheduleHandler{
+		svc:       svc,
+		decoder:   d,
+		writer:    w,
+		validator: v,
+	}
+}
+
+func (h *ScheduleHandler) Index(w http.ResponseWriter, r *http.Request) {
+	appID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		h.writer.Write(w, http.StatusBadRequest, &response.Response{
+			Message: "invalid application id",
+		})
+		return
+	}
+
+	schedules, err := h.svc.List(r.Context(), appID)
+	if err != nil {
+		if errors.Is(err, domain.ErrApplicationNotFound) {
+			h.writer.Write(w, http.StatusNotFound, &response.Response{
+				Message: "application not found",
+			})
+			return
+		}
+		h.writer.Write(w, http.StatusInternalServerError, &response.Response{
+			Message: "failed to list schedules",
+		})
+		return
+	}
+
+	h.writer.Write(w, http.StatusOK, &response.Response{
+		Data: schedules,
+	})
+}
+
+func (h *ScheduleHandler) Show(w http.ResponseWriter, r *http.Request) {
+	appID, scheduleID, ok := h.parseIDs(w, r)
+	if !ok {
+		return
+	}
+
+	schedule, err := h.svc.GetByID(r.Context(), appID, scheduleID)
+	if err != nil {
+		h.writeError(w, err, "failed to get schedule")
+		return
+	}
+
+	h.writer.Write(w, http.StatusOK, &response.Response{
+		Data: schedule,
+	})
+}
+
+func (h *ScheduleHandler) Store(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	userCtx, ok := middleware.GetUser(r.Context())
+	if !ok {
+		h.writer.Write(w, http.StatusUnauthorized, &response.Response{
+			Message: "unauthorized",
+		})
+		return
+	}
+
+	appID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		h.writer.Write(w, http.StatusBadRequest, &response.Response{
+			Message: "invalid application id",
+		})
+		return
+	}
+
+	var req domain.ScheduleRequest
+	if err := h.decoder.Decode(r, &req); err != nil {
+		h.writer.Write(w, http.StatusBadRequest, &response.Response{
+			Message: err.Error(),
+		})
+		return
+	}
+
+	if errs := h.validator.Validate(&req); len(errs) > 0 {
+		h.writer.WriteValidationError(w, errs)
+		return
+	}
+
+	schedule, err := h.svc.Create(r.Context(), appID, req, userCtx.ID)
+	if err != nil {
+		h.writeError(w, err, "failed to create schedule")
+		return
+	}
+
+	h.writer.Write(w, http.StatusCreated, &response.Response{
+		Message: "schedule created successfully",
+		Data:    schedule,
+	})
+}
+
+func (h *ScheduleHandler) Update(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	appID, scheduleID, ok := h.parseIDs(w, r)
+	if !ok {
+		return
+	}
+
+	var req domain.ScheduleRequest
+	if err := h.decoder.Decode(r, &req); err != nil {
+		h.writer.Write(w, http.StatusBadRequest, &response.Response{
+			Message: err.Error(),
+		})
+		return
+	}
+
+	if errs := h.validator.Validate(&req); len(errs) > 0 {
+		h.writer.WriteValidationError(w, errs)
+		return
+	}
+
+	schedule, err := h.svc.Update(r.Context(), appID, scheduleID, req)
+	if err != nil {
+		h.writeError(w, err, "failed to update schedule")
+		return
+	}
+
+	h.writer.Write(w, http.StatusOK, &response.Response{
+		Message: "schedule updated successfully",
+		Data:    schedule,
+	})
+}
+
+func (h *ScheduleHandler) Destroy(w http.ResponseWriter, r *http.Request) {
+	appID, scheduleID, ok := h.parseIDs(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.svc.Delete(r.Context(), appID, scheduleID); err != nil {
+		h.writeError(w, err, "failed to delete schedule")
+		return
+	}
+
+	h.writer.Write(w, http.StatusOK, &response.Response{
+		Message: "schedule deleted successfully",
+	})
+}
+
+func (h *ScheduleHandler) parseIDs(w http.ResponseWriter, r *http.Request) (int64, int64, bool) {
+	appID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		h.writer.Write(w, http.StatusBadRequest, &response.Response{
+			Message: "invalid application id",
+		})
+		return 0, 0, false
+	}
+
+	scheduleID, err := strconv.ParseInt(r.PathValue("schedule_id"), 10, 64)
+	if err != nil {
+		h.writer.Write(w, http.StatusBadRequest, &response.Response{
+			Message: "invalid schedule id",
+		})
+		return 0, 0, false
+	}
+
+	return appID, scheduleID, true
+}
+
+func (h *ScheduleHandler) writeError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, domain.ErrApplicationNotFound):
+		h.writer.Write(w, http.StatusNotFound, &response.Response{
+			Message: "application not found",
+		})
+	case errors.Is(err, domain.ErrScheduleNotFound):
+		h.writer.Write(w, http.StatusNotFound, &response.Response{
+			Message: "schedule not found",
+		})
+	case errors.Is(err, domain.ErrInvalidSchedule):
+		h.writer.Write(w, http.StatusUnprocessableEntity, &response.Response{
+			Message: err.Error(),
+		})
+	default:
+		h.writer.Write(w, http.StatusInternalServerError, &response.Response{
+			Message: fallback,
+		})
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"reflect"
 	"strings"
 
+	"horizonx/internal/domain"
+
 	"github.com/go-playground/validator/v10"
 )
 
@@ -18,8 +20,23 @@ type DefaultValidator struct {
 }
 
 func NewValidator() Validator {
+	validate := validator.New()
+
+	validate.RegisterValidation("compose_file", func(fl validator.FieldLevel) bool {
+		return domain.ValidComposeFile(fl.Field().String())
+	})
+	validate.RegisterValidation("compose_profile", func(fl validator.FieldLevel) bool {
+		return domain.ValidComposeProfile(fl.Field().String())
+	})
+	validate.RegisterValidation("compose_project", func(fl validator.FieldLevel) bool {
+		return domain.ValidComposeProject(fl.Field().String())
+	})
+	validate.RegisterValidation("image_ref", func(fl validator.FieldLevel) bool {
+		return domain.ValidImageRef(fl.Field().String())
+	})
+
 	return &DefaultValidator{
-		validate: validator.New(),
+		validate: validate,
 	}
 }
 
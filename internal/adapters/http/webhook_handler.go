@@ -0,0 +1,157 @@
+package http
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"horizonx/internal/adapters/http/response"
+	"horizonx/internal/domain"
+	"horizonx/internal/gitwebhook"
+)
+
+// webhookMaxBody is well above the push payloads providers send, GitHub
+// caps them at 25 MB but lists at most 20 commits.
+const webhookMaxBody = 5 << 20
+
+type WebhookHandler struct {
+	svc domain.GitWebhookService
+
+	writer response.ResponseWriter
+}
+
+func NewWebhookHandler(
+	svc domain.GitWebhookService,
+	w response.ResponseWriter,
+) *WebhookHandler {
+	return &WebhookHandler{
+		svc:    svc,
+		writer: w,
+	}
+}
+
+// Receive takes push deliveries from git providers. It is not behind the
+// user middleware, the signature of the delivery authenticates it.
+func (h *WebhookHandler) Receive(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	appID, err := strconv.ParseInt(r.PathValue("application_id"), 10, 64)
+	if err != nil {
+		h.writer.Write(w, http.StatusBadRequest, &response.Response{
+			Message: "invalid application id",
+		})
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, webhookMaxBody))
+	if err != nil {
+		h.writer.Write(w, http.StatusRequestEntityTooLarge, &response.Response{
+			Message: "payload too large",
+		})
+		return
+	}
+
+	hook, err := gitwebhook.FromHeader(r.Header, body)
+	if err != nil {
+		h.writeError(w, err, "failed to read webhook")
+		return
+	}
+
+	result, err := h.svc.Receive(r.Context(), appID, hook)
+	if err != nil {
+		h.writeError(w, err, "failed to handle webhook")
+		return
+	}
+
+	if result.Deployment == nil {
+		h.writer.Write(w, http.StatusOK, &response.Response{
+			Message: result.Ignored,
+		})
+		return
+	}
+
+	h.writer.Write(w, http.StatusAccepted, &response.Response{
+		Message: "deployment started",
+		Data:    result.Deployment,
+	})
+}
+
+// Enable creates the webhook secret of an application, or rotates it. The
+// secret is only shown in this response.
+func (h *WebhookHandler) Enable(w http.ResponseWriter, r *http.Request) {
+	appID, ok := h.parseID(w, r)
+	if !ok {
+		return
+	}
+
+	secret, err := h.svc.Enable(r.Context(), appID)
+	if err != nil {
+		h.writeError(w, err, "failed to enable webhook")
+		return
+	}
+
+	h.writer.Write(w, http.StatusOK, &response.Response{
+		Message: "webhook enabled, the secret is only shown once",
+		Data:    secret,
+	})
+}
+
+func (h *WebhookHandler) Disable(w http.ResponseWriter, r *http.Request) {
+	appID, ok := h.parseID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.svc.Disable(r.Context(), appID); err != nil {
+		h.writeError(w, err, "failed to disable webhook")
+		return
+	}
+
+	h.writer.Write(w, http.StatusOK, &response.Response{
+		Message: "webhook disabled",
+	})
+}
+
+func (h *WebhookHandler) parseID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	appID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		h.writer.Write(w, http.StatusBadRequest, &response.Response{
+			Message: "invalid application id",
+		})
+		return 0, false
+	}
+
+	return appID, true
+}
+
+// writeError answers a missing application and a disabled webhook alike, a
+// caller without the secret learns nothing about the application.
+func (h *WebhookHandler) writeError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, domain.ErrApplicationNotFound), errors.Is(err, domain.ErrWebhookDisabled):
+		h.writer.Write(w, http.StatusNotFound, &response.Response{
+			Message: "webhook not found",
+		})
+	case errors.Is(err, domain.ErrInvalidWebhookSignature):
+		h.writer.Write(w, http.StatusUnauthorized, &response.Response{
+			Message: err.Error(),
+		})
+	case errors.Is(err, domain.ErrUnknownWebhookProvider), errors.Is(err, domain.ErrInvalidWebhookPayload):
+		h.writer.Write(w, http.StatusBadRequest, &response.Response{
+			Message: err.Error(),
+		})
+	case errors.Is(err, domain.ErrInvalidApplicationSource):
+		h.writer.Write(w, http.StatusUnprocessableEntity, &response.Response{
+			Message: err.Error(),
+		})
+	case errors.Is(err, domain.ErrSecretsDisabled):
+		h.writer.Write(w, http.StatusServiceUnavailable, &response.Response{
+			Message: err.Error(),
+		})
+	default:
+		h.writer.Write(w, http.StatusInternalServerError, &response.Response{
+			Message: fallback,
+		})
+	}
+}
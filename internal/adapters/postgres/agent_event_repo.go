@@ -0,0 +1,56 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"horizonx/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type AgentEventRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewAgentEventRepository(db *pgxpool.Pool) domain.AgentEventRepository {
+	return &AgentEventRepository{db: db}
+}
+
+func (r *AgentEventRepository) Exists(ctx context.Context, eventID uuid.UUID) (bool, error) {
+	query := `SELECT EXISTS (SELECT 1 FROM agent_events WHERE event_id = $1)`
+
+	var exists bool
+	if err := r.db.QueryRow(ctx, query, eventID).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to look up agent event: %w", err)
+	}
+
+	return exists, nil
+}
+
+func (r *AgentEventRepository) Record(ctx context.Context, serverID uuid.UUID, eventID uuid.UUID) error {
+	query := `
+		INSERT INTO agent_events (event_id, server_id)
+		VALUES ($1, $2)
+		ON CONFLICT (event_id) DO NOTHING
+	`
+
+	if _, err := r.db.Exec(ctx, query, eventID, serverID); err != nil {
+		return fmt.Errorf("failed to record agent event: %w", err)
+	}
+
+	return nil
+}
+
+func (r *AgentEventRepository) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	query := `DELETE FROM agent_events WHERE received_at < $1`
+
+	ct, err := r.db.Exec(ctx, query, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune agent events: %w", err)
+	}
+
+	return ct.RowsAffected(), nil
+}
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"horizonx/internal/domain"
+	"horizonx/internal/secret"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
@@ -15,11 +16,14 @@ import (
 )
 
 type ApplicationRepository struct {
-	db *pgxpool.Pool
+	db  *pgxpool.Pool
+	box *secret.Box
 }
 
-func NewApplicationRepository(db *pgxpool.Pool) domain.ApplicationRepository {
-	return &ApplicationRepository{db: db}
+// NewApplicationRepository encrypts env var values with box, a nil box
+// stores them in plain text.
+func NewApplicationRepository(db *pgxpool.Pool, box *secret.Box) domain.ApplicationRepository {
+	return &ApplicationRepository{db: db, box: box}
 }
 
 func (r *ApplicationRepository) List(ctx context.Context, opts domain.ApplicationListOptions) ([]*domain.Application, int64, error) {
@@ -28,10 +32,24 @@ func (r *ApplicationRepository) List(ctx context.Context, opts domain.Applicatio
 			id,
 			server_id,
 			name,
+			source,
 			repo_url,
 			branch,
+			git_credential_id,
 			status,
 			last_deployment_at,
+			job_timeouts,
+			deploy_strategy,
+			auto_rollback,
+			rollback_window_minutes,
+			webhook_secret IS NOT NULL,
+			git_poll_interval_seconds,
+			git_poll_debounce_seconds,
+			compose_path,
+			compose_overrides,
+			compose_profiles,
+			compose_project_name,
+			registry_credential_id,
 			created_at,
 			updated_at
 		FROM applications
@@ -93,10 +111,24 @@ func (r *ApplicationRepository) List(ctx context.Context, opts domain.Applicatio
 			&a.ID,
 			&a.ServerID,
 			&a.Name,
+			&a.Source,
 			&a.RepoURL,
 			&a.Branch,
+			&a.GitCredentialID,
 			&a.Status,
 			&a.LastDeploymentAt,
+			&a.JobTimeouts,
+			&a.DeployStrategy,
+			&a.AutoRollback,
+			&a.RollbackWindowMinutes,
+			&a.WebhookEnabled,
+			&a.GitPollIntervalSeconds,
+			&a.GitPollDebounceSeconds,
+			&a.ComposePath,
+			&a.ComposeOverrides,
+			&a.ComposeProfiles,
+			&a.ComposeProjectName,
+			&a.RegistryCredentialID,
 			&a.CreatedAt,
 			&a.UpdatedAt,
 		); err != nil {
@@ -115,7 +147,10 @@ func (r *ApplicationRepository) List(ctx context.Context, opts domain.Applicatio
 
 func (r *ApplicationRepository) GetByID(ctx context.Context, appID int64) (*domain.Application, error) {
 	query := `
-		SELECT id, server_id, name, repo_url, branch, status, last_deployment_at, created_at, updated_at
+		SELECT id, server_id, name, source, repo_url, branch, git_credential_id, status, last_deployment_at, job_timeouts, deploy_strategy,
+			auto_rollback, rollback_window_minutes, webhook_secret IS NOT NULL, git_poll_interval_seconds, git_poll_debounce_seconds,
+			compose_path, compose_overrides, compose_profiles, compose_project_name, compose_file, registry_credential_id,
+			services, health_checked_at, created_at, updated_at
 		FROM applications
 		WHERE id = $1 AND deleted_at IS NULL
 	`
@@ -125,10 +160,27 @@ func (r *ApplicationRepository) GetByID(ctx context.Context, appID int64) (*doma
 		&app.ID,
 		&app.ServerID,
 		&app.Name,
+		&app.Source,
 		&app.RepoURL,
 		&app.Branch,
+		&app.GitCredentialID,
 		&app.Status,
 		&app.LastDeploymentAt,
+		&app.JobTimeouts,
+		&app.DeployStrategy,
+		&app.AutoRollback,
+		&app.RollbackWindowMinutes,
+		&app.WebhookEnabled,
+		&app.GitPollIntervalSeconds,
+		&app.GitPollDebounceSeconds,
+		&app.ComposePath,
+		&app.ComposeOverrides,
+		&app.ComposeProfiles,
+		&app.ComposeProjectName,
+		&app.ComposeFile,
+		&app.RegistryCredentialID,
+		&app.Services,
+		&app.HealthCheckedAt,
 		&app.CreatedAt,
 		&app.UpdatedAt,
 	)
@@ -144,8 +196,13 @@ func (r *ApplicationRepository) GetByID(ctx context.Context, appID int64) (*doma
 
 func (r *ApplicationRepository) Create(ctx context.Context, app *domain.Application) (*domain.Application, error) {
 	query := `
-		INSERT INTO applications (server_id, name, repo_url, branch, status, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		INSERT INTO applications (
+			server_id, name, repo_url, branch, git_credential_id, status, job_timeouts, deploy_strategy,
+			auto_rollback, rollback_window_minutes, git_poll_interval_seconds, git_poll_debounce_seconds,
+			compose_path, compose_overrides, compose_profiles, compose_project_name,
+			source, compose_file, registry_credential_id, created_at, updated_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21)
 		RETURNING id, created_at, updated_at
 	`
 
@@ -156,7 +213,21 @@ func (r *ApplicationRepository) Create(ctx context.Context, app *domain.Applicat
 		app.Name,
 		app.RepoURL,
 		app.Branch,
+		app.GitCredentialID,
 		domain.AppStatusUnknown,
+		jobTimeoutsOrEmpty(app.JobTimeouts),
+		app.DeployStrategy,
+		app.AutoRollback,
+		app.RollbackWindowMinutes,
+		app.GitPollIntervalSeconds,
+		app.GitPollDebounceSeconds,
+		app.ComposePath,
+		stringsOrEmpty(app.ComposeOverrides),
+		stringsOrEmpty(app.ComposeProfiles),
+		app.ComposeProjectName,
+		app.Source,
+		app.ComposeFile,
+		app.RegistryCredentialID,
 		now,
 		now,
 	).Scan(&app.ID, &app.CreatedAt, &app.UpdatedAt)
@@ -170,8 +241,11 @@ func (r *ApplicationRepository) Create(ctx context.Context, app *domain.Applicat
 func (r *ApplicationRepository) Update(ctx context.Context, app *domain.Application, appID int64) error {
 	query := `
 		UPDATE applications
-		SET name = $1, repo_url = $2, branch = $3, updated_at = $4
-		WHERE id = $5 AND deleted_at IS NULL
+		SET name = $1, repo_url = $2, branch = $3, git_credential_id = $4, job_timeouts = $5, deploy_strategy = $6,
+			auto_rollback = $7, rollback_window_minutes = $8, git_poll_interval_seconds = $9, git_poll_debounce_seconds = $10,
+			compose_path = $11, compose_overrides = $12, compose_profiles = $13, compose_project_name = $14,
+			compose_file = $15, registry_credential_id = $16, updated_at = $17
+		WHERE id = $18 AND deleted_at IS NULL
 	`
 
 	now := time.Now().UTC()
@@ -179,6 +253,19 @@ func (r *ApplicationRepository) Update(ctx context.Context, app *domain.Applicat
 		app.Name,
 		app.RepoURL,
 		app.Branch,
+		app.GitCredentialID,
+		jobTimeoutsOrEmpty(app.JobTimeouts),
+		app.DeployStrategy,
+		app.AutoRollback,
+		app.RollbackWindowMinutes,
+		app.GitPollIntervalSeconds,
+		app.GitPollDebounceSeconds,
+		app.ComposePath,
+		stringsOrEmpty(app.ComposeOverrides),
+		stringsOrEmpty(app.ComposeProfiles),
+		app.ComposeProjectName,
+		app.ComposeFile,
+		app.RegistryCredentialID,
 		now,
 		appID,
 	)
@@ -265,25 +352,36 @@ func (r *ApplicationRepository) SyncEnvVars(ctx context.Context, appID int64, en
 	for _, e := range envVars {
 		keys = append(keys, e.Key)
 
+		plain, sealed, err := r.sealEnvValue(appID, e.Key, e.Value)
+		if err != nil {
+			return err
+		}
+
 		batch.Queue(`
 			INSERT INTO environment_variables (
 				application_id,
 				key,
 				value,
+				encrypted_value,
+				data_key,
 				is_preview,
 				created_at,
 				updated_at
 			)
-			VALUES ($1, $2, $3, $4, $5, $6)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 			ON CONFLICT (application_id, key)
 			DO UPDATE SET
 				value = EXCLUDED.value,
+				encrypted_value = EXCLUDED.encrypted_value,
+				data_key = EXCLUDED.data_key,
 				is_preview = EXCLUDED.is_preview,
 				updated_at = EXCLUDED.updated_at
 		`,
 			appID,
 			e.Key,
-			e.Value,
+			plain,
+			sealed.Ciphertext,
+			sealed.DataKey,
 			e.IsPreview,
 			now,
 			now,
@@ -316,9 +414,18 @@ func (r *ApplicationRepository) SyncEnvVars(ctx context.Context, appID int64, en
 	return nil
 }
 
+// ListEnvVars leaves encrypted values out, only ListEnvVarValues decrypts.
 func (r *ApplicationRepository) ListEnvVars(ctx context.Context, appID int64) ([]domain.EnvironmentVariable, error) {
 	query := `
-		SELECT id, application_id, key, value, is_preview, created_at, updated_at
+		SELECT
+			id,
+			application_id,
+			key,
+			COALESCE(value, ''),
+			encrypted_value IS NOT NULL,
+			is_preview,
+			created_at,
+			updated_at
 		FROM environment_variables
 		WHERE application_id = $1
 		ORDER BY key ASC
@@ -338,6 +445,7 @@ func (r *ApplicationRepository) ListEnvVars(ctx context.Context, appID int64) ([
 			&env.ApplicationID,
 			&env.Key,
 			&env.Value,
+			&env.Encrypted,
 			&env.IsPreview,
 			&env.CreatedAt,
 			&env.UpdatedAt,
@@ -351,20 +459,122 @@ func (r *ApplicationRepository) ListEnvVars(ctx context.Context, appID int64) ([
 	return envVars, nil
 }
 
+func (r *ApplicationRepository) ListEnvVarValues(ctx context.Context, appID int64) (map[string]string, error) {
+	query := `
+		SELECT key, value, encrypted_value, data_key
+		FROM environment_variables
+		WHERE application_id = $1
+	`
+
+	rows, err := r.db.Query(ctx, query, appID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query env vars: %w", err)
+	}
+	defer rows.Close()
+
+	values := make(map[string]string)
+	for rows.Next() {
+		var (
+			key    string
+			plain  *string
+			sealed secret.Envelope
+		)
+		if err := rows.Scan(&key, &plain, &sealed.Ciphertext, &sealed.DataKey); err != nil {
+			return nil, fmt.Errorf("failed to scan env var: %w", err)
+		}
+
+		if plain != nil {
+			values[key] = *plain
+			continue
+		}
+
+		if r.box == nil {
+			return nil, fmt.Errorf("env var %s is encrypted: %w", key, domain.ErrSecretsDisabled)
+		}
+		value, err := r.box.OpenEnvelope(&sealed, envVarAAD(appID, key))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt env var %s: %w", key, err)
+		}
+		values[key] = string(value)
+	}
+
+	return values, rows.Err()
+}
+
+func (r *ApplicationRepository) GetEnvVar(ctx context.Context, appID int64, key string) (*domain.EnvironmentVariable, error) {
+	query := `
+		SELECT id, application_id, key, value, encrypted_value, data_key, is_preview, created_at, updated_at
+		FROM environment_variables
+		WHERE application_id = $1 AND key = $2
+	`
+
+	var (
+		env    domain.EnvironmentVariable
+		plain  *string
+		sealed secret.Envelope
+	)
+	err := r.db.QueryRow(ctx, query, appID, key).Scan(
+		&env.ID,
+		&env.ApplicationID,
+		&env.Key,
+		&plain,
+		&sealed.Ciphertext,
+		&sealed.DataKey,
+		&env.IsPreview,
+		&env.CreatedAt,
+		&env.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrEnvVarNotFound
+		}
+		return nil, fmt.Errorf("failed to get env var: %w", err)
+	}
+
+	if plain != nil {
+		env.Value = *plain
+		return &env, nil
+	}
+
+	if r.box == nil {
+		return nil, fmt.Errorf("env var %s is encrypted: %w", key, domain.ErrSecretsDisabled)
+	}
+	value, err := r.box.OpenEnvelope(&sealed, envVarAAD(appID, key))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt env var %s: %w", key, err)
+	}
+	env.Value = string(value)
+	env.Encrypted = true
+
+	return &env, nil
+}
+
 func (r *ApplicationRepository) CreateEnvVar(ctx context.Context, env *domain.EnvironmentVariable) error {
 	query := `
-		INSERT INTO environment_variables (application_id, key, value, is_preview, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6)
+		INSERT INTO environment_variables (application_id, key, value, encrypted_value, data_key, is_preview, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 		ON CONFLICT (application_id, key) 
-		DO UPDATE SET value = EXCLUDED.value, is_preview = EXCLUDED.is_preview, updated_at = EXCLUDED.updated_at
+		DO UPDATE SET
+			value = EXCLUDED.value,
+			encrypted_value = EXCLUDED.encrypted_value,
+			data_key = EXCLUDED.data_key,
+			is_preview = EXCLUDED.is_preview,
+			updated_at = EXCLUDED.updated_at
 		RETURNING id, created_at, updated_at
 	`
 
+	plain, sealed, err := r.sealEnvValue(env.ApplicationID, env.Key, env.Value)
+	if err != nil {
+		return err
+	}
+
 	now := time.Now().UTC()
-	err := r.db.QueryRow(ctx, query,
+	err = r.db.QueryRow(ctx, query,
 		env.ApplicationID,
 		env.Key,
-		env.Value,
+		plain,
+		sealed.Ciphertext,
+		sealed.DataKey,
 		env.IsPreview,
 		now,
 		now,
@@ -379,12 +589,19 @@ func (r *ApplicationRepository) CreateEnvVar(ctx context.Context, env *domain.En
 func (r *ApplicationRepository) UpdateEnvVar(ctx context.Context, env *domain.EnvironmentVariable) error {
 	query := `
 		UPDATE environment_variables
-		SET value = $1, is_preview = $2, updated_at = $3
-		WHERE application_id = $4 AND key = $5
+		SET value = $1, encrypted_value = $2, data_key = $3, is_preview = $4, updated_at = $5
+		WHERE application_id = $6 AND key = $7
 	`
 
+	plain, sealed, err := r.sealEnvValue(env.ApplicationID, env.Key, env.Value)
+	if err != nil {
+		return err
+	}
+
 	ct, err := r.db.Exec(ctx, query,
-		env.Value,
+		plain,
+		sealed.Ciphertext,
+		sealed.DataKey,
 		env.IsPreview,
 		time.Now().UTC(),
 		env.ApplicationID,
@@ -395,12 +612,33 @@ func (r *ApplicationRepository) UpdateEnvVar(ctx context.Context, env *domain.En
 	}
 
 	if ct.RowsAffected() == 0 {
-		return fmt.Errorf("env var not found")
+		return domain.ErrEnvVarNotFound
 	}
 
 	return nil
 }
 
+// sealEnvValue returns what an env var value is stored as: the value itself
+// without a box, otherwise an envelope and a nil value.
+func (r *ApplicationRepository) sealEnvValue(appID int64, key string, value string) (*string, secret.Envelope, error) {
+	if r.box == nil {
+		return &value, secret.Envelope{}, nil
+	}
+
+	sealed, err := r.box.SealEnvelope([]byte(value), envVarAAD(appID, key))
+	if err != nil {
+		return nil, secret.Envelope{}, fmt.Errorf("failed to encrypt env var: %w", err)
+	}
+
+	return nil, *sealed, nil
+}
+
+// envVarAAD binds a sealed env var to its application and key, the row id
+// is not known before the upsert.
+func envVarAAD(appID int64, key string) []byte {
+	return secret.AAD("environment_variables", "encrypted_value", fmt.Sprintf("%d/%s", appID, key))
+}
+
 func (r *ApplicationRepository) DeleteEnvVar(ctx context.Context, appID int64, key string) error {
 	query := `DELETE FROM environment_variables WHERE application_id = $1 AND key = $2`
 
@@ -410,7 +648,40 @@ func (r *ApplicationRepository) DeleteEnvVar(ctx context.Context, appID int64, k
 	}
 
 	if ct.RowsAffected() == 0 {
-		return fmt.Errorf("env var not found")
+		return domain.ErrEnvVarNotFound
+	}
+
+	return nil
+}
+
+func (r *ApplicationRepository) GetWebhookSecret(ctx context.Context, appID int64) ([]byte, error) {
+	query := `SELECT webhook_secret FROM applications WHERE id = $1 AND deleted_at IS NULL`
+
+	var secret []byte
+	if err := r.db.QueryRow(ctx, query, appID).Scan(&secret); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrApplicationNotFound
+		}
+		return nil, fmt.Errorf("failed to get webhook secret: %w", err)
+	}
+
+	return secret, nil
+}
+
+func (r *ApplicationRepository) SetWebhookSecret(ctx context.Context, appID int64, encrypted []byte) error {
+	query := `
+		UPDATE applications
+		SET webhook_secret = $1, updated_at = $2
+		WHERE id = $3 AND deleted_at IS NULL
+	`
+
+	ct, err := r.db.Exec(ctx, query, encrypted, time.Now().UTC(), appID)
+	if err != nil {
+		return fmt.Errorf("failed to set webhook secret: %w", err)
+	}
+
+	if ct.RowsAffected() == 0 {
+		return domain.ErrApplicationNotFound
 	}
 
 	return nil
@@ -422,24 +693,24 @@ func (r *ApplicationRepository) UpdateHealth(ctx context.Context, serverID uuid.
 	}
 
 	valueStrings := make([]string, 0, len(reports))
-	valueArgs := make([]any, 0, len(reports)*2)
+	valueArgs := make([]any, 0, len(reports)*3)
 
-	argPos := 2
+	argPos := 3
 	for _, d := range reports {
-		valueStrings = append(valueStrings, fmt.Sprintf("($%d::bigint, $%d::text)", argPos, argPos+1))
-		valueArgs = append(valueArgs, d.ApplicationID, d.Status)
-		argPos += 2
+		valueStrings = append(valueStrings, fmt.Sprintf("($%d::bigint, $%d::text, $%d::jsonb)", argPos, argPos+1, argPos+2))
+		valueArgs = append(valueArgs, d.ApplicationID, d.Status, servicesOrEmpty(d.Services))
+		argPos += 3
 	}
 
 	query := fmt.Sprintf(`
 		UPDATE applications a
-		SET status = v.status
-		FROM (VALUES %s) AS v(app_id, status)
+		SET status = v.status, services = v.services, health_checked_at = $2
+		FROM (VALUES %s) AS v(app_id, status, services)
 		WHERE a.id = v.app_id
 		  AND a.server_id = $1
 	`, strings.Join(valueStrings, ","))
 
-	args := append([]any{serverID}, valueArgs...)
+	args := append([]any{serverID, time.Now().UTC()}, valueArgs...)
 
 	_, err := r.db.Exec(ctx, query, args...)
 	if err != nil {
@@ -448,3 +719,24 @@ func (r *ApplicationRepository) UpdateHealth(ctx context.Context, serverID uuid.
 
 	return nil
 }
+
+func jobTimeoutsOrEmpty(t domain.JobTimeouts) domain.JobTimeouts {
+	if t == nil {
+		return domain.JobTimeouts{}
+	}
+	return t
+}
+
+func stringsOrEmpty(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
+
+func servicesOrEmpty(s []domain.ServiceHealth) []domain.ServiceHealth {
+	if s == nil {
+		return []domain.ServiceHealth{}
+	}
+	return s
+}
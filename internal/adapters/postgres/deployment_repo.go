@@ -28,10 +28,15 @@ func (r *DeploymentRepository) List(ctx context.Context, opts domain.DeploymentL
 			d.id,
 			d.application_id,
 			d.branch,
+			d.ref,
 			d.commit_hash,
 			d.commit_message,
 			d.status,
 			d.deployed_by,
+			d.pushed_by,
+			d.image,
+			d.rollback_of,
+			d.superseded_by,
 			d.triggered_at,
 			d.started_at,
 			d.finished_at,
@@ -112,10 +117,15 @@ func (r *DeploymentRepository) List(ctx context.Context, opts domain.DeploymentL
 			&d.ID,
 			&d.ApplicationID,
 			&d.Branch,
+			&d.Ref,
 			&d.CommitHash,
 			&d.CommitMessage,
 			&d.Status,
 			&d.DeployedBy,
+			&d.PushedBy,
+			&d.Image,
+			&d.RollbackOf,
+			&d.SupersededBy,
 			&d.TriggeredAt,
 			&d.StartedAt,
 			&d.FinishedAt,
@@ -152,10 +162,15 @@ func (r *DeploymentRepository) GetByID(ctx context.Context, deploymentID int64)
 			d.id,
 			d.application_id,
 			d.branch,
+			d.ref,
 			d.commit_hash,
 			d.commit_message,
 			d.status, 
 			d.deployed_by,
+			d.pushed_by,
+			d.image,
+			d.rollback_of,
+			d.superseded_by,
 			d.triggered_at,
 			d.started_at,
 			d.finished_at,
@@ -180,10 +195,15 @@ func (r *DeploymentRepository) GetByID(ctx context.Context, deploymentID int64)
 		&d.ID,
 		&d.ApplicationID,
 		&d.Branch,
+		&d.Ref,
 		&d.CommitHash,
 		&d.CommitMessage,
 		&d.Status,
 		&d.DeployedBy,
+		&d.PushedBy,
+		&d.Image,
+		&d.RollbackOf,
+		&d.SupersededBy,
 		&d.TriggeredAt,
 		&d.StartedAt,
 		&d.FinishedAt,
@@ -210,20 +230,65 @@ func (r *DeploymentRepository) GetByID(ctx context.Context, deploymentID int64)
 	return &d, nil
 }
 
+func (r *DeploymentRepository) GetLastSuccessful(ctx context.Context, appID int64, beforeID int64) (*domain.Deployment, error) {
+	query := `
+		SELECT id, application_id, branch, ref, commit_hash, commit_message, status, deployed_by, pushed_by, image,
+			rollback_of, triggered_at, started_at, finished_at
+		FROM deployments
+		WHERE application_id = $1 AND id < $2 AND status = $3 AND (commit_hash IS NOT NULL OR image IS NOT NULL)
+		ORDER BY id DESC
+		LIMIT 1
+	`
+
+	var d domain.Deployment
+	if err := r.db.QueryRow(ctx, query, appID, beforeID, domain.DeploymentSuccess).Scan(
+		&d.ID,
+		&d.ApplicationID,
+		&d.Branch,
+		&d.Ref,
+		&d.CommitHash,
+		&d.CommitMessage,
+		&d.Status,
+		&d.DeployedBy,
+		&d.PushedBy,
+		&d.Image,
+		&d.RollbackOf,
+		&d.TriggeredAt,
+		&d.StartedAt,
+		&d.FinishedAt,
+	); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrDeploymentNotFound
+		}
+		return nil, fmt.Errorf("failed to get last successful deployment: %w", err)
+	}
+
+	return &d, nil
+}
+
 func (r *DeploymentRepository) Create(ctx context.Context, d *domain.Deployment) (*domain.Deployment, error) {
 	query := `
 		INSERT INTO deployments (
 			application_id,
 			branch,
+			ref,
+			commit_hash,
+			commit_message,
 			deployed_by,
+			pushed_by,
+			image,
+			rollback_of,
 			status,
 			triggered_at
 		)
-		VALUES ($1, $2, $3, $4, $5)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
 		RETURNING
 			id,
 			application_id,
 			deployed_by,
+			pushed_by,
+			image,
+			rollback_of,
 			triggered_at
 	`
 
@@ -231,13 +296,22 @@ func (r *DeploymentRepository) Create(ctx context.Context, d *domain.Deployment)
 	if err := r.db.QueryRow(ctx, query,
 		d.ApplicationID,
 		d.Branch,
+		d.Ref,
+		d.CommitHash,
+		d.CommitMessage,
 		d.DeployedBy,
+		d.PushedBy,
+		d.Image,
+		d.RollbackOf,
 		domain.DeploymentPending,
 		now,
 	).Scan(
 		&d.ID,
 		&d.ApplicationID,
 		&d.DeployedBy,
+		&d.PushedBy,
+		&d.Image,
+		&d.RollbackOf,
 		&d.TriggeredAt,
 	); err != nil {
 		return nil, fmt.Errorf("failed to create deployment: %w", err)
@@ -0,0 +1,189 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"horizonx/internal/domain"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const pgUniqueViolation = "23505"
+
+type GitCredentialRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewGitCredentialRepository(db *pgxpool.Pool) domain.GitCredentialRepository {
+	return &GitCredentialRepository{db: db}
+}
+
+const gitCredentialColumns = `
+	id, name, type, username, encrypted_secret, public_key, known_hosts, created_by, created_at, updated_at
+`
+
+func scanGitCredential(row pgx.Row) (*domain.GitCredential, error) {
+	var c domain.GitCredential
+
+	err := row.Scan(
+		&c.ID,
+		&c.Name,
+		&c.Type,
+		&c.Username,
+		&c.EncryptedSecret,
+		&c.PublicKey,
+		&c.KnownHosts,
+		&c.CreatedBy,
+		&c.CreatedAt,
+		&c.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &c, nil
+}
+
+func (r *GitCredentialRepository) List(ctx context.Context) ([]*domain.GitCredential, error) {
+	query := `SELECT ` + gitCredentialColumns + ` FROM git_credentials ORDER BY name ASC`
+
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query git credentials: %w", err)
+	}
+	defer rows.Close()
+
+	credentials := []*domain.GitCredential{}
+	for rows.Next() {
+		c, err := scanGitCredential(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan git credential: %w", err)
+		}
+		credentials = append(credentials, c)
+	}
+
+	return credentials, rows.Err()
+}
+
+func (r *GitCredentialRepository) GetByID(ctx context.Context, credentialID int64) (*domain.GitCredential, error) {
+	query := `SELECT ` + gitCredentialColumns + ` FROM git_credentials WHERE id = $1`
+
+	c, err := scanGitCredential(r.db.QueryRow(ctx, query, credentialID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrGitCredentialNotFound
+		}
+		return nil, fmt.Errorf("failed to get git credential: %w", err)
+	}
+
+	return c, nil
+}
+
+func (r *GitCredentialRepository) NextID(ctx context.Context) (int64, error) {
+	query := `SELECT nextval(pg_get_serial_sequence('git_credentials', 'id'))`
+
+	var id int64
+	if err := r.db.QueryRow(ctx, query).Scan(&id); err != nil {
+		return 0, fmt.Errorf("failed to reserve git credential id: %w", err)
+	}
+
+	return id, nil
+}
+
+func (r *GitCredentialRepository) Create(ctx context.Context, c *domain.GitCredential) (*domain.GitCredential, error) {
+	query := `
+		INSERT INTO git_credentials (
+			id, name, type, username, encrypted_secret, public_key, known_hosts, created_by, created_at, updated_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING created_at, updated_at
+	`
+
+	now := time.Now().UTC()
+	err := r.db.QueryRow(ctx, query,
+		c.ID,
+		c.Name,
+		c.Type,
+		c.Username,
+		c.EncryptedSecret,
+		c.PublicKey,
+		c.KnownHosts,
+		c.CreatedBy,
+		now,
+		now,
+	).Scan(&c.CreatedAt, &c.UpdatedAt)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, domain.ErrGitCredentialExists
+		}
+		return nil, fmt.Errorf("failed to create git credential: %w", err)
+	}
+
+	return c, nil
+}
+
+func (r *GitCredentialRepository) Update(ctx context.Context, c *domain.GitCredential) error {
+	query := `
+		UPDATE git_credentials
+		SET name = $1, username = $2, encrypted_secret = $3, public_key = $4, known_hosts = $5, updated_at = $6
+		WHERE id = $7
+		RETURNING updated_at
+	`
+
+	err := r.db.QueryRow(ctx, query,
+		c.Name,
+		c.Username,
+		c.EncryptedSecret,
+		c.PublicKey,
+		c.KnownHosts,
+		time.Now().UTC(),
+		c.ID,
+	).Scan(&c.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.ErrGitCredentialNotFound
+		}
+		if isUniqueViolation(err) {
+			return domain.ErrGitCredentialExists
+		}
+		return fmt.Errorf("failed to update git credential: %w", err)
+	}
+
+	return nil
+}
+
+// Delete refuses credentials still attached to a live application, deleted
+// applications lose the reference through the foreign key.
+func (r *GitCredentialRepository) Delete(ctx context.Context, credentialID int64) error {
+	var inUse bool
+	if err := r.db.QueryRow(ctx,
+		`SELECT EXISTS (SELECT 1 FROM applications WHERE git_credential_id = $1 AND deleted_at IS NULL)`,
+		credentialID,
+	).Scan(&inUse); err != nil {
+		return fmt.Errorf("failed to check git credential usage: %w", err)
+	}
+	if inUse {
+		return domain.ErrGitCredentialInUse
+	}
+
+	ct, err := r.db.Exec(ctx, `DELETE FROM git_credentials WHERE id = $1`, credentialID)
+	if err != nil {
+		return fmt.Errorf("failed to delete git credential: %w", err)
+	}
+
+	if ct.RowsAffected() == 0 {
+		return domain.ErrGitCredentialNotFound
+	}
+
+	return nil
+}
+
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == pgUniqueViolation
+}
@@ -0,0 +1,114 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"horizonx/internal/domain"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type GitPollRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewGitPollRepository(db *pgxpool.Pool) domain.GitPollRepository {
+	return &GitPollRepository{db: db}
+}
+
+func (r *GitPollRepository) ClaimDue(ctx context.Context, now time.Time, limit int) ([]int64, error) {
+	// Locked rows are being claimed by another control plane and skipped.
+	query := `
+		WITH due AS (
+			SELECT a.id
+			FROM applications a
+			LEFT JOIN git_polls p ON p.application_id = a.id
+			WHERE a.deleted_at IS NULL
+			  AND a.git_poll_interval_seconds > 0
+			  AND (p.polled_at IS NULL OR p.polled_at <= $1 - make_interval(secs => a.git_poll_interval_seconds))
+			ORDER BY p.polled_at ASC NULLS FIRST
+			LIMIT $2
+			FOR UPDATE OF a SKIP LOCKED
+		)
+		INSERT INTO git_polls (application_id, polled_at)
+		SELECT id, $1 FROM due
+		ON CONFLICT (application_id) DO UPDATE SET polled_at = EXCLUDED.polled_at
+		RETURNING application_id
+	`
+
+	rows, err := r.db.Query(ctx, query, now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim due git polls: %w", err)
+	}
+	defer rows.Close()
+
+	var appIDs []int64
+	for rows.Next() {
+		var appID int64
+		if err := rows.Scan(&appID); err != nil {
+			return nil, fmt.Errorf("failed to scan git poll: %w", err)
+		}
+		appIDs = append(appIDs, appID)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return appIDs, nil
+}
+
+// Get returns an empty state for applications that were never polled.
+func (r *GitPollRepository) Get(ctx context.Context, appID int64) (*domain.GitPollState, error) {
+	query := `
+		SELECT application_id, remote_hash, remote_changed_at, triggered_hash, polled_at
+		FROM git_polls
+		WHERE application_id = $1
+	`
+
+	var s domain.GitPollState
+	err := r.db.QueryRow(ctx, query, appID).Scan(
+		&s.ApplicationID,
+		&s.RemoteHash,
+		&s.RemoteChangedAt,
+		&s.TriggeredHash,
+		&s.PolledAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return &domain.GitPollState{ApplicationID: appID}, nil
+		}
+		return nil, fmt.Errorf("failed to get git poll: %w", err)
+	}
+
+	return &s, nil
+}
+
+func (r *GitPollRepository) Save(ctx context.Context, s *domain.GitPollState) error {
+	query := `
+		INSERT INTO git_polls (application_id, remote_hash, remote_changed_at, triggered_hash, polled_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (application_id) DO UPDATE SET
+			remote_hash = EXCLUDED.remote_hash,
+			remote_changed_at = EXCLUDED.remote_changed_at,
+			triggered_hash = EXCLUDED.triggered_hash,
+			polled_at = COALESCE(EXCLUDED.polled_at, git_polls.polled_at)
+	`
+
+	_, err := r.db.Exec(ctx, query,
+		s.ApplicationID,
+		s.RemoteHash,
+		s.RemoteChangedAt,
+		s.TriggeredHash,
+		s.PolledAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save git poll: %w", err)
+	}
+
+	return nil
+}
@@ -4,7 +4,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
 	"strings"
+	"time"
 
 	"horizonx/internal/domain"
 
@@ -21,21 +23,45 @@ func NewJobRepository(db *pgxpool.Pool) domain.JobRepository {
 	return &JobRepository{db: db}
 }
 
+const jobColumns = `
+	id, trace_id, server_id, application_id, deployment_id, type, payload, status, queued_at, started_at,
+	finished_at, expired_at, lease_expires_at, heartbeat_at, attempt, max_attempts, next_run_at, retry_of_id,
+	timeout_seconds
+`
+
+func scanJob(row pgx.Row) (*domain.Job, error) {
+	var j domain.Job
+
+	err := row.Scan(
+		&j.ID,
+		&j.TraceID,
+		&j.ServerID,
+		&j.ApplicationID,
+		&j.DeploymentID,
+		&j.Type,
+		&j.Payload,
+		&j.Status,
+		&j.QueuedAt,
+		&j.StartedAt,
+		&j.FinishedAt,
+		&j.ExpiredAt,
+		&j.LeaseExpiresAt,
+		&j.HeartbeatAt,
+		&j.Attempt,
+		&j.MaxAttempts,
+		&j.NextRunAt,
+		&j.RetryOfID,
+		&j.TimeoutSeconds,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &j, nil
+}
+
 func (r *JobRepository) List(ctx context.Context, opts domain.JobListOptions) ([]*domain.Job, int64, error) {
-	baseQuery := `
-		SELECT
-			id,
-			trace_id,
-			server_id,
-			application_id,
-			deployment_id,
-			type,
-			payload,
-			status,
-			queued_at,
-			started_at,
-			finished_at,
-			expired_at
+	baseQuery := `SELECT ` + jobColumns + `
 		FROM jobs
 	`
 
@@ -108,24 +134,12 @@ func (r *JobRepository) List(ctx context.Context, opts domain.JobListOptions) ([
 
 	var jobs []*domain.Job
 	for rows.Next() {
-		var job domain.Job
-		if err := rows.Scan(
-			&job.ID,
-			&job.TraceID,
-			&job.ServerID,
-			&job.ApplicationID,
-			&job.DeploymentID,
-			&job.Type,
-			&job.Payload,
-			&job.Status,
-			&job.QueuedAt,
-			&job.StartedAt,
-			&job.FinishedAt,
-			&job.ExpiredAt,
-		); err != nil {
+		job, err := scanJob(rows)
+		if err != nil {
 			return nil, 0, fmt.Errorf("failed to scan jobs: %w", err)
 		}
-		jobs = append(jobs, &job)
+
+		jobs = append(jobs, job)
 	}
 
 	if err := rows.Err(); err != nil {
@@ -135,105 +149,164 @@ func (r *JobRepository) List(ctx context.Context, opts domain.JobListOptions) ([
 	return jobs, total, nil
 }
 
-func (r *JobRepository) GetPending(ctx context.Context, serverID uuid.UUID) ([]*domain.Job, error) {
-	query := `
-		SELECT
-			id,
-			trace_id,
-			server_id,
-			application_id,
-			deployment_id,
-			type,
-			payload,
-			status,
-			queued_at,
-			expired_at
+func (r *JobRepository) GetByID(ctx context.Context, jobID int64) (*domain.Job, error) {
+	query := `SELECT ` + jobColumns + `
 		FROM jobs
-		WHERE server_id = $1
-		AND status = $2
-		ORDER BY queued_at ASC
-		LIMIT 30
+		WHERE id = $1 LIMIT 1
 	`
 
-	rows, err := r.db.Query(ctx, query, serverID, domain.JobQueued)
+	j, err := scanJob(r.db.QueryRow(ctx, query, jobID))
 	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrJobNotFound
+		}
+
 		return nil, err
 	}
 
-	var jobs []*domain.Job
-	for rows.Next() {
-		var j domain.Job
-		if err := rows.Scan(
-			&j.ID,
-			&j.TraceID,
-			&j.ServerID,
-			&j.ApplicationID,
-			&j.DeploymentID,
-			&j.Type,
-			&j.Payload,
-			&j.Status,
-			&j.QueuedAt,
-			&j.ExpiredAt,
-		); err != nil {
-			return nil, err
-		}
+	return j, nil
+}
 
-		jobs = append(jobs, &j)
+func (r *JobRepository) Create(ctx context.Context, j *domain.Job) (*domain.Job, error) {
+	return insertJob(ctx, r.db, j)
+}
+
+func (r *JobRepository) CreateUnlessDeploying(ctx context.Context, j *domain.Job) (*domain.Job, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin tx: %w", err)
 	}
+	defer tx.Rollback(ctx)
 
-	if err := rows.Err(); err != nil {
+	if err := lockApplication(ctx, tx, *j.ApplicationID); err != nil {
 		return nil, err
 	}
 
-	return jobs, nil
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM jobs
+			WHERE application_id = $1
+			  AND type = $2
+			  AND status IN ('queued', 'running')
+		)
+	`
+
+	var deploying bool
+	if err := tx.QueryRow(ctx, query, *j.ApplicationID, domain.JobTypeAppDeploy).Scan(&deploying); err != nil {
+		return nil, fmt.Errorf("failed to look up active deploys: %w", err)
+	}
+	if deploying {
+		return nil, domain.ErrDeploymentInProgress
+	}
+
+	job, err := insertJob(ctx, tx, j)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit tx: %w", err)
+	}
+
+	return job, nil
 }
 
-func (r *JobRepository) GetByID(ctx context.Context, jobID int64) (*domain.Job, error) {
+func (r *JobRepository) CreateDeploy(ctx context.Context, j *domain.Job) (*domain.Job, []*domain.Job, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockApplication(ctx, tx, *j.ApplicationID); err != nil {
+		return nil, nil, err
+	}
+
+	job, err := insertJob(ctx, tx, j)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	query := `
-		SELECT
-			id,
-			trace_id,
-			server_id,
-			application_id,
-			deployment_id,
-			type,
-			payload,
-			status,
-			queued_at,
-			started_at,
-			finished_at,
-			expired_at
-		FROM jobs
-		WHERE id = $1 LIMIT 1
-	`
+		UPDATE jobs
+		SET
+			status = 'cancelled',
+			finished_at = NOW()
+		WHERE application_id = $1
+		  AND type = $2
+		  AND status = 'queued'
+		  AND id <> $3
+		RETURNING ` + jobColumns
 
-	var j domain.Job
-	err := r.db.QueryRow(ctx, query, jobID).Scan(
-		&j.ID,
-		&j.TraceID,
-		&j.ServerID,
-		&j.ApplicationID,
-		&j.DeploymentID,
-		&j.Type,
-		&j.Payload,
-		&j.Status,
-		&j.QueuedAt,
-		&j.StartedAt,
-		&j.FinishedAt,
-		&j.ExpiredAt,
-	)
+	rows, err := tx.Query(ctx, query, *job.ApplicationID, domain.JobTypeAppDeploy, job.ID)
 	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, domain.ErrJobNotFound
+		return nil, nil, fmt.Errorf("failed to supersede queued jobs: %w", err)
+	}
+
+	var superseded []*domain.Job
+	var deploymentIDs []int64
+	for rows.Next() {
+		s, err := scanJob(rows)
+		if err != nil {
+			rows.Close()
+			return nil, nil, fmt.Errorf("failed to scan superseded jobs: %w", err)
 		}
 
-		return nil, err
+		superseded = append(superseded, s)
+		if s.DeploymentID != nil {
+			deploymentIDs = append(deploymentIDs, *s.DeploymentID)
+		}
 	}
+	rows.Close()
 
-	return &j, nil
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	// Finished deployments keep their recorded outcome.
+	if len(deploymentIDs) > 0 {
+		query := `
+			UPDATE deployments
+			SET status = $1, superseded_by = $2, finished_at = NOW()
+			WHERE id = ANY($3)
+			  AND id <> $2
+			  AND finished_at IS NULL
+		`
+
+		_, err := tx.Exec(ctx, query, domain.DeploymentSuperseded, job.DeploymentID, deploymentIDs)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to supersede queued deployments: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, nil, fmt.Errorf("failed to commit tx: %w", err)
+	}
+
+	return job, superseded, nil
 }
 
-func (r *JobRepository) Create(ctx context.Context, j *domain.Job) (*domain.Job, error) {
+// lockApplication takes the row lock of the application for the rest of tx,
+// it serializes the jobs created for the same application.
+func lockApplication(ctx context.Context, tx pgx.Tx, appID int64) error {
+	var id int64
+	err := tx.QueryRow(ctx, `SELECT id FROM applications WHERE id = $1 FOR UPDATE`, appID).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.ErrApplicationNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to lock application: %w", err)
+	}
+
+	return nil
+}
+
+// rowQuerier is satisfied by both the pool and a transaction.
+type rowQuerier interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+func insertJob(ctx context.Context, q rowQuerier, j *domain.Job) (*domain.Job, error) {
 	query := `
 		INSERT INTO jobs
 		(
@@ -243,13 +316,18 @@ func (r *JobRepository) Create(ctx context.Context, j *domain.Job) (*domain.Job,
 			deployment_id,
 			type,
 			payload,
-			expired_at
+			expired_at,
+			attempt,
+			max_attempts,
+			next_run_at,
+			retry_of_id,
+			timeout_seconds
 		)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
-		RETURNING id, queued_at
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		RETURNING id, status, queued_at
 	`
 
-	err := r.db.QueryRow(ctx, query,
+	err := q.QueryRow(ctx, query,
 		j.TraceID,
 		j.ServerID,
 		j.ApplicationID,
@@ -257,8 +335,14 @@ func (r *JobRepository) Create(ctx context.Context, j *domain.Job) (*domain.Job,
 		j.Type,
 		j.Payload,
 		j.ExpiredAt,
+		j.Attempt,
+		j.MaxAttempts,
+		j.NextRunAt,
+		j.RetryOfID,
+		j.TimeoutSeconds,
 	).Scan(
 		&j.ID,
+		&j.Status,
 		&j.QueuedAt,
 	)
 	if err != nil {
@@ -287,151 +371,311 @@ func (r *JobRepository) Delete(ctx context.Context, jobID int64) error {
 	return nil
 }
 
-func (r *JobRepository) Retry(ctx context.Context, jobID int64, j *domain.Job) (*domain.Job, error) {
+// ListAttempts returns every attempt of the job chain started by rootID,
+// oldest first.
+func (r *JobRepository) ListAttempts(ctx context.Context, rootID int64) ([]*domain.Job, error) {
+	query := `SELECT ` + jobColumns + `
+		FROM jobs
+		WHERE id = $1 OR retry_of_id = $1
+		ORDER BY attempt ASC, id ASC
+	`
+
+	rows, err := r.db.Query(ctx, query, rootID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query job attempts: %w", err)
+	}
+	defer rows.Close()
+
+	var jobs []*domain.Job
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan job attempts: %w", err)
+		}
+
+		jobs = append(jobs, job)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return jobs, nil
+}
+
+func (r *JobRepository) DeleteFinishedBefore(ctx context.Context, jobType domain.JobType, before time.Time) (int64, error) {
 	query := `
-		UPDATE jobs
-		SET
-			status = $2,
-			queued_at = $3,
-			started_at = null,
-			finished_at = null,
-			expired_at = $4
-		WHERE id = $1
-		RETURNING
-			id,
-			trace_id,
-			server_id,
-			application_id,
-			deployment_id,
-			type,
-			payload,
-			status,
-			queued_at,
-			expired_at
+		DELETE FROM jobs
+		WHERE type = $1
+		  AND status NOT IN ('queued', 'running')
+		  AND finished_at < $2
 	`
 
-	err := r.db.QueryRow(ctx, query,
-		jobID,
-		j.Status,
-		j.QueuedAt,
-		j.ExpiredAt,
-	).Scan(
-		&j.ID,
-		&j.TraceID,
-		&j.ServerID,
-		&j.ApplicationID,
-		&j.DeploymentID,
-		&j.Type,
-		&j.Payload,
-		&j.Status,
-		&j.QueuedAt,
-		&j.ExpiredAt,
-	)
+	tag, err := r.db.Exec(ctx, query, jobType, before)
 	if err != nil {
-		return nil, fmt.Errorf("failed to retry job: %w", err)
+		return 0, fmt.Errorf("failed to delete finished jobs: %w", err)
 	}
 
-	return j, nil
+	return tag.RowsAffected(), nil
 }
 
-func (r *JobRepository) MarkRunning(ctx context.Context, jobID int64) (*domain.Job, error) {
+func (r *JobRepository) MarkFinished(
+	ctx context.Context,
+	jobID int64,
+	serverID uuid.UUID,
+	status domain.JobStatus,
+) (*domain.Job, bool, error) {
+	// A finish the agent could not deliver before its lease ran out still
+	// counts for an expired job, unless the reaper already queued a retry
+	// that replaces it.
+	query := `
+		UPDATE jobs j
+		SET
+			status = $1,
+			finished_at = NOW(),
+			expired_at = CASE WHEN $1 = 'expired' THEN NOW() ELSE j.expired_at END,
+			lease_expires_at = NULL
+		WHERE j.id = $2
+		  AND j.server_id = $3
+		  AND (
+			j.status = 'running'
+			OR (
+				j.status = 'expired'
+				AND $1 <> 'expired'
+				AND NOT EXISTS (
+					SELECT 1 FROM jobs r
+					WHERE r.retry_of_id = COALESCE(j.retry_of_id, j.id)
+					  AND r.attempt > j.attempt
+				)
+			)
+		  )
+		RETURNING ` + jobColumns
+
+	job, err := scanJob(r.db.QueryRow(ctx, query, status, jobID, serverID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		job, err := r.GetByID(ctx, jobID)
+		if err != nil {
+			return nil, false, err
+		}
+		if job.ServerID != serverID {
+			return nil, false, domain.ErrInvalidJobState
+		}
+		return job, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	return job, true, nil
+}
+
+func (r *JobRepository) MarkCancelled(ctx context.Context, jobID int64) (*domain.Job, error) {
 	query := `
 		UPDATE jobs
 		SET
-			status = 'running',
-			started_at = NOW()
+			status = 'cancelled',
+			finished_at = NOW(),
+			lease_expires_at = NULL
 		WHERE id = $1
-		  AND status = 'queued'
-		RETURNING
-			id,
-			trace_id,
-			server_id,
-			application_id,
-			deployment_id,
-			type,
-			payload,
-			status,
-			queued_at,
-			started_at,
-			finished_at,
-			expired_at
-	`
+		  AND status IN ('queued', 'running')
+		RETURNING ` + jobColumns
 
-	var job domain.Job
-	err := r.db.QueryRow(ctx, query, jobID).Scan(
-		&job.ID,
-		&job.TraceID,
-		&job.ServerID,
-		&job.ApplicationID,
-		&job.DeploymentID,
-		&job.Type,
-		&job.Payload,
-		&job.Status,
-		&job.QueuedAt,
-		&job.StartedAt,
-		&job.FinishedAt,
-		&job.ExpiredAt,
-	)
+	job, err := scanJob(r.db.QueryRow(ctx, query, jobID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		if _, err := r.GetByID(ctx, jobID); err != nil {
+			return nil, err
+		}
+		return nil, domain.ErrInvalidJobState
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return job, nil
+}
 
-	if err == pgx.ErrNoRows {
-		return r.GetByID(ctx, jobID)
+func (r *JobRepository) Claim(ctx context.Context, serverID uuid.UUID, limit int, lightLimit int, lease time.Duration) ([]*domain.Job, error) {
+	// Each lane is claimed against its own limit, $5 lists the light types.
+	claimable := func(lane string, limit string) string {
+		return `
+			SELECT q.id AS claim_id
+			FROM jobs q
+			WHERE q.server_id = $1
+			  AND q.status = 'queued'
+			  AND (q.next_run_at IS NULL OR q.next_run_at <= NOW())
+			  AND ` + lane + `
+			  -- serialized jobs wait for the one running or queued ahead of
+			  -- them on the same application
+			  AND NOT (
+				q.application_id IS NOT NULL
+				AND q.type = ANY($4)
+				AND EXISTS (
+					SELECT 1
+					FROM jobs o
+					WHERE o.application_id = q.application_id
+					  AND o.type = ANY($4)
+					  AND o.id <> q.id
+					  AND (
+						o.status = 'running'
+						OR (o.status = 'queued' AND o.id < q.id AND (o.next_run_at IS NULL OR o.next_run_at <= NOW()))
+					  )
+				)
+			  )
+			ORDER BY q.queued_at ASC
+			LIMIT ` + limit + `
+			FOR UPDATE SKIP LOCKED`
 	}
+
+	query := `
+		WITH main_lane AS (` + claimable(`NOT (q.type = ANY($5))`, `$2`) + `
+		),
+		light_lane AS (` + claimable(`q.type = ANY($5)`, `$6`) + `
+		),
+		claimable AS (
+			SELECT claim_id FROM main_lane
+			UNION ALL
+			SELECT claim_id FROM light_lane
+		)
+		UPDATE jobs j
+		SET
+			status = 'running',
+			started_at = NOW(),
+			heartbeat_at = NOW(),
+			lease_expires_at = NOW() + make_interval(secs => $3)
+		FROM claimable c
+		WHERE j.id = c.claim_id
+		RETURNING ` + jobColumns
+
+	serialized := make([]string, 0, len(domain.SerializedJobTypes))
+	for _, t := range domain.SerializedJobTypes {
+		serialized = append(serialized, string(t))
+	}
+
+	light := make([]string, 0, len(domain.LightJobTypes))
+	for _, t := range domain.LightJobTypes {
+		light = append(light, string(t))
+	}
+
+	rows, err := r.db.Query(ctx, query, serverID, limit, lease.Seconds(), serialized, light, lightLimit)
 	if err != nil {
+		return nil, fmt.Errorf("failed to claim jobs: %w", err)
+	}
+	defer rows.Close()
+
+	var jobs []*domain.Job
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan claimed jobs: %w", err)
+		}
+
+		jobs = append(jobs, job)
+	}
+
+	if err := rows.Err(); err != nil {
 		return nil, err
 	}
 
-	return &job, nil
+	// UPDATE ... RETURNING does not keep the CTE ordering
+	slices.SortFunc(jobs, func(a, b *domain.Job) int {
+		return a.QueuedAt.Compare(*b.QueuedAt)
+	})
+
+	return jobs, nil
 }
 
-func (r *JobRepository) MarkFinished(
-	ctx context.Context,
-	jobID int64,
-	status domain.JobStatus,
-) (*domain.Job, error) {
+func (r *JobRepository) Heartbeat(ctx context.Context, jobID int64, serverID uuid.UUID, lease time.Duration) (*domain.Job, error) {
 	query := `
 		UPDATE jobs
 		SET
-			status = $1,
-			finished_at = NOW()
-		WHERE id = $2
+			heartbeat_at = NOW(),
+			lease_expires_at = NOW() + make_interval(secs => $3)
+		WHERE id = $1
+		  AND server_id = $2
 		  AND status = 'running'
-		RETURNING
-			id,
-			trace_id,
-			server_id,
-			application_id,
-			deployment_id,
-			type,
-			payload,
-			status,
-			queued_at,
-			started_at,
-			finished_at,
-			expired_at
+		RETURNING ` + jobColumns
+
+	job, err := scanJob(r.db.QueryRow(ctx, query, jobID, serverID, lease.Seconds()))
+	if errors.Is(err, pgx.ErrNoRows) {
+		if _, err := r.GetByID(ctx, jobID); err != nil {
+			return nil, err
+		}
+		return nil, domain.ErrInvalidJobState
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to extend job lease: %w", err)
+	}
+
+	return job, nil
+}
+
+func (r *JobRepository) ListExpiredLeases(ctx context.Context) ([]*domain.Job, error) {
+	query := `SELECT ` + jobColumns + `
+		FROM jobs
+		WHERE status = 'running'
+		  AND lease_expires_at < NOW()
+		ORDER BY lease_expires_at ASC
+		LIMIT 100
 	`
 
-	var job domain.Job
-	err := r.db.QueryRow(ctx, query, status, jobID).Scan(
-		&job.ID,
-		&job.TraceID,
-		&job.ServerID,
-		&job.ApplicationID,
-		&job.DeploymentID,
-		&job.Type,
-		&job.Payload,
-		&job.Status,
-		&job.QueuedAt,
-		&job.StartedAt,
-		&job.FinishedAt,
-		&job.ExpiredAt,
-	)
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query expired job leases: %w", err)
+	}
+	defer rows.Close()
+
+	var jobs []*domain.Job
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan expired job leases: %w", err)
+		}
 
-	if err == pgx.ErrNoRows {
-		return r.GetByID(ctx, jobID)
+		jobs = append(jobs, job)
 	}
-	if err != nil {
+
+	if err := rows.Err(); err != nil {
 		return nil, err
 	}
 
-	return &job, nil
+	return jobs, nil
+}
+
+func (r *JobRepository) ReleaseLease(ctx context.Context, jobID int64, requeue bool) (*domain.Job, error) {
+	query := `
+		UPDATE jobs
+		SET
+			status = 'expired',
+			expired_at = NOW(),
+			finished_at = NOW(),
+			lease_expires_at = NULL
+		WHERE id = $1
+		  AND status = 'running'
+		  AND lease_expires_at < NOW()
+		RETURNING ` + jobColumns
+
+	if requeue {
+		query = `
+			UPDATE jobs
+			SET
+				status = 'queued',
+				queued_at = NOW(),
+				started_at = NULL,
+				lease_expires_at = NULL,
+				heartbeat_at = NULL
+			WHERE id = $1
+			  AND status = 'running'
+			  AND lease_expires_at < NOW()
+			RETURNING ` + jobColumns
+	}
+
+	job, err := scanJob(r.db.QueryRow(ctx, query, jobID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, domain.ErrInvalidJobState
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to release job lease: %w", err)
+	}
+
+	return job, nil
 }
@@ -3,11 +3,13 @@ package postgres
 import (
 	"context"
 	"fmt"
+	"slices"
 	"strings"
 	"time"
 
 	"horizonx/internal/domain"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -232,3 +234,73 @@ func (r *LogRepository) Create(ctx context.Context, l *domain.Log) (*domain.Log,
 
 	return l, nil
 }
+
+// CreateBatch inserts logs with a single COPY. IDs are reserved up front so
+// they follow the order of the batch and can be handed back to the caller.
+func (r *LogRepository) CreateBatch(ctx context.Context, logs []*domain.Log) error {
+	if len(logs) == 0 {
+		return nil
+	}
+
+	rows, err := r.db.Query(ctx,
+		`SELECT nextval(pg_get_serial_sequence('logs', 'id')) FROM generate_series(1, $1)`,
+		len(logs),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to reserve log ids: %w", err)
+	}
+
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[int64])
+	if err != nil {
+		return fmt.Errorf("failed to reserve log ids: %w", err)
+	}
+	slices.Sort(ids)
+
+	now := time.Now().UTC()
+	for i, l := range logs {
+		l.ID = ids[i]
+		l.CreatedAt = now
+	}
+
+	_, err = r.db.CopyFrom(ctx,
+		pgx.Identifier{"logs"},
+		[]string{
+			"id",
+			"timestamp",
+			"level",
+			"source",
+			"action",
+			"trace_id",
+			"job_id",
+			"server_id",
+			"application_id",
+			"deployment_id",
+			"message",
+			"context",
+			"created_at",
+		},
+		pgx.CopyFromSlice(len(logs), func(i int) ([]any, error) {
+			l := logs[i]
+			return []any{
+				l.ID,
+				l.Timestamp,
+				l.Level,
+				l.Source,
+				l.Action,
+				l.TraceID,
+				l.JobID,
+				l.ServerID,
+				l.ApplicationID,
+				l.DeploymentID,
+				l.Message,
+				l.Context,
+				l.CreatedAt,
+			}, nil
+		}),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to emit logs: %w", err)
+	}
+
+	return nil
+}
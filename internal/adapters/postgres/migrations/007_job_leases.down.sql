@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_jobs_lease_expires_at;
+DROP INDEX IF EXISTS idx_jobs_server_queued;
+
+ALTER TABLE jobs
+    DROP COLUMN IF EXISTS heartbeat_at,
+    DROP COLUMN IF EXISTS lease_expires_at;
@@ -0,0 +1,8 @@
+ALTER TABLE jobs
+    ADD COLUMN IF NOT EXISTS lease_expires_at TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS heartbeat_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_jobs_server_queued ON jobs (server_id, queued_at) WHERE status = 'queued';
+CREATE INDEX IF NOT EXISTS idx_jobs_lease_expires_at ON jobs (lease_expires_at) WHERE status = 'running';
+
+COMMENT ON COLUMN jobs.lease_expires_at IS 'Deadline for the claiming agent to heartbeat before the job is reaped';
@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS idx_jobs_type_finished_at;
+DROP INDEX IF EXISTS idx_jobs_retry_of_id;
+
+ALTER TABLE jobs
+    DROP CONSTRAINT IF EXISTS fk_job_retry_of,
+    DROP COLUMN IF EXISTS retry_of_id,
+    DROP COLUMN IF EXISTS next_run_at,
+    DROP COLUMN IF EXISTS max_attempts,
+    DROP COLUMN IF EXISTS attempt;
@@ -0,0 +1,12 @@
+ALTER TABLE jobs
+    ADD COLUMN IF NOT EXISTS attempt INT NOT NULL DEFAULT 1,
+    ADD COLUMN IF NOT EXISTS max_attempts INT NOT NULL DEFAULT 1,
+    ADD COLUMN IF NOT EXISTS next_run_at TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS retry_of_id BIGINT,
+    ADD CONSTRAINT fk_job_retry_of FOREIGN KEY (retry_of_id) REFERENCES jobs(id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS idx_jobs_retry_of_id ON jobs (retry_of_id) WHERE retry_of_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_jobs_type_finished_at ON jobs (type, finished_at);
+
+COMMENT ON COLUMN jobs.next_run_at IS 'Earliest time a queued retry attempt may be claimed';
+COMMENT ON COLUMN jobs.retry_of_id IS 'First attempt of the chain this retry belongs to';
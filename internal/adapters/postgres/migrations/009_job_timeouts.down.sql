@@ -0,0 +1,5 @@
+ALTER TABLE applications
+    DROP COLUMN IF EXISTS job_timeouts;
+
+ALTER TABLE jobs
+    DROP COLUMN IF EXISTS timeout_seconds;
@@ -0,0 +1,8 @@
+ALTER TABLE jobs
+    ADD COLUMN IF NOT EXISTS timeout_seconds INT NOT NULL DEFAULT 0;
+
+ALTER TABLE applications
+    ADD COLUMN IF NOT EXISTS job_timeouts JSONB NOT NULL DEFAULT '{}';
+
+COMMENT ON COLUMN jobs.timeout_seconds IS 'Deadline enforced by the agent, 0 means the job type default';
+COMMENT ON COLUMN applications.job_timeouts IS 'Per job type timeout overrides in seconds';
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS agent_events (
+    event_id UUID PRIMARY KEY,
+    server_id UUID NOT NULL,
+    received_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT fk_agent_event_server FOREIGN KEY (server_id) REFERENCES servers(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_agent_events_received_at ON agent_events (received_at);
+
+COMMENT ON TABLE agent_events IS 'Outbox event IDs already processed, used to ignore agent replays';
@@ -0,0 +1,3 @@
+ALTER TABLE applications
+    DROP COLUMN IF EXISTS health_checked_at,
+    DROP COLUMN IF EXISTS services;
@@ -0,0 +1,6 @@
+ALTER TABLE applications
+    ADD COLUMN IF NOT EXISTS services JSONB NOT NULL DEFAULT '[]',
+    ADD COLUMN IF NOT EXISTS health_checked_at TIMESTAMPTZ;
+
+COMMENT ON COLUMN applications.services IS 'Per container state reported by the last health check';
+COMMENT ON COLUMN applications.health_checked_at IS 'When the agent last reported the application health';
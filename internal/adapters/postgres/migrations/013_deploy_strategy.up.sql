@@ -0,0 +1,4 @@
+ALTER TABLE applications
+    ADD COLUMN IF NOT EXISTS deploy_strategy VARCHAR(20) NOT NULL DEFAULT 'recreate';
+
+COMMENT ON COLUMN applications.deploy_strategy IS 'recreate, build_then_swap or blue_green';
@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS idx_deployments_app_status;
+
+ALTER TABLE deployments
+    DROP CONSTRAINT IF EXISTS fk_deployment_rollback_of,
+    DROP COLUMN IF EXISTS rollback_of;
+
+ALTER TABLE applications
+    DROP COLUMN IF EXISTS rollback_window_minutes,
+    DROP COLUMN IF EXISTS auto_rollback;
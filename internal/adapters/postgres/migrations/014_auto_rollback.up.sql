@@ -0,0 +1,13 @@
+ALTER TABLE applications
+    ADD COLUMN IF NOT EXISTS auto_rollback BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN IF NOT EXISTS rollback_window_minutes INTEGER NOT NULL DEFAULT 0;
+
+ALTER TABLE deployments
+    ADD COLUMN IF NOT EXISTS rollback_of BIGINT
+        CONSTRAINT fk_deployment_rollback_of REFERENCES deployments(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_deployments_app_status ON deployments(application_id, status);
+
+COMMENT ON COLUMN applications.auto_rollback IS 'redeploy the last successful deployment when a deploy fails';
+COMMENT ON COLUMN applications.rollback_window_minutes IS 'minutes after a deploy in which an unhealthy application is rolled back, 0 for failed deploys only';
+COMMENT ON COLUMN deployments.rollback_of IS 'deployment this one replaced by redeploying an earlier one';
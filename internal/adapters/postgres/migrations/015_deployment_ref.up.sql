@@ -0,0 +1,4 @@
+ALTER TABLE deployments
+    ADD COLUMN IF NOT EXISTS ref VARCHAR(255);
+
+COMMENT ON COLUMN deployments.ref IS 'commit SHA or tag the deploy was pinned to, NULL for the branch head';
@@ -0,0 +1,5 @@
+ALTER TABLE applications
+    DROP CONSTRAINT IF EXISTS fk_application_git_credential,
+    DROP COLUMN IF EXISTS git_credential_id;
+
+DROP TABLE IF EXISTS git_credentials;
@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS git_credentials (
+    id BIGSERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    type VARCHAR(20) NOT NULL,
+    username VARCHAR(255) NOT NULL DEFAULT '',
+    encrypted_secret BYTEA NOT NULL,
+    public_key TEXT NOT NULL DEFAULT '',
+    known_hosts TEXT NOT NULL DEFAULT '',
+
+    created_by BIGINT,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+
+    CONSTRAINT uq_git_credentials_name UNIQUE (name),
+    CONSTRAINT fk_git_credential_author FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+ALTER TABLE applications
+    ADD COLUMN IF NOT EXISTS git_credential_id BIGINT,
+    ADD CONSTRAINT fk_application_git_credential FOREIGN KEY (git_credential_id) REFERENCES git_credentials(id) ON DELETE SET NULL;
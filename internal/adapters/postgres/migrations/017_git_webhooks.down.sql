@@ -0,0 +1,5 @@
+ALTER TABLE deployments
+    DROP COLUMN IF EXISTS pushed_by;
+
+ALTER TABLE applications
+    DROP COLUMN IF EXISTS webhook_secret;
@@ -0,0 +1,8 @@
+ALTER TABLE applications
+    ADD COLUMN IF NOT EXISTS webhook_secret BYTEA;
+
+ALTER TABLE deployments
+    ADD COLUMN IF NOT EXISTS pushed_by VARCHAR(255);
+
+COMMENT ON COLUMN applications.webhook_secret IS 'encrypted git webhook secret, NULL while the webhook is disabled';
+COMMENT ON COLUMN deployments.pushed_by IS 'git user whose push triggered the deploy through a webhook';
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS git_polls;
+
+ALTER TABLE applications
+    DROP COLUMN IF EXISTS git_poll_debounce_seconds,
+    DROP COLUMN IF EXISTS git_poll_interval_seconds;
@@ -0,0 +1,13 @@
+ALTER TABLE applications
+    ADD COLUMN IF NOT EXISTS git_poll_interval_seconds INT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS git_poll_debounce_seconds INT NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS git_polls (
+    application_id BIGINT PRIMARY KEY,
+    remote_hash VARCHAR(64) NOT NULL DEFAULT '',
+    remote_changed_at TIMESTAMPTZ,
+    triggered_hash VARCHAR(64) NOT NULL DEFAULT '',
+    polled_at TIMESTAMPTZ,
+
+    CONSTRAINT fk_git_poll_app FOREIGN KEY (application_id) REFERENCES applications(id) ON DELETE CASCADE
+);
@@ -0,0 +1,5 @@
+ALTER TABLE applications
+    DROP COLUMN IF EXISTS compose_project_name,
+    DROP COLUMN IF EXISTS compose_profiles,
+    DROP COLUMN IF EXISTS compose_overrides,
+    DROP COLUMN IF EXISTS compose_path;
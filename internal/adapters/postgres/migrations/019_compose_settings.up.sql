@@ -0,0 +1,8 @@
+ALTER TABLE applications
+    ADD COLUMN IF NOT EXISTS compose_path VARCHAR(255) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS compose_overrides TEXT[] NOT NULL DEFAULT '{}',
+    ADD COLUMN IF NOT EXISTS compose_profiles TEXT[] NOT NULL DEFAULT '{}',
+    ADD COLUMN IF NOT EXISTS compose_project_name VARCHAR(63) NOT NULL DEFAULT '';
+
+COMMENT ON COLUMN applications.compose_path IS 'compose file relative to the repository root, empty for the standard names';
+COMMENT ON COLUMN applications.compose_project_name IS 'compose project name, empty for app-<id>';
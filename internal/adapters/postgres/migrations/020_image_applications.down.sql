@@ -0,0 +1,10 @@
+ALTER TABLE deployments
+    DROP COLUMN IF EXISTS image;
+
+ALTER TABLE applications
+    DROP CONSTRAINT IF EXISTS fk_application_registry_credential,
+    DROP COLUMN IF EXISTS registry_credential_id,
+    DROP COLUMN IF EXISTS compose_file,
+    DROP COLUMN IF EXISTS source;
+
+DROP TABLE IF EXISTS registry_credentials;
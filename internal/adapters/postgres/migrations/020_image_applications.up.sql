@@ -0,0 +1,27 @@
+CREATE TABLE IF NOT EXISTS registry_credentials (
+    id BIGSERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    registry VARCHAR(255) NOT NULL,
+    username VARCHAR(255) NOT NULL,
+    encrypted_password BYTEA NOT NULL,
+
+    created_by BIGINT,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+
+    CONSTRAINT uq_registry_credentials_name UNIQUE (name),
+    CONSTRAINT fk_registry_credential_author FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+ALTER TABLE applications
+    ADD COLUMN IF NOT EXISTS source VARCHAR(20) NOT NULL DEFAULT 'git',
+    ADD COLUMN IF NOT EXISTS compose_file TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS registry_credential_id BIGINT,
+    ADD CONSTRAINT fk_application_registry_credential FOREIGN KEY (registry_credential_id) REFERENCES registry_credentials(id) ON DELETE SET NULL;
+
+ALTER TABLE deployments
+    ADD COLUMN IF NOT EXISTS image VARCHAR(255);
+
+COMMENT ON COLUMN applications.source IS 'git builds from the repository, image pulls prebuilt images';
+COMMENT ON COLUMN applications.compose_file IS 'compose file of an image application';
+COMMENT ON COLUMN deployments.image IS 'image tag or digest an image application was deployed with';
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_jobs_app_active;
+
+ALTER TABLE deployments
+    DROP CONSTRAINT IF EXISTS fk_deployment_superseded_by,
+    DROP COLUMN IF EXISTS superseded_by;
@@ -0,0 +1,6 @@
+ALTER TABLE deployments
+    ADD COLUMN IF NOT EXISTS superseded_by BIGINT,
+    ADD CONSTRAINT fk_deployment_superseded_by FOREIGN KEY (superseded_by) REFERENCES deployments(id) ON DELETE SET NULL;
+
+-- Claims look for queued or running jobs of the same application
+CREATE INDEX IF NOT EXISTS idx_jobs_app_active ON jobs (application_id, type) WHERE status IN ('queued', 'running');
@@ -0,0 +1,15 @@
+-- Encrypted values cannot be decrypted here. Rather than drop them, refuse
+-- to go down while any are stored.
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM environment_variables WHERE encrypted_value IS NOT NULL) THEN
+        RAISE EXCEPTION 'environment_variables holds encrypted values that would be lost'
+            USING HINT = 'Delete those variables or set them again with SECRET_KEY unset, then migrate down.';
+    END IF;
+END
+$$;
+
+ALTER TABLE environment_variables
+    DROP COLUMN IF EXISTS data_key,
+    DROP COLUMN IF EXISTS encrypted_value,
+    ALTER COLUMN value SET NOT NULL;
@@ -0,0 +1,6 @@
+-- Encrypted values keep value NULL, encrypted_value is sealed with its own
+-- data key and data_key with the server's SECRET_KEY.
+ALTER TABLE environment_variables
+    ALTER COLUMN value DROP NOT NULL,
+    ADD COLUMN IF NOT EXISTS encrypted_value BYTEA,
+    ADD COLUMN IF NOT EXISTS data_key BYTEA;
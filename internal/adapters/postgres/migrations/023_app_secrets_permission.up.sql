@@ -0,0 +1,8 @@
+INSERT INTO permissions (name) VALUES ('app_secrets_read') ON CONFLICT (name) DO NOTHING;
+
+-- Only admins may reveal env var values, viewers keep seeing them masked
+INSERT INTO role_has_permissions (role_id, permission_id)
+SELECT r.id, p.id
+FROM roles r, permissions p
+WHERE r.name = 'admin' AND p.name = 'app_secrets_read'
+ON CONFLICT DO NOTHING;
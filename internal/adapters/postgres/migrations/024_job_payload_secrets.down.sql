@@ -0,0 +1,3 @@
+-- The payloads keep their references, resolving them on claim still works.
+-- Stripped secrets are not restored.
+SELECT 1;
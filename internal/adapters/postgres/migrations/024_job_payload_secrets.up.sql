@@ -0,0 +1,32 @@
+-- Job payloads reference credentials and env vars instead of carrying them,
+-- the secrets are resolved when an agent claims the job.
+UPDATE jobs j
+SET payload = (j.payload - 'git_auth' - 'registry_auth' - 'env_vars') || jsonb_strip_nulls(jsonb_build_object(
+    'git_credential_id', a.git_credential_id,
+    'registry_credential_id', a.registry_credential_id,
+    'env_keys', CASE WHEN jsonb_typeof(j.payload->'env_vars') = 'object'
+        THEN (SELECT jsonb_agg(k) FROM jsonb_object_keys(j.payload->'env_vars') k)
+    END
+))
+FROM applications a
+WHERE j.type = 'app_deploy'
+  AND a.id = (j.payload->>'application_id')::BIGINT
+  AND j.payload ?| ARRAY['git_auth', 'registry_auth', 'env_vars'];
+
+-- Jobs of deleted applications
+UPDATE jobs
+SET payload = payload - 'git_auth' - 'registry_auth' - 'env_vars'
+WHERE type = 'app_deploy'
+  AND payload ?| ARRAY['git_auth', 'registry_auth', 'env_vars'];
+
+UPDATE jobs j
+SET payload = jsonb_set(j.payload, '{targets}', (
+    SELECT COALESCE(jsonb_agg(
+        (t - 'git_auth') || jsonb_strip_nulls(jsonb_build_object('git_credential_id', a.git_credential_id))
+        ORDER BY n
+    ), '[]'::JSONB)
+    FROM jsonb_array_elements(j.payload->'targets') WITH ORDINALITY AS e(t, n)
+    LEFT JOIN applications a ON a.id = (t->>'application_id')::BIGINT
+))
+WHERE j.type = 'app_git_poll'
+  AND jsonb_typeof(j.payload->'targets') = 'array';
@@ -0,0 +1,176 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"horizonx/internal/domain"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type RegistryCredentialRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewRegistryCredentialRepository(db *pgxpool.Pool) domain.RegistryCredentialRepository {
+	return &RegistryCredentialRepository{db: db}
+}
+
+const registryCredentialColumns = `
+	id, name, registry, username, encrypted_password, created_by, created_at, updated_at
+`
+
+func scanRegistryCredential(row pgx.Row) (*domain.RegistryCredential, error) {
+	var c domain.RegistryCredential
+
+	err := row.Scan(
+		&c.ID,
+		&c.Name,
+		&c.Registry,
+		&c.Username,
+		&c.EncryptedPassword,
+		&c.CreatedBy,
+		&c.CreatedAt,
+		&c.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &c, nil
+}
+
+func (r *RegistryCredentialRepository) List(ctx context.Context) ([]*domain.RegistryCredential, error) {
+	query := `SELECT ` + registryCredentialColumns + ` FROM registry_credentials ORDER BY name ASC`
+
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query registry credentials: %w", err)
+	}
+	defer rows.Close()
+
+	credentials := []*domain.RegistryCredential{}
+	for rows.Next() {
+		c, err := scanRegistryCredential(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan registry credential: %w", err)
+		}
+		credentials = append(credentials, c)
+	}
+
+	return credentials, rows.Err()
+}
+
+func (r *RegistryCredentialRepository) GetByID(ctx context.Context, credentialID int64) (*domain.RegistryCredential, error) {
+	query := `SELECT ` + registryCredentialColumns + ` FROM registry_credentials WHERE id = $1`
+
+	c, err := scanRegistryCredential(r.db.QueryRow(ctx, query, credentialID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrRegistryCredentialNotFound
+		}
+		return nil, fmt.Errorf("failed to get registry credential: %w", err)
+	}
+
+	return c, nil
+}
+
+func (r *RegistryCredentialRepository) NextID(ctx context.Context) (int64, error) {
+	query := `SELECT nextval(pg_get_serial_sequence('registry_credentials', 'id'))`
+
+	var id int64
+	if err := r.db.QueryRow(ctx, query).Scan(&id); err != nil {
+		return 0, fmt.Errorf("failed to reserve registry credential id: %w", err)
+	}
+
+	return id, nil
+}
+
+func (r *RegistryCredentialRepository) Create(ctx context.Context, c *domain.RegistryCredential) (*domain.RegistryCredential, error) {
+	query := `
+		INSERT INTO registry_credentials (
+			id, name, registry, username, encrypted_password, created_by, created_at, updated_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING created_at, updated_at
+	`
+
+	now := time.Now().UTC()
+	err := r.db.QueryRow(ctx, query,
+		c.ID,
+		c.Name,
+		c.Registry,
+		c.Username,
+		c.EncryptedPassword,
+		c.CreatedBy,
+		now,
+		now,
+	).Scan(&c.CreatedAt, &c.UpdatedAt)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, domain.ErrRegistryCredentialExists
+		}
+		return nil, fmt.Errorf("failed to create registry credential: %w", err)
+	}
+
+	return c, nil
+}
+
+func (r *RegistryCredentialRepository) Update(ctx context.Context, c *domain.RegistryCredential) error {
+	query := `
+		UPDATE registry_credentials
+		SET name = $1, registry = $2, username = $3, encrypted_password = $4, updated_at = $5
+		WHERE id = $6
+		RETURNING updated_at
+	`
+
+	err := r.db.QueryRow(ctx, query,
+		c.Name,
+		c.Registry,
+		c.Username,
+		c.EncryptedPassword,
+		time.Now().UTC(),
+		c.ID,
+	).Scan(&c.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.ErrRegistryCredentialNotFound
+		}
+		if isUniqueViolation(err) {
+			return domain.ErrRegistryCredentialExists
+		}
+		return fmt.Errorf("failed to update registry credential: %w", err)
+	}
+
+	return nil
+}
+
+// Delete refuses credentials still attached to a live application, deleted
+// applications lose the reference through the foreign key.
+func (r *RegistryCredentialRepository) Delete(ctx context.Context, credentialID int64) error {
+	var inUse bool
+	if err := r.db.QueryRow(ctx,
+		`SELECT EXISTS (SELECT 1 FROM applications WHERE registry_credential_id = $1 AND deleted_at IS NULL)`,
+		credentialID,
+	).Scan(&inUse); err != nil {
+		return fmt.Errorf("failed to check registry credential usage: %w", err)
+	}
+	if inUse {
+		return domain.ErrRegistryCredentialInUse
+	}
+
+	ct, err := r.db.Exec(ctx, `DELETE FROM registry_credentials WHERE id = $1`, credentialID)
+	if err != nil {
+		return fmt.Errorf("failed to delete registry credential: %w", err)
+	}
+
+	if ct.RowsAffected() == 0 {
+		return domain.ErrRegistryCredentialNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,231 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"horizonx/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type ScheduleRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewScheduleRepository(db *pgxpool.Pool) domain.ScheduleRepository {
+	return &ScheduleRepository{db: db}
+}
+
+const scheduleColumns = `
+	s.id, s.application_id, s.name, s.cron_expression, s.action, s.service, s.command, s.enabled, s.created_by,
+	s.last_run_at, s.last_trace_id, s.last_error, s.next_run_at, s.created_at, s.updated_at
+`
+
+func scanSchedule(row pgx.Row) (*domain.Schedule, error) {
+	var s domain.Schedule
+
+	err := row.Scan(
+		&s.ID,
+		&s.ApplicationID,
+		&s.Name,
+		&s.CronExpression,
+		&s.Action,
+		&s.Service,
+		&s.Command,
+		&s.Enabled,
+		&s.CreatedBy,
+		&s.LastRunAt,
+		&s.LastTraceID,
+		&s.LastError,
+		&s.NextRunAt,
+		&s.CreatedAt,
+		&s.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &s, nil
+}
+
+func (r *ScheduleRepository) List(ctx context.Context, appID int64) ([]*domain.Schedule, error) {
+	query := `SELECT ` + scheduleColumns + `
+		FROM schedules s
+		WHERE s.application_id = $1
+		ORDER BY s.created_at ASC
+	`
+
+	rows, err := r.db.Query(ctx, query, appID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query schedules: %w", err)
+	}
+	defer rows.Close()
+
+	schedules := []*domain.Schedule{}
+	for rows.Next() {
+		s, err := scanSchedule(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan schedule: %w", err)
+		}
+		schedules = append(schedules, s)
+	}
+
+	return schedules, rows.Err()
+}
+
+func (r *ScheduleRepository) GetByID(ctx context.Context, appID int64, scheduleID int64) (*domain.Schedule, error) {
+	query := `SELECT ` + scheduleColumns + `
+		FROM schedules s
+		WHERE s.id = $1 AND s.application_id = $2
+	`
+
+	s, err := scanSchedule(r.db.QueryRow(ctx, query, scheduleID, appID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrScheduleNotFound
+		}
+		return nil, fmt.Errorf("failed to get schedule: %w", err)
+	}
+
+	return s, nil
+}
+
+func (r *ScheduleRepository) Create(ctx context.Context, s *domain.Schedule) (*domain.Schedule, error) {
+	query := `
+		INSERT INTO schedules (
+			application_id, name, cron_expression, action, service, command, enabled, created_by, next_run_at,
+			created_at, updated_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING id, created_at, updated_at
+	`
+
+	now := time.Now().UTC()
+	err := r.db.QueryRow(ctx, query,
+		s.ApplicationID,
+		s.Name,
+		s.CronExpression,
+		s.Action,
+		s.Service,
+		s.Command,
+		s.Enabled,
+		s.CreatedBy,
+		s.NextRunAt,
+		now,
+		now,
+	).Scan(&s.ID, &s.CreatedAt, &s.UpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create schedule: %w", err)
+	}
+
+	return s, nil
+}
+
+func (r *ScheduleRepository) Update(ctx context.Context, s *domain.Schedule) error {
+	query := `
+		UPDATE schedules
+		SET name = $1, cron_expression = $2, action = $3, service = $4, command = $5, enabled = $6,
+			next_run_at = $7, updated_at = $8
+		WHERE id = $9 AND application_id = $10
+		RETURNING updated_at
+	`
+
+	err := r.db.QueryRow(ctx, query,
+		s.Name,
+		s.CronExpression,
+		s.Action,
+		s.Service,
+		s.Command,
+		s.Enabled,
+		s.NextRunAt,
+		time.Now().UTC(),
+		s.ID,
+		s.ApplicationID,
+	).Scan(&s.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.ErrScheduleNotFound
+		}
+		return fmt.Errorf("failed to update schedule: %w", err)
+	}
+
+	return nil
+}
+
+func (r *ScheduleRepository) Delete(ctx context.Context, appID int64, scheduleID int64) error {
+	query := `DELETE FROM schedules WHERE id = $1 AND application_id = $2`
+
+	ct, err := r.db.Exec(ctx, query, scheduleID, appID)
+	if err != nil {
+		return fmt.Errorf("failed to delete schedule: %w", err)
+	}
+
+	if ct.RowsAffected() == 0 {
+		return domain.ErrScheduleNotFound
+	}
+
+	return nil
+}
+
+func (r *ScheduleRepository) ListDue(ctx context.Context, now time.Time, limit int) ([]*domain.Schedule, error) {
+	query := `SELECT ` + scheduleColumns + `
+		FROM schedules s
+		JOIN applications a ON a.id = s.application_id
+		WHERE s.enabled AND s.next_run_at <= $1 AND a.deleted_at IS NULL
+		ORDER BY s.next_run_at ASC
+		LIMIT $2
+	`
+
+	rows, err := r.db.Query(ctx, query, now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query due schedules: %w", err)
+	}
+	defer rows.Close()
+
+	var schedules []*domain.Schedule
+	for rows.Next() {
+		s, err := scanSchedule(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan schedule: %w", err)
+		}
+		schedules = append(schedules, s)
+	}
+
+	return schedules, rows.Err()
+}
+
+func (r *ScheduleRepository) MarkRun(ctx context.Context, s *domain.Schedule, runAt time.Time, nextRunAt *time.Time) (bool, error) {
+	query := `
+		UPDATE schedules
+		SET last_run_at = $1, next_run_at = $2
+		WHERE id = $3 AND next_run_at = $4
+	`
+
+	ct, err := r.db.Exec(ctx, query, runAt, nextRunAt, s.ID, s.NextRunAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to mark schedule run: %w", err)
+	}
+
+	return ct.RowsAffected() == 1, nil
+}
+
+func (r *ScheduleRepository) RecordResult(ctx context.Context, scheduleID int64, traceID uuid.UUID, runErr error) error {
+	query := `UPDATE schedules SET last_trace_id = $1, last_error = $2 WHERE id = $3`
+
+	var lastError *string
+	if runErr != nil {
+		msg := runErr.Error()
+		lastError = &msg
+	}
+
+	if _, err := r.db.Exec(ctx, query, traceID, lastError, scheduleID); err != nil {
+		return fmt.Errorf("failed to record schedule result: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,164 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"horizonx/internal/secret"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// sealedColumns hold values sealed directly with the master key, for the
+// additional data secret.AAD(table, column, id).
+var sealedColumns = []struct {
+	table  string
+	column string
+}{
+	{"git_credentials", "encrypted_secret"},
+	{"registry_credentials", "encrypted_password"},
+	{"applications", "webhook_secret"},
+}
+
+// ReencryptSecrets brings every stored secret under the current key of box:
+// env var values still in plain text are encrypted, env var data keys are
+// rewrapped and the sealed credential columns are sealed again. It returns
+// the number of rows updated.
+//
+// Rows are rewritten one by one and only if unchanged since they were read,
+// a running server that knows both the current and the previous key keeps
+// working meanwhile. Running it again is harmless.
+func ReencryptSecrets(ctx context.Context, db *pgxpool.Pool, box *secret.Box) (int64, error) {
+	updated, err := reencryptEnvVars(ctx, db, box)
+	if err != nil {
+		return updated, err
+	}
+
+	for _, c := range sealedColumns {
+		n, err := resealColumn(ctx, db, box, c.table, c.column)
+		updated += n
+		if err != nil {
+			return updated, err
+		}
+	}
+
+	return updated, nil
+}
+
+func reencryptEnvVars(ctx context.Context, db *pgxpool.Pool, box *secret.Box) (int64, error) {
+	type envRow struct {
+		id      int64
+		appID   int64
+		key     string
+		value   *string
+		dataKey []byte
+	}
+
+	rows, err := db.Query(ctx, `SELECT id, application_id, key, value, data_key FROM environment_variables ORDER BY id ASC`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query env vars: %w", err)
+	}
+
+	var envs []envRow
+	for rows.Next() {
+		var e envRow
+		if err := rows.Scan(&e.id, &e.appID, &e.key, &e.value, &e.dataKey); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan env var: %w", err)
+		}
+		envs = append(envs, e)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	var updated int64
+	for _, e := range envs {
+		var (
+			query string
+			args  []any
+		)
+
+		switch {
+		case e.value != nil:
+			sealed, err := box.SealEnvelope([]byte(*e.value), envVarAAD(e.appID, e.key))
+			if err != nil {
+				return updated, err
+			}
+			query = `
+				UPDATE environment_variables
+				SET value = NULL, encrypted_value = $1, data_key = $2
+				WHERE id = $3 AND value = $4
+			`
+			args = []any{sealed.Ciphertext, sealed.DataKey, e.id, *e.value}
+
+		case e.dataKey != nil:
+			wrapped, err := box.Reseal(e.dataKey, envVarAAD(e.appID, e.key))
+			if err != nil {
+				return updated, fmt.Errorf("failed to rewrap data key of env var %d: %w", e.id, err)
+			}
+			query = `
+				UPDATE environment_variables
+				SET data_key = $1
+				WHERE id = $2 AND data_key = $3
+			`
+			args = []any{wrapped, e.id, e.dataKey}
+
+		default:
+			continue
+		}
+
+		ct, err := db.Exec(ctx, query, args...)
+		if err != nil {
+			return updated, fmt.Errorf("failed to update env var %d: %w", e.id, err)
+		}
+		updated += ct.RowsAffected()
+	}
+
+	return updated, nil
+}
+
+func resealColumn(ctx context.Context, db *pgxpool.Pool, box *secret.Box, table, column string) (int64, error) {
+	type sealedRow struct {
+		id     int64
+		sealed []byte
+	}
+
+	rows, err := db.Query(ctx, fmt.Sprintf(`SELECT id, %s FROM %s WHERE %s IS NOT NULL ORDER BY id ASC`, column, table, column))
+	if err != nil {
+		return 0, fmt.Errorf("failed to query %s: %w", table, err)
+	}
+
+	var sealed []sealedRow
+	for rows.Next() {
+		var s sealedRow
+		if err := rows.Scan(&s.id, &s.sealed); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan %s: %w", table, err)
+		}
+		sealed = append(sealed, s)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	query := fmt.Sprintf(`UPDATE %s SET %s = $1 WHERE id = $2 AND %s = $3`, table, column, column)
+
+	var updated int64
+	for _, s := range sealed {
+		resealed, err := box.Reseal(s.sealed, secret.AAD(table, column, s.id))
+		if err != nil {
+			return updated, fmt.Errorf("failed to reseal %s.%s of row %d: %w", table, column, s.id, err)
+		}
+
+		ct, err := db.Exec(ctx, query, resealed, s.id, s.sealed)
+		if err != nil {
+			return updated, fmt.Errorf("failed to update %s row %d: %w", table, s.id, err)
+		}
+		updated += ct.RowsAffected()
+	}
+
+	return updated, nil
+}
@@ -0,0 +1,219 @@
+package postgres
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"horizonx/internal/secret"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// testDB connects to HORIZONX_TEST_DATABASE_URL with a schema of its own,
+// dropped when the test ends. The test is skipped without a database.
+func testDB(t *testing.T) *pgxpool.Pool {
+	t.Helper()
+
+	url := os.Getenv("HORIZONX_TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("HORIZONX_TEST_DATABASE_URL is not set")
+	}
+
+	ctx := context.Background()
+	schema := fmt.Sprintf("horizonx_test_%d", time.Now().UnixNano())
+
+	admin, err := pgxpool.New(ctx, url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(admin.Close)
+
+	if _, err := admin.Exec(ctx, `CREATE SCHEMA `+schema); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_, _ = admin.Exec(context.Background(), `DROP SCHEMA `+schema+` CASCADE`)
+	})
+
+	cfg, err := pgxpool.ParseConfig(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.ConnConfig.RuntimeParams["search_path"] = schema
+
+	db, err := pgxpool.NewWithConfig(ctx, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(db.Close)
+
+	return db
+}
+
+// createSecretTables creates the columns ReencryptSecrets touches, as the
+// migrations leave them.
+func createSecretTables(t *testing.T, db *pgxpool.Pool) {
+	t.Helper()
+
+	_, err := db.Exec(context.Background(), `
+		CREATE TABLE environment_variables (
+			id BIGSERIAL PRIMARY KEY,
+			application_id BIGINT NOT NULL,
+			key VARCHAR(255) NOT NULL,
+			value TEXT,
+			encrypted_value BYTEA,
+			data_key BYTEA
+		);
+		CREATE TABLE git_credentials (id BIGSERIAL PRIMARY KEY, encrypted_secret BYTEA NOT NULL);
+		CREATE TABLE registry_credentials (id BIGSERIAL PRIMARY KEY, encrypted_password BYTEA NOT NULL);
+		CREATE TABLE applications (id BIGSERIAL PRIMARY KEY, webhook_secret BYTEA);
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func testBox(t *testing.T, fill byte, previous ...byte) *secret.Box {
+	t.Helper()
+
+	var prev [][]byte
+	for _, p := range previous {
+		prev = append(prev, bytes.Repeat([]byte{p}, secret.KeySize))
+	}
+
+	box, err := secret.NewBox(bytes.Repeat([]byte{fill}, secret.KeySize), prev...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return box
+}
+
+func seal(t *testing.T, box *secret.Box, value string, aad []byte) []byte {
+	t.Helper()
+
+	sealed, err := box.Seal([]byte(value), aad)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sealed
+}
+
+func TestReencryptSecrets(t *testing.T) {
+	ctx := context.Background()
+	db := testDB(t)
+	createSecretTables(t, db)
+
+	oldBox := testBox(t, 1)
+	env, err := oldBox.SealEnvelope([]byte("sealed value"), envVarAAD(1, "SEALED"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = db.Exec(ctx, `
+		INSERT INTO environment_variables (id, application_id, key, value, encrypted_value, data_key)
+		VALUES (1, 1, 'PLAIN', 'plain value', NULL, NULL), (2, 1, 'SEALED', NULL, $1, $2)
+	`, env.Ciphertext, env.DataKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(ctx, `INSERT INTO git_credentials (id, encrypted_secret) VALUES (1, $1)`, seal(t, oldBox, "ssh key", secret.AAD("git_credentials", "encrypted_secret", 1))); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(ctx, `INSERT INTO registry_credentials (id, encrypted_password) VALUES (1, $1)`, seal(t, oldBox, "registry password", secret.AAD("registry_credentials", "encrypted_password", 1))); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(ctx, `INSERT INTO applications (id, webhook_secret) VALUES (1, $1), (2, NULL)`, seal(t, oldBox, "webhook secret", secret.AAD("applications", "webhook_secret", 1))); err != nil {
+		t.Fatal(err)
+	}
+
+	updated, err := ReencryptSecrets(ctx, db, testBox(t, 2, 1))
+	if err != nil {
+		t.Fatalf("ReencryptSecrets: %v", err)
+	}
+	if updated != 5 {
+		t.Errorf("updated %d rows, want 5", updated)
+	}
+
+	// Running it again finds everything under the current key already.
+	if _, err := ReencryptSecrets(ctx, db, testBox(t, 2, 1)); err != nil {
+		t.Fatalf("second ReencryptSecrets: %v", err)
+	}
+
+	current := testBox(t, 2)
+
+	rows, err := db.Query(ctx, `SELECT id, key, value, encrypted_value, data_key FROM environment_variables ORDER BY id`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+
+	want := map[int64]string{1: "plain value", 2: "sealed value"}
+	for rows.Next() {
+		var (
+			id    int64
+			key   string
+			value *string
+			e     secret.Envelope
+		)
+		if err := rows.Scan(&id, &key, &value, &e.Ciphertext, &e.DataKey); err != nil {
+			t.Fatal(err)
+		}
+		if value != nil {
+			t.Errorf("env var %d is still stored in plain text", id)
+			continue
+		}
+
+		got, err := current.OpenEnvelope(&e, envVarAAD(1, key))
+		if err != nil || string(got) != want[id] {
+			t.Errorf("env var %d opens as %q, %v, want %q", id, got, err, want[id])
+		}
+		if _, err := oldBox.OpenEnvelope(&e, envVarAAD(1, key)); err == nil {
+			t.Errorf("env var %d still opens with the old key", id)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, c := range []struct {
+		query string
+		aad   []byte
+		want  string
+	}{
+		{`SELECT encrypted_secret FROM git_credentials WHERE id = 1`, secret.AAD("git_credentials", "encrypted_secret", 1), "ssh key"},
+		{`SELECT encrypted_password FROM registry_credentials WHERE id = 1`, secret.AAD("registry_credentials", "encrypted_password", 1), "registry password"},
+		{`SELECT webhook_secret FROM applications WHERE id = 1`, secret.AAD("applications", "webhook_secret", 1), "webhook secret"},
+	} {
+		var sealed []byte
+		if err := db.QueryRow(ctx, c.query).Scan(&sealed); err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := current.Open(sealed, c.aad)
+		if err != nil || string(got) != c.want {
+			t.Errorf("%s: opens as %q, %v, want %q", c.query, got, err, c.want)
+		}
+		if _, err := oldBox.Open(sealed, c.aad); err == nil {
+			t.Errorf("%s: still opens with the old key", c.query)
+		}
+	}
+
+	var webhookSecret []byte
+	if err := db.QueryRow(ctx, `SELECT webhook_secret FROM applications WHERE id = 2`).Scan(&webhookSecret); err != nil {
+		t.Fatal(err)
+	}
+	if webhookSecret != nil {
+		t.Error("an application without a webhook secret got one")
+	}
+
+	// A key the values were not sealed with fails on the first row instead
+	// of losing them.
+	if _, err := ReencryptSecrets(ctx, db, testBox(t, 3)); !errors.Is(err, secret.ErrCiphertext) {
+		t.Errorf("unknown key: err = %v, want ErrCiphertext", err)
+	}
+}
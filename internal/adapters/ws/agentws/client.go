@@ -17,7 +17,7 @@ const (
 	writeWait      = 10 * time.Second
 	pongWait       = 60 * time.Second
 	pingPeriod     = (pongWait * 9) / 10
-	maxMessageSize = 8192
+	maxMessageSize = 1 << 20 // container log chunks
 )
 
 type Client struct {
@@ -28,13 +28,21 @@ type Client struct {
 	conn *websocket.Conn
 	send chan []byte
 
-	log logger.Logger
-	svc domain.ServerService
+	log    logger.Logger
+	svc    domain.ServerService
+	logSvc domain.ContainerLogService
 
 	ID uuid.UUID
 }
 
-func NewClient(hub *Router, conn *websocket.Conn, log logger.Logger, svc domain.ServerService, cID uuid.UUID) *Client {
+func NewClient(
+	hub *Router,
+	conn *websocket.Conn,
+	log logger.Logger,
+	svc domain.ServerService,
+	logSvc domain.ContainerLogService,
+	cID uuid.UUID,
+) *Client {
 	ctx, cancel := context.WithCancel(hub.ctx)
 
 	return &Client{
@@ -45,8 +53,9 @@ func NewClient(hub *Router, conn *websocket.Conn, log logger.Logger, svc domain.
 		conn: conn,
 		send: make(chan []byte, 256),
 
-		log: log,
-		svc: svc,
+		log:    log,
+		svc:    svc,
+		logSvc: logSvc,
 
 		ID: cID,
 	}
@@ -104,6 +113,15 @@ func (a *Client) readPump() {
 					break
 				}
 
+			case "container_logs_chunk":
+				var chunk domain.ContainerLogsChunk
+				if err := json.Unmarshal(msg.Payload, &chunk); err != nil {
+					a.log.Error("ws: failed to unmarshal container logs payload", "error", err)
+					break
+				}
+
+				a.logSvc.HandleChunk(a.ID, chunk)
+
 			default:
 				a.log.Debug("ws: unknown agent message event", "event", msg.Event)
 			}
@@ -15,9 +15,10 @@ type Handler struct {
 	upgrader websocket.Upgrader
 	log      logger.Logger
 	svc      domain.ServerService
+	logSvc   domain.ContainerLogService
 }
 
-func NewHandler(router *Router, log logger.Logger, svc domain.ServerService) *Handler {
+func NewHandler(router *Router, log logger.Logger, svc domain.ServerService, logSvc domain.ContainerLogService) *Handler {
 	upgrader := websocket.Upgrader{
 		CheckOrigin: func(r *http.Request) bool {
 			return true
@@ -29,6 +30,7 @@ func NewHandler(router *Router, log logger.Logger, svc domain.ServerService) *Ha
 		upgrader: upgrader,
 		log:      log,
 		svc:      svc,
+		logSvc:   logSvc,
 	}
 }
 
@@ -69,7 +71,7 @@ func (h *Handler) Serve(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	a := NewClient(h.router, conn, h.log, h.svc, serverID)
+	a := NewClient(h.router, conn, h.log, h.svc, h.logSvc, serverID)
 	a.hub.register <- a
 
 	go a.writePump()
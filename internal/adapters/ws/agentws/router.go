@@ -2,7 +2,9 @@ package agentws
 
 import (
 	"context"
+	"encoding/json"
 
+	"horizonx/internal/domain"
 	"horizonx/internal/logger"
 
 	"github.com/google/uuid"
@@ -16,6 +18,7 @@ type Router struct {
 
 	register   chan *Client
 	unregister chan *Client
+	messages   chan *domain.WsServerMessage
 
 	log logger.Logger
 }
@@ -29,6 +32,7 @@ func NewRouter(parent context.Context, log logger.Logger) *Router {
 		agents:     make(map[uuid.UUID]*Client),
 		register:   make(chan *Client, 64),
 		unregister: make(chan *Client, 64),
+		messages:   make(chan *domain.WsServerMessage, 256),
 		log:        log,
 	}
 }
@@ -44,18 +48,24 @@ func (r *Router) Run() {
 			return
 
 		case a := <-r.register:
+			if existing, ok := r.agents[a.ID]; ok && existing != a {
+				close(existing.send)
+			}
 			r.agents[a.ID] = a
 			a.log.Info("ws: agent registered", "id", a.ID)
 
 		case a := <-r.unregister:
 			agent, ok := r.agents[a.ID]
-			if !ok {
+			if !ok || agent != a {
 				continue
 			}
 
 			delete(r.agents, a.ID)
 			close(agent.send)
 			r.log.Info("ws: agent unregistered", "id", a.ID)
+
+		case msg := <-r.messages:
+			r.handleMessage(msg)
 		}
 	}
 }
@@ -63,3 +73,32 @@ func (r *Router) Run() {
 func (r *Router) Stop() {
 	r.cancel()
 }
+
+func (r *Router) Send(msg *domain.WsServerMessage) {
+	select {
+	case r.messages <- msg:
+	case <-r.ctx.Done():
+	default:
+		r.log.Warn("ws: agent message buffer full, dropping message", "event", msg.Event)
+	}
+}
+
+func (r *Router) handleMessage(msg *domain.WsServerMessage) {
+	agent, ok := r.agents[msg.TargetServerID]
+	if !ok {
+		r.log.Debug("ws: target agent is not connected", "server_id", msg.TargetServerID, "event", msg.Event)
+		return
+	}
+
+	message, err := json.Marshal(msg)
+	if err != nil {
+		r.log.Error("ws: failed to marshal agent message", "error", err)
+		return
+	}
+
+	select {
+	case agent.send <- message:
+	default:
+		r.log.Warn("ws: agent channel full, dropping message", "server_id", msg.TargetServerID, "event", msg.Event)
+	}
+}
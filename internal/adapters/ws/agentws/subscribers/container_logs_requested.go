@@ -0,0 +1,34 @@
+package subscribers
+
+import (
+	"encoding/json"
+
+	"horizonx/internal/adapters/ws/agentws"
+	"horizonx/internal/domain"
+)
+
+type ContainerLogsRequested struct {
+	router *agentws.Router
+}
+
+func NewContainerLogsRequested(router *agentws.Router) *ContainerLogsRequested {
+	return &ContainerLogsRequested{router: router}
+}
+
+func (s *ContainerLogsRequested) Handle(event any) {
+	evt, ok := event.(domain.EventContainerLogsRequested)
+	if !ok {
+		return
+	}
+
+	payload, err := json.Marshal(evt.Request)
+	if err != nil {
+		return
+	}
+
+	s.router.Send(&domain.WsServerMessage{
+		TargetServerID: evt.ServerID,
+		Event:          "container_logs",
+		Payload:        payload,
+	})
+}
@@ -0,0 +1,34 @@
+package subscribers
+
+import (
+	"encoding/json"
+
+	"horizonx/internal/adapters/ws/agentws"
+	"horizonx/internal/domain"
+)
+
+type ContainerLogsStopped struct {
+	router *agentws.Router
+}
+
+func NewContainerLogsStopped(router *agentws.Router) *ContainerLogsStopped {
+	return &ContainerLogsStopped{router: router}
+}
+
+func (s *ContainerLogsStopped) Handle(event any) {
+	evt, ok := event.(domain.EventContainerLogsStopped)
+	if !ok {
+		return
+	}
+
+	payload, err := json.Marshal(domain.ContainerLogsStop{RequestID: evt.RequestID})
+	if err != nil {
+		return
+	}
+
+	s.router.Send(&domain.WsServerMessage{
+		TargetServerID: evt.ServerID,
+		Event:          "container_logs_stop",
+		Payload:        payload,
+	})
+}
@@ -0,0 +1,34 @@
+package subscribers
+
+import (
+	"encoding/json"
+
+	"horizonx/internal/adapters/ws/agentws"
+	"horizonx/internal/domain"
+)
+
+type JobCancelled struct {
+	router *agentws.Router
+}
+
+func NewJobCancelled(router *agentws.Router) *JobCancelled {
+	return &JobCancelled{router: router}
+}
+
+func (s *JobCancelled) Handle(event any) {
+	evt, ok := event.(domain.EventJobCancelled)
+	if !ok {
+		return
+	}
+
+	payload, err := json.Marshal(evt)
+	if err != nil {
+		return
+	}
+
+	s.router.Send(&domain.WsServerMessage{
+		TargetServerID: evt.ServerID,
+		Event:          "job_cancel",
+		Payload:        payload,
+	})
+}
@@ -0,0 +1,34 @@
+package subscribers
+
+import (
+	"encoding/json"
+
+	"horizonx/internal/adapters/ws/agentws"
+	"horizonx/internal/domain"
+)
+
+type JobQueued struct {
+	router *agentws.Router
+}
+
+func NewJobQueued(router *agentws.Router) *JobQueued {
+	return &JobQueued{router: router}
+}
+
+func (s *JobQueued) Handle(event any) {
+	evt, ok := event.(domain.EventJobQueued)
+	if !ok {
+		return
+	}
+
+	payload, err := json.Marshal(evt)
+	if err != nil {
+		return
+	}
+
+	s.router.Send(&domain.WsServerMessage{
+		TargetServerID: evt.ServerID,
+		Event:          "job_available",
+		Payload:        payload,
+	})
+}
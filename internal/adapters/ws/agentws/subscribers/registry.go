@@ -0,0 +1,19 @@
+package subscribers
+
+import (
+	"horizonx/internal/adapters/ws/agentws"
+)
+
+func Register(bus EventBus, router *agentws.Router) {
+	// Job Events
+	jobQueued := NewJobQueued(router)
+	jobCancelled := NewJobCancelled(router)
+	bus.Subscribe("job_queued", jobQueued.Handle)
+	bus.Subscribe("job_cancelled", jobCancelled.Handle)
+
+	// Container Log Events
+	containerLogsRequested := NewContainerLogsRequested(router)
+	containerLogsStopped := NewContainerLogsStopped(router)
+	bus.Subscribe("container_logs_requested", containerLogsRequested.Handle)
+	bus.Subscribe("container_logs_stopped", containerLogsStopped.Handle)
+}
@@ -0,0 +1,10 @@
+// Package subscribers
+package subscribers
+
+type Subscriber interface {
+	Handle(event any)
+}
+
+type EventBus interface {
+	Subscribe(eventName string, handler func(event any))
+}
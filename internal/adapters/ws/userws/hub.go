@@ -3,6 +3,7 @@ package userws
 import (
 	"context"
 	"encoding/json"
+	"sync"
 
 	"horizonx/internal/domain"
 	"horizonx/internal/logger"
@@ -15,6 +16,10 @@ type Hub struct {
 	clients  map[*Client]bool
 	channels map[string]map[*Client]bool
 
+	// channelsMu guards channels for readers outside the Run loop.
+	channelsMu sync.RWMutex
+	onEmpty    func(channel string)
+
 	register    chan *Client
 	unregister  chan *Client
 	subscribe   chan *Subscription
@@ -72,30 +77,20 @@ func (h *Hub) Run() {
 			close(c.send)
 			h.log.Info("ws: user unregistered", "id", c.ID)
 
-			for chID, subs := range h.channels {
-				if _, subsribed := subs[c]; subsribed {
-					delete(subs, c)
-					if len(subs) == 0 {
-						delete(h.channels, chID)
-					}
-				}
+			for chID := range h.channels {
+				h.leave(chID, c)
 			}
 
 		case sub := <-h.subscribe:
+			h.channelsMu.Lock()
 			if h.channels[sub.channel] == nil {
 				h.channels[sub.channel] = make(map[*Client]bool)
 			}
 			h.channels[sub.channel][sub.client] = true
+			h.channelsMu.Unlock()
 
 		case sub := <-h.unsubscribe:
-			if subs, ok := h.channels[sub.channel]; ok {
-				if _, subscribed := subs[sub.client]; subscribed {
-					delete(subs, sub.client)
-					if len(subs) == 0 {
-						delete(h.channels, sub.channel)
-					}
-				}
-			}
+			h.leave(sub.channel, sub.client)
 
 		case ev := <-h.events:
 			h.handleEvent(ev)
@@ -107,6 +102,39 @@ func (h *Hub) Stop() {
 	h.cancel()
 }
 
+// OnChannelEmpty registers fn to be called when the last client leaves a
+// channel. It must be set before Run.
+func (h *Hub) OnChannelEmpty(fn func(channel string)) {
+	h.onEmpty = fn
+}
+
+// Subscribers reports how many clients are subscribed to channel.
+func (h *Hub) Subscribers(channel string) int {
+	h.channelsMu.RLock()
+	defer h.channelsMu.RUnlock()
+	return len(h.channels[channel])
+}
+
+func (h *Hub) leave(channel string, c *Client) {
+	h.channelsMu.Lock()
+	subs, ok := h.channels[channel]
+	if !ok || !subs[c] {
+		h.channelsMu.Unlock()
+		return
+	}
+
+	delete(subs, c)
+	empty := len(subs) == 0
+	if empty {
+		delete(h.channels, channel)
+	}
+	h.channelsMu.Unlock()
+
+	if empty && h.onEmpty != nil {
+		h.onEmpty(channel)
+	}
+}
+
 func (h *Hub) Broadcast(ev *domain.WsServerEvent) {
 	select {
 	case h.events <- ev:
@@ -0,0 +1,35 @@
+package subscribers
+
+import (
+	"fmt"
+
+	"horizonx/internal/adapters/ws/userws"
+	"horizonx/internal/domain"
+)
+
+type DeploymentRolledBack struct {
+	hub *userws.Hub
+}
+
+func NewDeploymentRolledBack(hub *userws.Hub) *DeploymentRolledBack {
+	return &DeploymentRolledBack{hub: hub}
+}
+
+func (s *DeploymentRolledBack) Handle(event any) {
+	evt, ok := event.(domain.EventDeploymentRolledBack)
+	if !ok {
+		return
+	}
+
+	s.hub.Broadcast(&domain.WsServerEvent{
+		Channel: fmt.Sprintf("deployment:%d", evt.DeploymentID),
+		Event:   "deployment_rolled_back",
+		Payload: evt,
+	})
+
+	s.hub.Broadcast(&domain.WsServerEvent{
+		Channel: "deployments",
+		Event:   "deployment_rolled_back",
+		Payload: evt,
+	})
+}
@@ -31,15 +31,21 @@ func Register(bus EventBus, hub *userws.Hub) {
 	bus.Subscribe("application_created", applicationCreated.Handle)
 	bus.Subscribe("application_status_changed", applicationStatusChanged.Handle)
 
+	// Container Log Events
+	containerLogsReceived := NewContainerLogsReceived(hub)
+	bus.Subscribe("container_logs_received", containerLogsReceived.Handle)
+
 	// Deployment Events
 	deploymentCreated := NewDeploymentCreated(hub)
 	deploymentStarted := NewDeploymentStarted(hub)
 	deploymentFinished := NewDeploymentFinished(hub)
 	deploymentStatusChanged := NewDeploymentStatusChanged(hub)
 	deploymentCommitInfoReceived := NewDeploymentCommitInfoReceived(hub)
+	deploymentRolledBack := NewDeploymentRolledBack(hub)
 	bus.Subscribe("deployment_created", deploymentCreated.Handle)
 	bus.Subscribe("deployment_started", deploymentStarted.Handle)
 	bus.Subscribe("deployment_finished", deploymentFinished.Handle)
 	bus.Subscribe("deployment_status_changed", deploymentStatusChanged.Handle)
 	bus.Subscribe("deployment_commit_info_received", deploymentCommitInfoReceived.Handle)
+	bus.Subscribe("deployment_rolled_back", deploymentRolledBack.Handle)
 }
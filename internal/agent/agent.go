@@ -7,6 +7,8 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"horizonx/internal/config"
@@ -24,14 +26,24 @@ const (
 	maxMessageSize = 8192
 )
 
+type MessageHandler = func(payload json.RawMessage)
+
 type Agent struct {
 	conn *websocket.Conn
 	send chan []byte
 	cfg  *config.Config
 	log  logger.Logger
+
+	handlers   map[string][]MessageHandler
+	handlersMu sync.RWMutex
+	connected  atomic.Bool
 }
 
-var ErrUnauthorized = errors.New("connection failed: unauthorized (check token)")
+var (
+	ErrUnauthorized   = errors.New("connection failed: unauthorized (check token)")
+	ErrNotConnected   = errors.New("ws: not connected to server")
+	ErrSendBufferFull = errors.New("ws: send buffer full")
+)
 
 func IsFatalError(err error) bool {
 	return errors.Is(err, ErrUnauthorized)
@@ -42,9 +54,23 @@ func NewAgent(cfg *config.Config, log logger.Logger) *Agent {
 		send: make(chan []byte, 256),
 		cfg:  cfg,
 		log:  log,
+
+		handlers: make(map[string][]MessageHandler),
 	}
 }
 
+// On registers a handler for server messages with the given event name.
+func (a *Agent) On(event string, handler MessageHandler) {
+	a.handlersMu.Lock()
+	defer a.handlersMu.Unlock()
+	a.handlers[event] = append(a.handlers[event], handler)
+}
+
+// IsConnected reports whether the websocket session is currently established.
+func (a *Agent) IsConnected() bool {
+	return a.connected.Load()
+}
+
 func (a *Agent) Run(ctx context.Context) error {
 	a.send = make(chan []byte, 256)
 	reconnectInterval := 5 * time.Second
@@ -96,6 +122,7 @@ func (a *Agent) start(ctx context.Context) error {
 	}
 
 	a.conn = conn
+	a.connected.Store(true)
 	a.log.Info("ws connected to server", "url", a.cfg.AgentTargetWsURL)
 
 	go a.sendServerOSInfo()
@@ -105,6 +132,7 @@ func (a *Agent) start(ctx context.Context) error {
 
 	defer func() {
 		cancel()
+		a.connected.Store(false)
 		a.conn.Close()
 	}()
 
@@ -168,12 +196,33 @@ func (a *Agent) readPump(ctx context.Context) error {
 			case <-ctx.Done():
 				return ctx.Err()
 			default:
-				a.log.Debug("incoming server message", "payload", serverMessage.Payload)
+				a.log.Debug("incoming server message", "event", serverMessage.Event, "payload", serverMessage.Payload)
+				a.dispatch(serverMessage)
 			}
 		}
 	}
 }
 
+func (a *Agent) dispatch(msg domain.WsServerMessage) {
+	if msg.TargetServerID != a.cfg.AgentServerID {
+		a.log.Warn("ws: server message targets another server, ignoring", "target_server_id", msg.TargetServerID)
+		return
+	}
+
+	a.handlersMu.RLock()
+	handlers := a.handlers[msg.Event]
+	a.handlersMu.RUnlock()
+
+	if len(handlers) == 0 {
+		a.log.Debug("ws: unhandled server message event", "event", msg.Event)
+		return
+	}
+
+	for _, h := range handlers {
+		h(msg.Payload)
+	}
+}
+
 func (a *Agent) writePump(ctx context.Context) error {
 	ticker := time.NewTicker(pingPeriod)
 	defer ticker.Stop()
@@ -214,38 +263,49 @@ func (a *Agent) writePump(ctx context.Context) error {
 	}
 }
 
-func (a *Agent) sendServerOSInfo() {
-	system := system.NewReader(a.log)
-
-	osInfo := domain.OSInfo{
-		Hostname:      system.Hostname(),
-		Name:          system.OsName(),
-		Arch:          system.Arch(),
-		KernelVersion: system.KernelVersion(),
+// Send queues an event for the server. It fails instead of blocking when
+// the session is down or the send buffer is full.
+func (a *Agent) Send(event string, payload any) error {
+	if !a.connected.Load() {
+		return ErrNotConnected
 	}
 
-	payloadBytes, err := json.Marshal(osInfo)
+	payloadBytes, err := json.Marshal(payload)
 	if err != nil {
-		a.log.Error("ws: failed to marshal OS info payload", "error", err.Error())
-		return
+		return fmt.Errorf("failed to marshal %s payload: %w", event, err)
 	}
 
-	rawMessage := &domain.WsAgentMessage{
+	message, err := json.Marshal(&domain.WsAgentMessage{
 		ServerID: a.cfg.AgentServerID,
-		Event:    "server_os_info",
+		Event:    event,
 		Payload:  payloadBytes,
-	}
-
-	message, err := json.Marshal(rawMessage)
+	})
 	if err != nil {
-		a.log.Error("ws: failed to marshal full WS message", "error", err.Error())
-		return
+		return fmt.Errorf("failed to marshal ws message: %w", err)
 	}
 
 	select {
 	case a.send <- message:
-		a.log.Debug("ws: sent server OS info")
+		return nil
 	default:
-		a.log.Warn("ws: send channel full, dropping server OS info")
+		return ErrSendBufferFull
 	}
 }
+
+func (a *Agent) sendServerOSInfo() {
+	system := system.NewReader(a.log)
+
+	osInfo := domain.OSInfo{
+		Hostname:      system.Hostname(),
+		Name:          system.OsName(),
+		Arch:          system.Arch(),
+		KernelVersion: system.KernelVersion(),
+	}
+
+	if err := a.Send("server_os_info", osInfo); err != nil {
+		a.log.Warn("ws: failed to send server OS info", "error", err)
+		return
+	}
+
+	a.log.Debug("ws: sent server OS info")
+}
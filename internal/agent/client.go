@@ -4,14 +4,39 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"time"
 
 	"horizonx/internal/config"
 	"horizonx/internal/domain"
+
+	"github.com/google/uuid"
 )
 
+var ErrJobLeaseLost = errors.New("job lease lost")
+
+// StatusError is returned when the server answers with an unexpected status.
+type StatusError struct {
+	Op         string
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("failed to %s, status: %d", e.Op, e.StatusCode)
+}
+
+// Permanent reports whether retrying the request cannot succeed.
+func (e *StatusError) Permanent() bool {
+	switch e.StatusCode {
+	case http.StatusRequestTimeout, http.StatusTooManyRequests:
+		return false
+	default:
+		return e.StatusCode >= 400 && e.StatusCode < 500
+	}
+}
+
 type Client struct {
 	cfg  *config.Config
 	http *http.Client
@@ -53,14 +78,22 @@ func (c *Client) UpdateServerOSInfo(ctx context.Context, req domain.OSInfo) erro
 	return nil
 }
 
-func (c *Client) GetPendingJobs(ctx context.Context) ([]domain.Job, error) {
-	url := c.cfg.AgentTargetAPIURL + "/agent/jobs"
+// ClaimJobs leases up to limit queued jobs and up to lightLimit lightweight
+// jobs to this agent.
+func (c *Client) ClaimJobs(ctx context.Context, limit int, lightLimit int) ([]domain.Job, error) {
+	url := c.cfg.AgentTargetAPIURL + "/agent/jobs/claim"
 
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	body, err := json.Marshal(&domain.JobClaimRequest{Limit: limit, LightLimit: lightLimit})
 	if err != nil {
 		return nil, err
 	}
 
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", "Bearer "+c.cfg.AgentServerID.String()+"."+c.cfg.AgentServerAPIToken)
 
 	resp, err := c.http.Do(req)
@@ -84,8 +117,10 @@ func (c *Client) GetPendingJobs(ctx context.Context) ([]domain.Job, error) {
 	return response.Data, nil
 }
 
-func (c *Client) StartJob(ctx context.Context, jobID int64) error {
-	url := fmt.Sprintf("%s/agent/jobs/%d/start", c.cfg.AgentTargetAPIURL, jobID)
+// HeartbeatJob extends the lease of a claimed job. It returns
+// ErrJobLeaseLost when the server no longer considers this agent the owner.
+func (c *Client) HeartbeatJob(ctx context.Context, jobID int64) error {
+	url := fmt.Sprintf("%s/agent/jobs/%d/heartbeat", c.cfg.AgentTargetAPIURL, jobID)
 
 	req, err := http.NewRequestWithContext(ctx, "POST", url, nil)
 	if err != nil {
@@ -100,14 +135,17 @@ func (c *Client) StartJob(ctx context.Context, jobID int64) error {
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("failed to start job, status: %d", resp.StatusCode)
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusConflict, http.StatusNotFound:
+		return ErrJobLeaseLost
+	default:
+		return fmt.Errorf("failed to send job heartbeat, status: %d", resp.StatusCode)
 	}
-
-	return nil
 }
 
-func (c *Client) FinishJob(ctx context.Context, jobID int64, status domain.JobStatus) error {
+func (c *Client) FinishJob(ctx context.Context, eventID uuid.UUID, jobID int64, status domain.JobStatus) error {
 	url := fmt.Sprintf("%s/agent/jobs/%d/finish", c.cfg.AgentTargetAPIURL, jobID)
 
 	payload := &domain.JobFinishRequest{
@@ -126,6 +164,7 @@ func (c *Client) FinishJob(ctx context.Context, jobID int64, status domain.JobSt
 
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", "Bearer "+c.cfg.AgentServerID.String()+"."+c.cfg.AgentServerAPIToken)
+	req.Header.Set(domain.AgentEventIDHeader, eventID.String())
 
 	resp, err := c.http.Do(req)
 	if err != nil {
@@ -133,8 +172,8 @@ func (c *Client) FinishJob(ctx context.Context, jobID int64, status domain.JobSt
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("failed to mark job finished, status: %d", resp.StatusCode)
+	if !isSuccess(resp.StatusCode) {
+		return &StatusError{Op: "mark job finished", StatusCode: resp.StatusCode}
 	}
 
 	return nil
@@ -169,6 +208,35 @@ func (c *Client) SendAppHealthReports(ctx context.Context, req []domain.Applicat
 	return nil
 }
 
+func (c *Client) SendGitRemoteHeads(ctx context.Context, req []domain.GitRemoteHead) error {
+	url := fmt.Sprintf("%s/agent/applications/git-heads", c.cfg.AgentTargetAPIURL)
+
+	body, err := json.Marshal(&req)
+	if err != nil {
+		return err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", "Bearer "+c.cfg.AgentServerID.String()+"."+c.cfg.AgentServerAPIToken)
+
+	resp, err := c.http.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to send git remote heads, status: %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
 func (c *Client) SendMetrics(ctx context.Context, req *domain.Metrics) error {
 	url := fmt.Sprintf("%s/agent/metrics", c.cfg.AgentTargetAPIURL)
 
@@ -198,7 +266,7 @@ func (c *Client) SendMetrics(ctx context.Context, req *domain.Metrics) error {
 	return nil
 }
 
-func (c *Client) SendLog(ctx context.Context, req *domain.LogEmitRequest) error {
+func (c *Client) SendLog(ctx context.Context, eventID uuid.UUID, req *domain.LogEmitRequest) error {
 	url := fmt.Sprintf("%s/agent/logs", c.cfg.AgentTargetAPIURL)
 
 	body, err := json.Marshal(&req)
@@ -213,6 +281,7 @@ func (c *Client) SendLog(ctx context.Context, req *domain.LogEmitRequest) error
 
 	httpReq.Header.Set("Content-Type", "application/json")
 	httpReq.Header.Set("Authorization", "Bearer "+c.cfg.AgentServerID.String()+"."+c.cfg.AgentServerAPIToken)
+	httpReq.Header.Set(domain.AgentEventIDHeader, eventID.String())
 
 	resp, err := c.http.Do(httpReq)
 	if err != nil {
@@ -220,14 +289,48 @@ func (c *Client) SendLog(ctx context.Context, req *domain.LogEmitRequest) error
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusCreated {
-		return fmt.Errorf("failed to send log, status: %d", resp.StatusCode)
+	if !isSuccess(resp.StatusCode) {
+		return &StatusError{Op: "send log", StatusCode: resp.StatusCode}
+	}
+
+	return nil
+}
+
+// SendLogs ships a batch of log lines as NDJSON.
+func (c *Client) SendLogs(ctx context.Context, eventID uuid.UUID, reqs []domain.LogEmitRequest) error {
+	url := fmt.Sprintf("%s/agent/logs/batch", c.cfg.AgentTargetAPIURL)
+
+	var body bytes.Buffer
+	enc := json.NewEncoder(&body)
+	for i := range reqs {
+		if err := enc.Encode(&reqs[i]); err != nil {
+			return err
+		}
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, &body)
+	if err != nil {
+		return err
+	}
+
+	httpReq.Header.Set("Content-Type", "application/x-ndjson")
+	httpReq.Header.Set("Authorization", "Bearer "+c.cfg.AgentServerID.String()+"."+c.cfg.AgentServerAPIToken)
+	httpReq.Header.Set(domain.AgentEventIDHeader, eventID.String())
+
+	resp, err := c.http.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if !isSuccess(resp.StatusCode) {
+		return &StatusError{Op: "send logs", StatusCode: resp.StatusCode}
 	}
 
 	return nil
 }
 
-func (c *Client) SendCommitInfo(ctx context.Context, deploymentID int64, commitHash string, commitMessage string) error {
+func (c *Client) SendCommitInfo(ctx context.Context, eventID uuid.UUID, deploymentID int64, commitHash string, commitMessage string) error {
 	url := fmt.Sprintf("%s/agent/deployments/%d/commit-info", c.cfg.AgentTargetAPIURL, deploymentID)
 
 	payload := &domain.DeploymentCommitInfoRequest{
@@ -247,6 +350,7 @@ func (c *Client) SendCommitInfo(ctx context.Context, deploymentID int64, commitH
 
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", "Bearer "+c.cfg.AgentServerID.String()+"."+c.cfg.AgentServerAPIToken)
+	req.Header.Set(domain.AgentEventIDHeader, eventID.String())
 
 	resp, err := c.http.Do(req)
 	if err != nil {
@@ -254,9 +358,13 @@ func (c *Client) SendCommitInfo(ctx context.Context, deploymentID int64, commitH
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("failed to send deployment commit info, status: %d", resp.StatusCode)
+	if !isSuccess(resp.StatusCode) {
+		return &StatusError{Op: "send deployment commit info", StatusCode: resp.StatusCode}
 	}
 
 	return nil
 }
+
+func isSuccess(statusCode int) bool {
+	return statusCode >= 200 && statusCode < 300
+}
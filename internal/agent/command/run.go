@@ -7,9 +7,12 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"os"
 	"os/exec"
 	"strings"
 	"sync"
+	"syscall"
+	"time"
 
 	"horizonx/internal/domain"
 )
@@ -17,6 +20,10 @@ import (
 const (
 	initialScannerBufferSize = 4096
 	maxScannerBufferSize     = 10 * 1024 * 1024
+
+	// killWaitDelay bounds how long we keep reading output after the
+	// process group was killed, in case a detached child holds the pipes.
+	killWaitDelay = 5 * time.Second
 )
 
 type StreamHandler = func(line string, stream domain.LogStream, level domain.LogLevel)
@@ -25,6 +32,8 @@ type Command struct {
 	workDir string
 	name    string
 	args    []string
+	env     []string
+	stdin   io.Reader
 }
 
 func NewCommand(workDir, name string, args ...string) *Command {
@@ -35,12 +44,31 @@ func NewCommand(workDir, name string, args ...string) *Command {
 	}
 }
 
+// WithEnv adds KEY=value pairs to the environment inherited from the agent.
+func (c *Command) WithEnv(env ...string) *Command {
+	c.env = append(c.env, env...)
+	return c
+}
+
+// WithStdin feeds r to the standard input of the command, for secrets that
+// must not show up in the arguments.
+func (c *Command) WithStdin(r io.Reader) *Command {
+	c.stdin = r
+	return c
+}
+
 func (c *Command) Run(ctx context.Context, handlers ...StreamHandler) (string, error) {
-	var buf bytes.Buffer
+	// stdout and stderr are read concurrently.
+	var (
+		mu  sync.Mutex
+		buf bytes.Buffer
+	)
 
 	err := c.execute(ctx, func(line string, stream domain.LogStream, level domain.LogLevel) {
+		mu.Lock()
 		buf.WriteString(line)
 		buf.WriteString("\n")
+		mu.Unlock()
 
 		for _, h := range handlers {
 			if h != nil {
@@ -52,19 +80,41 @@ func (c *Command) Run(ctx context.Context, handlers ...StreamHandler) (string, e
 	return buf.String(), err
 }
 
+// Stream runs the command like Run but keeps no copy of the output, for
+// commands that may run for a long time.
+func (c *Command) Stream(ctx context.Context, handlers ...StreamHandler) error {
+	return c.execute(ctx, func(line string, stream domain.LogStream, level domain.LogLevel) {
+		for _, h := range handlers {
+			if h != nil {
+				h(line, stream, level)
+			}
+		}
+	})
+}
+
 func (c *Command) execute(ctx context.Context, handler StreamHandler) error {
 	cmd := exec.CommandContext(ctx, c.name, c.args...)
 	cmd.Dir = c.workDir
-
-	stdout, err := cmd.StdoutPipe()
-	if err != nil {
-		return fmt.Errorf("failed to create stdout pipe: %w", err)
+	if len(c.env) > 0 {
+		cmd.Env = append(os.Environ(), c.env...)
 	}
+	cmd.Stdin = c.stdin
 
-	stderr, err := cmd.StderrPipe()
-	if err != nil {
-		return fmt.Errorf("failed to create stderr pipe: %w", err)
+	// Run in its own process group so cancelling the context also kills
+	// the children spawned by docker compose and git.
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
 	}
+	cmd.WaitDelay = killWaitDelay
+
+	// Writers rather than StdoutPipe, so that the copying is done by exec
+	// and Wait gives up on it after WaitDelay. With pipes we would have to
+	// read them to EOF first, which a detached child can hold off forever.
+	stdout, stdoutW := io.Pipe()
+	stderr, stderrW := io.Pipe()
+	cmd.Stdout = stdoutW
+	cmd.Stderr = stderrW
 
 	if err := cmd.Start(); err != nil {
 		return fmt.Errorf("failed to start command: %w", err)
@@ -89,9 +139,11 @@ func (c *Command) execute(ctx context.Context, handler StreamHandler) error {
 		}
 	}()
 
-	wg.Wait()
-
 	cmdErr := cmd.Wait()
+	stdoutW.Close()
+	stderrW.Close()
+
+	wg.Wait()
 	close(errChan)
 
 	var streamErrs []error
@@ -100,6 +152,9 @@ func (c *Command) execute(ctx context.Context, handler StreamHandler) error {
 	}
 
 	if cmdErr != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("command aborted: %w", context.Cause(ctx))
+		}
 		return fmt.Errorf("command failed: %w", cmdErr)
 	}
 
@@ -132,6 +187,8 @@ func (c *Command) streamOutput(r io.Reader, handler StreamHandler, stream domain
 	}
 
 	if err := scanner.Err(); err != nil {
+		// Keep draining, the command blocks on a full pipe otherwise.
+		_, _ = io.Copy(io.Discard, r)
 		return fmt.Errorf("scanner error: %w", err)
 	}
 
@@ -0,0 +1,195 @@
+package agent
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"horizonx/internal/agent/docker"
+	"horizonx/internal/domain"
+	"horizonx/internal/logger"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// A one-off request answers with a single chunk, so its lines are capped
+	// to stay well below the server websocket read limit. The newest lines
+	// are kept.
+	containerLogsMaxBytes = 512 * 1024
+	containerLogsMaxLine  = 4096
+
+	// Follow streams are flushed when this many lines are waiting or after
+	// containerLogsFlushInterval, whichever comes first.
+	containerLogsBatchSize     = 100
+	containerLogsFlushInterval = 250 * time.Millisecond
+)
+
+// ContainerLogs serves container log requests pushed over the websocket.
+type ContainerLogs struct {
+	ctx    context.Context
+	docker *docker.Manager
+	send   func(event string, payload any) error
+	log    logger.Logger
+
+	streams   map[uuid.UUID]context.CancelFunc
+	streamsMu sync.Mutex
+}
+
+func NewContainerLogs(ctx context.Context, send func(event string, payload any) error, log logger.Logger) *ContainerLogs {
+	return &ContainerLogs{
+		ctx:    ctx,
+		docker: docker.NewManager(appsDir),
+		send:   send,
+		log:    log,
+
+		streams: make(map[uuid.UUID]context.CancelFunc),
+	}
+}
+
+// Handle runs req in the background.
+func (c *ContainerLogs) Handle(req domain.ContainerLogsRequest) {
+	if !req.Options.Follow {
+		go c.fetch(req)
+		return
+	}
+
+	ctx, cancel := context.WithCancel(c.ctx)
+
+	c.streamsMu.Lock()
+	if _, exists := c.streams[req.RequestID]; exists {
+		c.streamsMu.Unlock()
+		cancel()
+		return
+	}
+	c.streams[req.RequestID] = cancel
+	c.streamsMu.Unlock()
+
+	go c.follow(ctx, req)
+}
+
+// Stop ends a follow stream and reports whether it was running.
+func (c *ContainerLogs) Stop(requestID uuid.UUID) bool {
+	c.streamsMu.Lock()
+	cancel, ok := c.streams[requestID]
+	delete(c.streams, requestID)
+	c.streamsMu.Unlock()
+
+	if ok {
+		cancel()
+	}
+
+	return ok
+}
+
+func (c *ContainerLogs) fetch(req domain.ContainerLogsRequest) {
+	ctx, cancel := context.WithTimeout(c.ctx, domain.ContainerLogsTimeout)
+	defer cancel()
+
+	var (
+		mu    sync.Mutex
+		lines []domain.ContainerLogLine
+		size  int
+	)
+
+	err := c.docker.ComposeLogs(ctx, req.ApplicationID, req.Options, func(line string, stream domain.LogStream, _ domain.LogLevel) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		line = truncateLine(line)
+		lines = append(lines, domain.ContainerLogLine{Stream: stream, Line: line})
+		size += len(line)
+
+		for size > containerLogsMaxBytes && len(lines) > 0 {
+			size -= len(lines[0].Line)
+			lines = lines[1:]
+		}
+	})
+
+	chunk := domain.ContainerLogsChunk{
+		RequestID: req.RequestID,
+		Lines:     lines,
+		Done:      true,
+	}
+	if err != nil {
+		chunk.Error = err.Error()
+	}
+
+	if err := c.send("container_logs_chunk", chunk); err != nil {
+		c.log.Warn("failed to send container logs", "request_id", req.RequestID, "error", err)
+	}
+}
+
+func (c *ContainerLogs) follow(ctx context.Context, req domain.ContainerLogsRequest) {
+	defer c.Stop(req.RequestID)
+
+	var (
+		mu      sync.Mutex
+		pending []domain.ContainerLogLine
+	)
+
+	// flush sends the waiting lines. A stream whose lines cannot be
+	// delivered is stopped, the server forgets it when the session drops.
+	flush := func(done bool, runErr error) {
+		mu.Lock()
+		chunk := domain.ContainerLogsChunk{
+			RequestID: req.RequestID,
+			Lines:     pending,
+			Done:      done,
+		}
+		pending = nil
+		mu.Unlock()
+
+		if runErr != nil {
+			chunk.Error = runErr.Error()
+		}
+		if len(chunk.Lines) == 0 && !done {
+			return
+		}
+
+		if err := c.send("container_logs_chunk", chunk); err != nil {
+			c.log.Warn("failed to send container logs, stopping stream", "request_id", req.RequestID, "error", err)
+			c.Stop(req.RequestID)
+		}
+	}
+
+	go func() {
+		ticker := time.NewTicker(containerLogsFlushInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				flush(false, nil)
+			}
+		}
+	}()
+
+	err := c.docker.ComposeLogs(ctx, req.ApplicationID, req.Options, func(line string, stream domain.LogStream, _ domain.LogLevel) {
+		mu.Lock()
+		pending = append(pending, domain.ContainerLogLine{Stream: stream, Line: truncateLine(line)})
+		full := len(pending) >= containerLogsBatchSize
+		mu.Unlock()
+
+		if full {
+			flush(false, nil)
+		}
+	})
+
+	// A stop from the server or a shutdown needs no answer, anything else
+	// ends the stream on the server side too.
+	if ctx.Err() != nil {
+		return
+	}
+	flush(true, err)
+}
+
+func truncateLine(line string) string {
+	if len(line) <= containerLogsMaxLine {
+		return line
+	}
+
+	return line[:containerLogsMaxLine] + "..."
+}
@@ -0,0 +1,96 @@
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// ErrNotBlueGreenReady is returned for compose files whose projects cannot
+// run side by side.
+var ErrNotBlueGreenReady = errors.New("compose file does not support blue/green deploys")
+
+// ComposeProject is the part of the resolved compose model, as printed by
+// docker compose config, that blue/green deploys look at.
+type ComposeProject struct {
+	Services map[string]ComposeService `json:"services"`
+	Volumes  map[string]ComposeVolume  `json:"volumes"`
+}
+
+type ComposeService struct {
+	ContainerName string        `json:"container_name"`
+	Ports         []ComposePort `json:"ports"`
+}
+
+type ComposePort struct {
+	Target int `json:"target"`
+	// Published is a string in current compose releases and a number in
+	// older ones.
+	Published json.RawMessage `json:"published"`
+}
+
+type ComposeVolume struct {
+	Name     string `json:"name"`
+	External bool   `json:"external"`
+}
+
+// ProjectConfig resolves the compose files of appID for project.
+func (m *Manager) ProjectConfig(ctx context.Context, appID int64, project string) (*ComposeProject, error) {
+	output, err := m.compose(appID, project, "config", "--format", "json").Run(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg ComposeProject
+	if err := json.Unmarshal([]byte(output), &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse compose config: %w", err)
+	}
+
+	return &cfg, nil
+}
+
+// CheckBlueGreen rejects what breaks when two projects of the same compose
+// files run at once: fixed host ports and container names collide, and
+// volumes scoped to the project would start empty in the other slot.
+// Volumes with an explicit name or marked external are shared by both.
+func (c *ComposeProject) CheckBlueGreen(project string) error {
+	var problems []string
+
+	for _, name := range slices.Sorted(maps.Keys(c.Services)) {
+		svc := c.Services[name]
+		if svc.ContainerName != "" {
+			problems = append(problems, fmt.Sprintf("service %s sets container_name", name))
+		}
+		for _, port := range svc.Ports {
+			if published := port.published(); published != "" {
+				problems = append(problems, fmt.Sprintf("service %s publishes host port %s", name, published))
+			}
+		}
+	}
+
+	for _, key := range slices.Sorted(maps.Keys(c.Volumes)) {
+		vol := c.Volumes[key]
+		if !vol.External && (vol.Name == "" || vol.Name == project+"_"+key) {
+			problems = append(problems, fmt.Sprintf("volume %s is scoped to the project, give it a name or mark it external", key))
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %s", ErrNotBlueGreenReady, strings.Join(problems, "; "))
+	}
+
+	return nil
+}
+
+func (p ComposePort) published() string {
+	published := strings.Trim(string(p.Published), `"`)
+	if published == "null" || published == "0" {
+		return ""
+	}
+
+	return published
+}
@@ -4,13 +4,19 @@ package docker
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
+	"maps"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 
 	"horizonx/internal/agent/command"
+	"horizonx/internal/domain"
 )
 
 type Manager struct {
@@ -18,13 +24,31 @@ type Manager struct {
 }
 
 type Container struct {
-	ID       string `json:"ID"`
-	Name     string `json:"Name"`
-	Ports    string `json:"Ports"`
-	Project  string `json:"Project"`
-	State    string `json:"State"`
-	Health   string `json:"Health"`
-	ExitCode int    `json:"ExitCode"`
+	ID         string      `json:"ID"`
+	Name       string      `json:"Name"`
+	Service    string      `json:"Service"`
+	Ports      string      `json:"Ports"`
+	Publishers []Publisher `json:"Publishers"`
+	Project    string      `json:"Project"`
+	State      string      `json:"State"`
+	Health     string      `json:"Health"`
+	ExitCode   int         `json:"ExitCode"`
+}
+
+type Publisher struct {
+	URL           string `json:"URL"`
+	TargetPort    int    `json:"TargetPort"`
+	PublishedPort int    `json:"PublishedPort"`
+	Protocol      string `json:"Protocol"`
+}
+
+// ContainerInspect holds the docker inspect fields compose ps leaves out.
+type ContainerInspect struct {
+	ID           string `json:"Id"`
+	RestartCount int    `json:"RestartCount"`
+	State        struct {
+		StartedAt time.Time `json:"StartedAt"`
+	} `json:"State"`
 }
 
 func NewManager(workDir string) *Manager {
@@ -40,11 +64,205 @@ func (m *Manager) Initialize() error {
 }
 
 func (m *Manager) GetAppDir(appID int64) string {
-	return filepath.Join(m.workDir, fmt.Sprintf("app-%d", appID))
+	return filepath.Join(m.workDir, domain.ComposeProjectName(appID))
+}
+
+// standardComposeFiles are looked up at the repository root when an
+// application does not set a compose path, in the order compose uses.
+var standardComposeFiles = []string{
+	"compose.yaml",
+	"compose.yml",
+	"docker-compose.yaml",
+	"docker-compose.yml",
+}
+
+// ComposeConfig returns the compose settings of the last deploy of appID.
+// Every compose command of the application uses them, so start, stop and
+// logs act on what was deployed even after the settings changed.
+func (m *Manager) ComposeConfig(appID int64) domain.ComposeConfig {
+	var cfg domain.ComposeConfig
+
+	data, err := os.ReadFile(m.configFile(appID))
+	if err != nil {
+		return cfg
+	}
+
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return domain.ComposeConfig{}
+	}
+
+	return cfg
+}
+
+// SetComposeConfig records the compose settings a deploy uses.
+func (m *Manager) SetComposeConfig(appID int64, cfg domain.ComposeConfig) error {
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(m.configFile(appID), data, 0o644)
+}
+
+// BaseProject is the compose project of appID before any blue/green slot
+// suffix, the configured project name or the default one.
+func (m *Manager) BaseProject(appID int64) string {
+	return m.ComposeConfig(appID).Project(appID)
+}
+
+// ActiveProject returns the compose project currently serving appID. It is
+// the base project unless a blue/green deploy switched slots.
+func (m *Manager) ActiveProject(appID int64) string {
+	data, err := os.ReadFile(m.slotFile(appID))
+	if err != nil {
+		return m.BaseProject(appID)
+	}
+
+	if project := strings.TrimSpace(string(data)); project != "" {
+		return project
+	}
+
+	return m.BaseProject(appID)
+}
+
+// SetActiveProject records the compose project serving appID.
+func (m *Manager) SetActiveProject(appID int64, project string) error {
+	if project == m.BaseProject(appID) {
+		if err := os.Remove(m.slotFile(appID)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+
+	return os.WriteFile(m.slotFile(appID), []byte(project+"\n"), 0o644)
+}
+
+// slotFile and configFile live next to the app directory so a fresh clone
+// keeps them.
+func (m *Manager) slotFile(appID int64) string {
+	return filepath.Join(m.workDir, domain.ComposeProjectName(appID)+".slot")
+}
+
+func (m *Manager) configFile(appID int64) string {
+	return filepath.Join(m.workDir, domain.ComposeProjectName(appID)+".compose.json")
+}
+
+// composeFiles returns the -f files of cfg, relative to the app directory.
+// Without a compose path or overrides compose finds the file itself.
+func (m *Manager) composeFiles(appID int64, cfg domain.ComposeConfig) []string {
+	if cfg.Path == "" && len(cfg.Overrides) == 0 {
+		return nil
+	}
+
+	base := cfg.Path
+	if base == "" {
+		base = m.findStandardComposeFile(appID)
+	}
+	if base == "" {
+		return cfg.Overrides
+	}
+
+	return append([]string{base}, cfg.Overrides...)
+}
+
+func (m *Manager) findStandardComposeFile(appID int64) string {
+	appDir := m.GetAppDir(appID)
+	for _, f := range standardComposeFiles {
+		if _, err := os.Stat(filepath.Join(appDir, f)); err == nil {
+			return f
+		}
+	}
+
+	return ""
+}
+
+// compose builds a docker compose command for the given project of appID
+// with the compose files and profiles of its last deploy.
+func (m *Manager) compose(appID int64, project string, args ...string) *command.Command {
+	cfg := m.ComposeConfig(appID)
+
+	flags := []string{"compose", "-p", project}
+	for _, f := range m.composeFiles(appID, cfg) {
+		flags = append(flags, "-f", f)
+	}
+	for _, p := range cfg.Profiles {
+		flags = append(flags, "--profile", p)
+	}
+
+	return command.NewCommand(m.GetAppDir(appID), "docker", append(flags, args...)...)
 }
 
 func (m *Manager) ComposeUp(ctx context.Context, appID int64, detached, build bool, handlers ...command.StreamHandler) (string, error) {
-	args := []string{"compose", "up"}
+	return m.ProjectUp(ctx, appID, m.ActiveProject(appID), detached, build, handlers...)
+}
+
+func (m *Manager) ComposeDown(ctx context.Context, appID int64, removeVolumes bool, handlers ...command.StreamHandler) (string, error) {
+	return m.ProjectDown(ctx, appID, m.ActiveProject(appID), removeVolumes, handlers...)
+}
+
+// ComposeBuild builds the images of appID without touching its containers.
+func (m *Manager) ComposeBuild(ctx context.Context, appID int64, buildArgs map[string]string, handlers ...command.StreamHandler) (string, error) {
+	return m.ProjectBuild(ctx, appID, m.ActiveProject(appID), buildArgs, handlers...)
+}
+
+// ComposeSwap recreates only the containers whose image or configuration
+// changed, leaving the others running.
+func (m *Manager) ComposeSwap(ctx context.Context, appID int64, handlers ...command.StreamHandler) (string, error) {
+	return m.compose(appID, m.ActiveProject(appID), "up", "-d", "--remove-orphans").Run(ctx, handlers...)
+}
+
+func (m *Manager) ComposeStop(ctx context.Context, appID int64, handlers ...command.StreamHandler) (string, error) {
+	return m.compose(appID, m.ActiveProject(appID), "stop").Run(ctx, handlers...)
+}
+
+func (m *Manager) ComposeStart(ctx context.Context, appID int64, handlers ...command.StreamHandler) (string, error) {
+	return m.compose(appID, m.ActiveProject(appID), "start").Run(ctx, handlers...)
+}
+
+func (m *Manager) ComposeRestart(ctx context.Context, appID int64, handlers ...command.StreamHandler) (string, error) {
+	return m.compose(appID, m.ActiveProject(appID), "restart").Run(ctx, handlers...)
+}
+
+// ComposeExec runs a shell command inside a running service container.
+func (m *Manager) ComposeExec(ctx context.Context, appID int64, service, script string, handlers ...command.StreamHandler) (string, error) {
+	return m.compose(appID, m.ActiveProject(appID), "exec", "-T", service, "sh", "-c", script).Run(ctx, handlers...)
+}
+
+// ComposeLogs prints the last opts.Tail lines of the application containers
+// and, when following, keeps streaming until ctx is done.
+func (m *Manager) ComposeLogs(ctx context.Context, appID int64, opts domain.ContainerLogOptions, handlers ...command.StreamHandler) error {
+	args := []string{"logs", "--no-color", "--timestamps", "--tail", strconv.Itoa(max(opts.Tail, 0))}
+	if opts.Since != "" {
+		args = append(args, "--since", opts.Since)
+	}
+	if opts.Follow {
+		args = append(args, "--follow")
+	}
+	if opts.Service != "" {
+		args = append(args, "--", opts.Service)
+	}
+
+	return m.compose(appID, m.ActiveProject(appID), args...).Stream(ctx, handlers...)
+}
+
+func (m *Manager) ComposePs(ctx context.Context, appID int64, json bool, handlers ...command.StreamHandler) (string, error) {
+	return m.ProjectPs(ctx, appID, m.ActiveProject(appID), json, handlers...)
+}
+
+// ProjectBuild, ProjectUp, ProjectDown and ProjectPs act on an explicit
+// compose project of appID, such as the idle slot of a blue/green deploy.
+
+func (m *Manager) ProjectBuild(ctx context.Context, appID int64, project string, buildArgs map[string]string, handlers ...command.StreamHandler) (string, error) {
+	args := []string{"build"}
+	for _, key := range slices.Sorted(maps.Keys(buildArgs)) {
+		args = append(args, "--build-arg", key+"="+buildArgs[key])
+	}
+
+	return m.compose(appID, project, args...).Run(ctx, handlers...)
+}
+
+func (m *Manager) ProjectUp(ctx context.Context, appID int64, project string, detached, build bool, handlers ...command.StreamHandler) (string, error) {
+	args := []string{"up"}
 	if detached {
 		args = append(args, "-d")
 	}
@@ -52,76 +270,136 @@ func (m *Manager) ComposeUp(ctx context.Context, appID int64, detached, build bo
 		args = append(args, "--build")
 	}
 
-	cmd := command.NewCommand(m.GetAppDir(appID), "docker", args...)
-	return cmd.Run(ctx, handlers...)
+	return m.compose(appID, project, args...).Run(ctx, handlers...)
 }
 
-func (m *Manager) ComposeDown(ctx context.Context, appID int64, removeVolumes bool, handlers ...command.StreamHandler) (string, error) {
-	args := []string{"compose", "down"}
+func (m *Manager) ProjectDown(ctx context.Context, appID int64, project string, removeVolumes bool, handlers ...command.StreamHandler) (string, error) {
+	args := []string{"down"}
 	if removeVolumes {
 		args = append(args, "-v")
 	}
 
-	cmd := command.NewCommand(m.GetAppDir(appID), "docker", args...)
-	return cmd.Run(ctx, handlers...)
-}
-
-func (m *Manager) ComposeStop(ctx context.Context, appID int64, handlers ...command.StreamHandler) (string, error) {
-	cmd := command.NewCommand(m.GetAppDir(appID), "docker", "compose", "stop")
-	return cmd.Run(ctx, handlers...)
+	return m.compose(appID, project, args...).Run(ctx, handlers...)
 }
 
-func (m *Manager) ComposeStart(ctx context.Context, appID int64, handlers ...command.StreamHandler) (string, error) {
-	cmd := command.NewCommand(m.GetAppDir(appID), "docker", "compose", "start")
-	return cmd.Run(ctx, handlers...)
+// ProjectRun runs a shell command in a one-off container of service, removed
+// once the command exits. Deploy hooks run this way.
+func (m *Manager) ProjectRun(ctx context.Context, appID int64, project, service, script string, handlers ...command.StreamHandler) (string, error) {
+	return m.compose(appID, project, "run", "--rm", "-T", service, "sh", "-c", script).Run(ctx, handlers...)
 }
 
-func (m *Manager) ComposeRestart(ctx context.Context, appID int64, handlers ...command.StreamHandler) (string, error) {
-	cmd := command.NewCommand(m.GetAppDir(appID), "docker", "compose", "restart")
-	return cmd.Run(ctx, handlers...)
+// RemoveProject takes down a compose project by name alone, without its
+// compose files. It cleans up after the project of appID was renamed.
+func (m *Manager) RemoveProject(ctx context.Context, project string, handlers ...command.StreamHandler) (string, error) {
+	return command.NewCommand(m.workDir, "docker", "compose", "-p", project, "down", "--remove-orphans").Run(ctx, handlers...)
 }
 
-func (m *Manager) ComposeLogs(ctx context.Context, appID int64, tail int, handlers ...command.StreamHandler) (string, error) {
-	args := []string{"compose", "logs"}
-	if tail > 0 {
-		args = append(args, "--tail", fmt.Sprintf("%d", tail))
+// ProjectVolumes lists the volumes compose created for project. Taking the
+// project down leaves them in place.
+func (m *Manager) ProjectVolumes(ctx context.Context, project string) ([]string, error) {
+	output, err := command.NewCommand(m.workDir, "docker", "volume", "ls", "--quiet",
+		"--filter", "label=com.docker.compose.project="+project,
+	).Run(ctx)
+	if err != nil {
+		return nil, err
 	}
 
-	cmd := command.NewCommand(m.GetAppDir(appID), "docker", args...)
-	return cmd.Run(ctx, handlers...)
+	return strings.Fields(output), nil
 }
 
-func (m *Manager) ComposePs(ctx context.Context, appID int64, json bool, handlers ...command.StreamHandler) (string, error) {
-	args := []string{"compose", "ps"}
+func (m *Manager) ProjectPs(ctx context.Context, appID int64, project string, json bool, handlers ...command.StreamHandler) (string, error) {
+	args := []string{"ps", "--all"}
 	if json {
 		args = append(args, "--format", "json")
 	}
 
-	cmd := command.NewCommand(m.GetAppDir(appID), "docker", args...)
-	return cmd.Run(ctx, handlers...)
+	return m.compose(appID, project, args...).Run(ctx, handlers...)
+}
+
+// ParseContainers decodes the output of ComposePs in JSON format.
+func ParseContainers(output string) ([]Container, error) {
+	return parseJSONLines[Container](output)
+}
+
+// InspectContainers returns the inspect data of the given containers.
+func (m *Manager) InspectContainers(ctx context.Context, ids ...string) ([]ContainerInspect, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	args := append([]string{"inspect", "--type", "container", "--format", "{{json .}}"}, ids...)
+	output, err := command.NewCommand(m.workDir, "docker", args...).Run(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseJSONLines[ContainerInspect](output)
+}
+
+// parseJSONLines decodes docker output holding either one JSON object per
+// line or a single JSON array, as older compose releases print. Other lines,
+// such as warnings on stderr, are skipped.
+func parseJSONLines[T any](output string) ([]T, error) {
+	var items []T
+
+	for line := range strings.Lines(output) {
+		line = strings.TrimSpace(line)
+
+		switch {
+		case strings.HasPrefix(line, "["):
+			var batch []T
+			if err := json.Unmarshal([]byte(line), &batch); err != nil {
+				return nil, fmt.Errorf("failed to parse docker output: %w", err)
+			}
+			items = append(items, batch...)
+
+		case strings.HasPrefix(line, "{"):
+			var item T
+			if err := json.Unmarshal([]byte(line), &item); err != nil {
+				return nil, fmt.Errorf("failed to parse docker output: %w", err)
+			}
+			items = append(items, item)
+		}
+	}
+
+	return items, nil
 }
 
-func (m *Manager) ValidateDockerComposeFile(appID int64) error {
+// ValidateDockerComposeFile checks that the compose files of cfg exist in
+// the checkout of appID and do not point outside of it.
+func (m *Manager) ValidateDockerComposeFile(appID int64, cfg domain.ComposeConfig) error {
 	appDir := m.GetAppDir(appID)
-	files := []string{
-		"docker-compose.yml",
-		"docker-compose.yaml",
-		"compose.yml",
-		"compose.yaml",
+
+	if cfg.Path == "" && m.findStandardComposeFile(appID) == "" {
+		return fmt.Errorf("no docker-compose file found")
+	}
+
+	files := cfg.Overrides
+	if cfg.Path != "" {
+		files = append([]string{cfg.Path}, files...)
 	}
 
 	for _, f := range files {
-		if _, err := os.Stat(filepath.Join(appDir, f)); err == nil {
-			return nil
+		if !domain.ValidComposeFile(f) {
+			return fmt.Errorf("%w: %s is not a path inside the repository", domain.ErrInvalidDockerCompose, f)
+		}
+
+		info, err := os.Stat(filepath.Join(appDir, f))
+		if err != nil {
+			return fmt.Errorf("compose file %s not found", f)
+		}
+		if info.IsDir() {
+			return fmt.Errorf("compose file %s is a directory", f)
 		}
 	}
 
-	return fmt.Errorf("no docker-compose file found")
+	return nil
 }
 
-func (m *Manager) WriteEnvFile(appID int64, envVars map[string]string) error {
-	appDir := m.GetAppDir(appID)
-	envPath := filepath.Join(appDir, ".env")
+// WriteEnvFile writes the .env file into the project directory of cfg, the
+// directory of its compose file, where compose reads it from.
+func (m *Manager) WriteEnvFile(appID int64, cfg domain.ComposeConfig, envVars map[string]string) error {
+	envPath := filepath.Join(m.GetAppDir(appID), filepath.Dir(cfg.Path), ".env")
 
 	var buf bytes.Buffer
 	for k, v := range envVars {
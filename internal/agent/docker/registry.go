@@ -0,0 +1,104 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"horizonx/internal/agent/command"
+	"horizonx/internal/domain"
+)
+
+// RegistryLogin is a private docker client config holding the login of one
+// registry. Commands see it through DOCKER_CONFIG, the host config and its
+// logins stay untouched. Close removes it.
+type RegistryLogin struct {
+	dir string
+}
+
+// Login runs docker login into a fresh client config, a nil auth means the
+// images are public and returns a nil login.
+func (m *Manager) Login(ctx context.Context, auth *domain.RegistryAuth, handlers ...command.StreamHandler) (*RegistryLogin, error) {
+	if auth == nil {
+		return nil, nil
+	}
+
+	dir, err := os.MkdirTemp("", "horizonx-docker-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create docker config directory: %w", err)
+	}
+	l := &RegistryLogin{dir: dir}
+
+	// docker looks for CLI plugins in its config directory, compose may be
+	// installed there instead of system wide.
+	if host := hostConfigDir(); host != "" && dirExists(filepath.Join(host, "cli-plugins")) {
+		plugins := filepath.Join(host, "cli-plugins")
+		if err := os.Symlink(plugins, filepath.Join(dir, "cli-plugins")); err != nil {
+			l.Close()
+			return nil, fmt.Errorf("failed to link docker cli plugins: %w", err)
+		}
+	}
+
+	if _, err := command.NewCommand(
+		m.workDir, "docker", "--config", dir,
+		"login", "--username", auth.Username, "--password-stdin", auth.Registry,
+	).WithStdin(strings.NewReader(auth.Password)).Run(ctx, handlers...); err != nil {
+		l.Close()
+		return nil, err
+	}
+
+	return l, nil
+}
+
+// hostConfigDir is the docker client config the agent uses otherwise.
+func hostConfigDir() string {
+	if dir := os.Getenv("DOCKER_CONFIG"); dir != "" {
+		return dir
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(home, ".docker")
+}
+
+func dirExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+// Env returns the variables to add to docker commands, none for a nil
+// login.
+func (l *RegistryLogin) Env() []string {
+	if l == nil {
+		return nil
+	}
+
+	return []string{"DOCKER_CONFIG=" + l.dir}
+}
+
+// Close removes the client config and the login stored in it.
+func (l *RegistryLogin) Close() error {
+	if l == nil || l.dir == "" {
+		return nil
+	}
+
+	err := os.RemoveAll(l.dir)
+	l.dir = ""
+	return err
+}
+
+// WriteComposeFile writes the stored compose file of an image application
+// as compose.yaml, the first name compose looks for.
+func (m *Manager) WriteComposeFile(appID int64, content string) error {
+	return os.WriteFile(filepath.Join(m.GetAppDir(appID), standardComposeFiles[0]), []byte(content), 0o644)
+}
+
+// ComposePull pulls the images of appID with the registry login, if any.
+func (m *Manager) ComposePull(ctx context.Context, appID int64, login *RegistryLogin, handlers ...command.StreamHandler) (string, error) {
+	return m.compose(appID, m.ActiveProject(appID), "pull").WithEnv(login.Env()...).Run(ctx, handlers...)
+}
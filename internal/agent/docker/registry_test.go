@@ -0,0 +1,199 @@
+package docker
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"horizonx/internal/domain"
+)
+
+// stubDocker puts a docker executable on PATH that records its arguments
+// and stdin in the returned directory and exits with exitCode.
+func stubDocker(t *testing.T, exitCode string) string {
+	t.Helper()
+
+	bin := t.TempDir()
+	out := t.TempDir()
+
+	script := "#!/bin/sh\n" +
+		"printf '%s\\n' \"$@\" > \"$STUB_OUT/args\"\n" +
+		"cat > \"$STUB_OUT/stdin\"\n" +
+		"exit " + exitCode + "\n"
+	if err := os.WriteFile(filepath.Join(bin, "docker"), []byte(script), 0o755); err != nil {
+		t.Fatalf("write docker stub: %v", err)
+	}
+
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Setenv("STUB_OUT", out)
+
+	return out
+}
+
+func readStub(t *testing.T, dir, name string) string {
+	t.Helper()
+
+	raw, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		t.Fatalf("read %s: %v", name, err)
+	}
+
+	return string(raw)
+}
+
+func TestLoginNilAuth(t *testing.T) {
+	m := NewManager(t.TempDir())
+
+	l, err := m.Login(context.Background(), nil)
+	if err != nil || l != nil {
+		t.Fatalf("Login(nil) = %v, %v; want nil, nil", l, err)
+	}
+
+	// A nil login adds nothing to commands and closes cleanly.
+	if env := l.Env(); env != nil {
+		t.Errorf("Env() of nil login = %v, want nil", env)
+	}
+	if err := l.Close(); err != nil {
+		t.Errorf("Close() of nil login = %v", err)
+	}
+}
+
+func TestLogin(t *testing.T) {
+	out := stubDocker(t, "0")
+	t.Setenv("TMPDIR", t.TempDir())
+
+	host := t.TempDir()
+	if err := os.Mkdir(filepath.Join(host, "cli-plugins"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("DOCKER_CONFIG", host)
+
+	m := NewManager(t.TempDir())
+	l, err := m.Login(context.Background(), &domain.RegistryAuth{
+		Registry: "registry.example.com",
+		Username: "deploy",
+		Password: "s3cret",
+	})
+	if err != nil {
+		t.Fatalf("Login(): %v", err)
+	}
+	defer l.Close()
+
+	args := strings.Fields(readStub(t, out, "args"))
+	want := []string{"--config", l.dir, "login", "--username", "deploy", "--password-stdin", "registry.example.com"}
+	if !slices.Equal(args, want) {
+		t.Errorf("docker args = %v, want %v", args, want)
+	}
+	if slices.Contains(args, "s3cret") {
+		t.Error("password passed as an argument")
+	}
+	if stdin := readStub(t, out, "stdin"); stdin != "s3cret" {
+		t.Errorf("docker stdin = %q, want the password", stdin)
+	}
+
+	if l.dir == host || !strings.HasPrefix(l.dir, os.Getenv("TMPDIR")) {
+		t.Errorf("login config %s is not a fresh directory", l.dir)
+	}
+	if target, err := os.Readlink(filepath.Join(l.dir, "cli-plugins")); err != nil || target != filepath.Join(host, "cli-plugins") {
+		t.Errorf("cli-plugins link = %q, %v; want the host plugins", target, err)
+	}
+
+	if env := l.Env(); !slices.Equal(env, []string{"DOCKER_CONFIG=" + l.dir}) {
+		t.Errorf("Env() = %v", env)
+	}
+
+	dir := l.dir
+	if err := l.Close(); err != nil {
+		t.Fatalf("Close(): %v", err)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("login config %s still exists after Close", dir)
+	}
+	if err := l.Close(); err != nil {
+		t.Errorf("second Close() = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(host, "cli-plugins")); err != nil {
+		t.Errorf("Close removed the host cli plugins: %v", err)
+	}
+}
+
+func TestLoginWithoutHostPlugins(t *testing.T) {
+	stubDocker(t, "0")
+	t.Setenv("DOCKER_CONFIG", t.TempDir())
+
+	m := NewManager(t.TempDir())
+	l, err := m.Login(context.Background(), &domain.RegistryAuth{Registry: "r", Username: "u", Password: "p"})
+	if err != nil {
+		t.Fatalf("Login(): %v", err)
+	}
+	defer l.Close()
+
+	if _, err := os.Lstat(filepath.Join(l.dir, "cli-plugins")); !os.IsNotExist(err) {
+		t.Errorf("cli-plugins linked without host plugins: %v", err)
+	}
+}
+
+func TestLoginFailureRemovesConfig(t *testing.T) {
+	stubDocker(t, "1")
+	tmp := t.TempDir()
+	t.Setenv("TMPDIR", tmp)
+	t.Setenv("DOCKER_CONFIG", t.TempDir())
+
+	m := NewManager(t.TempDir())
+	l, err := m.Login(context.Background(), &domain.RegistryAuth{Registry: "r", Username: "u", Password: "p"})
+	if err == nil {
+		l.Close()
+		t.Fatal("Login() succeeded with a failing docker login")
+	}
+
+	entries, err := os.ReadDir(tmp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("failed login left %d entries behind", len(entries))
+	}
+}
+
+func TestHostConfigDir(t *testing.T) {
+	t.Setenv("DOCKER_CONFIG", "/srv/docker")
+	if got := hostConfigDir(); got != "/srv/docker" {
+		t.Errorf("hostConfigDir() with DOCKER_CONFIG = %q", got)
+	}
+
+	t.Setenv("DOCKER_CONFIG", "")
+	t.Setenv("HOME", "/home/agent")
+	if got := hostConfigDir(); got != "/home/agent/.docker" {
+		t.Errorf("hostConfigDir() = %q, want /home/agent/.docker", got)
+	}
+}
+
+func TestWriteComposeFile(t *testing.T) {
+	m := NewManager(t.TempDir())
+	const appID = 7
+
+	if err := os.MkdirAll(m.GetAppDir(appID), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, content := range []string{"services:\n  web:\n    image: nginx\n", "services: {}\n"} {
+		if err := m.WriteComposeFile(appID, content); err != nil {
+			t.Fatalf("WriteComposeFile(): %v", err)
+		}
+
+		raw, err := os.ReadFile(filepath.Join(m.GetAppDir(appID), "compose.yaml"))
+		if err != nil {
+			t.Fatalf("read compose file: %v", err)
+		}
+		if string(raw) != content {
+			t.Errorf("compose file = %q, want %q", raw, content)
+		}
+	}
+
+	if got := m.findStandardComposeFile(appID); got != "compose.yaml" {
+		t.Errorf("findStandardComposeFile() = %q, want the written compose.yaml", got)
+	}
+}
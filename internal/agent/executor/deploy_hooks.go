@@ -0,0 +1,126 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"horizonx/internal/agent/manifest"
+	"horizonx/internal/domain"
+)
+
+const (
+	healthURLInterval       = 2 * time.Second
+	healthURLRequestTimeout = 5 * time.Second
+)
+
+var errHealthURLFailed = errors.New("health check URL did not answer successfully")
+
+// runHooks runs manifest hooks one after the other in project. The first
+// failing hook ends the deploy.
+func (e *Executor) runHooks(ctx context.Context, appID int64, project string, hooks []manifest.Hook, step domain.LogStep, emit EmitHandler) error {
+	action := domain.ActionAppDeploy
+	handler := e.logStreamHandler(emit, action, step)
+
+	for _, hook := range hooks {
+		handler(
+			fmt.Sprintf("running %q in %s", hook.Command, hook.Service),
+			domain.StreamStdout,
+			domain.LogInfo,
+		)
+
+		if _, err := e.docker.ProjectRun(ctx, appID, project, hook.Service, hook.Command, handler); err != nil {
+			e.logFatalHandler(
+				fmt.Sprintf("%s hook %q in %s failed, %s", step, hook.Command, hook.Service, err.Error()),
+				emit,
+				action,
+				step,
+			)
+			return err
+		}
+	}
+
+	return nil
+}
+
+// finishDeploy runs the manifest steps that need the new containers to
+// serve: the health check URL, then the post-deploy hooks.
+func (e *Executor) finishDeploy(ctx context.Context, appID int64, mf *manifest.Manifest, emit EmitHandler) error {
+	if mf.HealthCheck != nil {
+		if err := e.waitHealthURL(ctx, mf.HealthCheck, emit); err != nil {
+			e.logFatalHandler(
+				fmt.Sprintf("%s is not healthy, %s", mf.HealthCheck.URL, err.Error()),
+				emit,
+				domain.ActionAppDeploy,
+				domain.StepHealthCheckURL,
+			)
+			return err
+		}
+	}
+
+	return e.runHooks(ctx, appID, e.docker.ActiveProject(appID), mf.PostDeploy, domain.StepPostDeploy, emit)
+}
+
+// waitHealthURL polls the health check URL until it answers with a status
+// below 400 or its timeout passes.
+func (e *Executor) waitHealthURL(ctx context.Context, hc *manifest.HealthCheck, emit EmitHandler) error {
+	ctx, cancel := context.WithTimeout(ctx, hc.Timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(healthURLInterval)
+	defer ticker.Stop()
+
+	client := &http.Client{
+		Timeout: healthURLRequestTimeout,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	log := e.logStreamHandler(emit, domain.ActionAppDeploy, domain.StepHealthCheckURL)
+	last := ""
+
+	for {
+		result, err := probeURL(ctx, client, hc.URL)
+		if err == nil {
+			log(fmt.Sprintf("%s answered %s", hc.URL, result), domain.StreamStdout, domain.LogInfo)
+			return nil
+		}
+
+		if err.Error() != last {
+			log(fmt.Sprintf("waiting for %s, %s", hc.URL, err.Error()), domain.StreamStdout, domain.LogInfo)
+			last = err.Error()
+		}
+
+		select {
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return fmt.Errorf("%w after %s: %s", errHealthURLFailed, hc.Timeout, last)
+			}
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// probeURL returns the status of a successful answer, redirects are not
+// followed and count as success.
+func probeURL(ctx context.Context, client *http.Client, url string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return "", fmt.Errorf("status %s", resp.Status)
+	}
+
+	return resp.Status, nil
+}
@@ -0,0 +1,129 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"os"
+
+	"horizonx/internal/domain"
+)
+
+// deployImage deploys an image application: it writes the stored compose
+// file, pulls the images and recreates the containers that changed. The
+// registry login only lives for the pull.
+func (e *Executor) deployImage(ctx context.Context, payload *domain.DeployAppPayload, emit EmitHandler) error {
+	appID := payload.ApplicationID
+	action := domain.ActionAppDeploy
+
+	if err := os.MkdirAll(e.docker.GetAppDir(appID), 0o755); err != nil {
+		return err
+	}
+
+	if err := e.docker.WriteComposeFile(appID, payload.ComposeFile); err != nil {
+		e.logFatalHandler(
+			fmt.Sprintf("failed to write compose file, %s", err.Error()),
+			emit,
+			action,
+			domain.StepBuildPrepare,
+		)
+		return err
+	}
+
+	// Paths and overrides have no meaning without a repository.
+	compose := domain.ComposeConfig{
+		Profiles:    payload.Compose.Profiles,
+		ProjectName: payload.Compose.ProjectName,
+	}
+	if err := e.docker.ValidateDockerComposeFile(appID, compose); err != nil {
+		e.logFatalHandler(
+			fmt.Sprintf("failed to validate docker compose file, %s", err.Error()),
+			emit,
+			action,
+			domain.StepBuildPrepare,
+		)
+		return err
+	}
+
+	if err := e.applyComposeConfig(ctx, appID, compose, emit); err != nil {
+		e.logFatalHandler(
+			fmt.Sprintf("failed to apply compose settings, %s", err.Error()),
+			emit,
+			action,
+			domain.StepBuildPrepare,
+		)
+		return err
+	}
+
+	env := maps.Clone(payload.EnvVars)
+	if payload.Image != "" {
+		if env == nil {
+			env = make(map[string]string)
+		}
+		env["HORIZONX_IMAGE_TAG"] = payload.Image
+		env["HORIZONX_IMAGE_REF"] = domain.ImageRefSuffix(payload.Image)
+	}
+	// Always rewritten so the variables and image tag of an earlier deploy
+	// never outlive it.
+	if err := e.docker.WriteEnvFile(appID, compose, env); err != nil {
+		e.logFatalHandler(
+			fmt.Sprintf("failed to write env, %s", err.Error()),
+			emit,
+			action,
+			domain.StepBuildPrepare,
+		)
+		return err
+	}
+
+	if err := e.pullImages(ctx, appID, payload.RegistryAuth, emit); err != nil {
+		return err
+	}
+
+	if _, err := e.docker.ComposeUp(ctx, appID, true, false, e.logStreamHandler(
+		emit,
+		action,
+		domain.StepDockerStart,
+	)); err != nil {
+		e.logFatalHandler(
+			fmt.Sprintf("failed to run docker compose up, %s", err.Error()),
+			emit,
+			action,
+			domain.StepDockerStart,
+		)
+		return err
+	}
+
+	return nil
+}
+
+func (e *Executor) pullImages(ctx context.Context, appID int64, auth *domain.RegistryAuth, emit EmitHandler) error {
+	action := domain.ActionAppDeploy
+
+	login, err := e.docker.Login(ctx, auth, e.logStreamHandler(emit, action, domain.StepDockerLogin))
+	if err != nil {
+		e.logFatalHandler(
+			fmt.Sprintf("failed to log in to %s, %s", auth.Registry, err.Error()),
+			emit,
+			action,
+			domain.StepDockerLogin,
+		)
+		return err
+	}
+	defer login.Close()
+
+	if _, err := e.docker.ComposePull(ctx, appID, login, e.logStreamHandler(
+		emit,
+		action,
+		domain.StepDockerPull,
+	)); err != nil {
+		e.logFatalHandler(
+			fmt.Sprintf("failed to run docker compose pull, %s", err.Error()),
+			emit,
+			action,
+			domain.StepDockerPull,
+		)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,298 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"horizonx/internal/agent/docker"
+	"horizonx/internal/agent/manifest"
+	"horizonx/internal/domain"
+)
+
+const (
+	// blueGreenHealthTimeout bounds how long a new slot may take to report
+	// running before it is torn down and the deploy fails.
+	blueGreenHealthTimeout  = 2 * time.Minute
+	blueGreenHealthInterval = 2 * time.Second
+)
+
+var errSlotUnhealthy = errors.New("new slot did not become healthy")
+
+// deployRecreate stops the running containers before building and starting
+// the new ones, the application is down for the whole build.
+func (e *Executor) deployRecreate(ctx context.Context, appID int64, mf *manifest.Manifest, emit EmitHandler) error {
+	action := domain.ActionAppDeploy
+
+	// Docker compose down
+	if _, err := e.docker.ComposeDown(ctx, appID, false, e.logStreamHandler(
+		emit,
+		action,
+		domain.StepDockerStop,
+	),
+	); err != nil {
+		e.logFatalHandler(
+			fmt.Sprintf("failed to run docker compose down, %s", err.Error()),
+			emit,
+			action,
+			domain.StepDockerStop,
+		)
+		return err
+	}
+
+	// Docker compose build
+	if _, err := e.docker.ComposeBuild(ctx, appID, mf.BuildArgs, e.logStreamHandler(
+		emit,
+		action,
+		domain.StepDockerBuild,
+	)); err != nil {
+		e.logFatalHandler(
+			fmt.Sprintf("failed to run docker compose build, %s", err.Error()),
+			emit,
+			action,
+			domain.StepDockerBuild,
+		)
+		return err
+	}
+
+	if err := e.runHooks(ctx, appID, e.docker.ActiveProject(appID), mf.PreDeploy, domain.StepPreDeploy, emit); err != nil {
+		return err
+	}
+
+	// Docker compose up
+	if _, err := e.docker.ComposeUp(ctx, appID, true, false, e.logStreamHandler(
+		emit,
+		action,
+		domain.StepDockerStart,
+	)); err != nil {
+		e.logFatalHandler(
+			fmt.Sprintf("failed to run docker compose up, %s", err.Error()),
+			emit,
+			action,
+			domain.StepDockerStart,
+		)
+		return err
+	}
+
+	return nil
+}
+
+// deployBuildThenSwap builds while the old containers keep serving, then
+// lets compose recreate only what changed.
+func (e *Executor) deployBuildThenSwap(ctx context.Context, appID int64, mf *manifest.Manifest, emit EmitHandler) error {
+	action := domain.ActionAppDeploy
+
+	if _, err := e.docker.ComposeBuild(ctx, appID, mf.BuildArgs, e.logStreamHandler(
+		emit,
+		action,
+		domain.StepDockerBuild,
+	)); err != nil {
+		e.logFatalHandler(
+			fmt.Sprintf("failed to run docker compose build, %s", err.Error()),
+			emit,
+			action,
+			domain.StepDockerBuild,
+		)
+		return err
+	}
+
+	if err := e.runHooks(ctx, appID, e.docker.ActiveProject(appID), mf.PreDeploy, domain.StepPreDeploy, emit); err != nil {
+		return err
+	}
+
+	if _, err := e.docker.ComposeSwap(ctx, appID, e.logStreamHandler(
+		emit,
+		action,
+		domain.StepDockerSwap,
+	)); err != nil {
+		e.logFatalHandler(
+			fmt.Sprintf("failed to swap containers, %s", err.Error()),
+			emit,
+			action,
+			domain.StepDockerSwap,
+		)
+		return err
+	}
+
+	return nil
+}
+
+// deployBlueGreen starts the new version under the idle compose project,
+// waits for it to become healthy and only then removes the old one. A slot
+// that never turns healthy is removed and the old one keeps serving.
+//
+// Both slots run at once, so compose files with fixed host ports or
+// container names are rejected before anything is built. So are volumes
+// scoped to the project, each slot would get its own empty copy; data
+// volumes need an explicit name or external: true to be shared.
+func (e *Executor) deployBlueGreen(ctx context.Context, appID int64, mf *manifest.Manifest, emit EmitHandler) error {
+	action := domain.ActionAppDeploy
+
+	current := e.docker.ActiveProject(appID)
+	next := nextSlot(e.docker.BaseProject(appID), current)
+
+	cfg, err := e.docker.ProjectConfig(ctx, appID, next)
+	if err == nil {
+		err = cfg.CheckBlueGreen(next)
+	}
+	if err != nil {
+		e.logFatalHandler(
+			fmt.Sprintf("failed to check compose file for blue/green, %s", err.Error()),
+			emit,
+			action,
+			domain.StepBuildPrepare,
+		)
+		return err
+	}
+
+	if _, err := e.docker.ProjectBuild(ctx, appID, next, mf.BuildArgs, e.logStreamHandler(
+		emit,
+		action,
+		domain.StepDockerBuild,
+	)); err != nil {
+		e.logFatalHandler(
+			fmt.Sprintf("failed to run docker compose build, %s", err.Error()),
+			emit,
+			action,
+			domain.StepDockerBuild,
+		)
+		return err
+	}
+
+	// Hooks run against the new slot, a failure leaves the old one serving.
+	if err := e.runHooks(ctx, appID, next, mf.PreDeploy, domain.StepPreDeploy, emit); err != nil {
+		e.teardownSlot(appID, next, emit)
+		return err
+	}
+
+	if _, err := e.docker.ProjectUp(ctx, appID, next, true, false, e.logStreamHandler(
+		emit,
+		action,
+		domain.StepDockerStart,
+	)); err != nil {
+		e.logFatalHandler(
+			fmt.Sprintf("failed to start %s, %s", next, err.Error()),
+			emit,
+			action,
+			domain.StepDockerStart,
+		)
+		e.teardownSlot(appID, next, emit)
+		return err
+	}
+
+	if err := e.waitSlotHealthy(ctx, appID, next, emit); err != nil {
+		e.logFatalHandler(
+			fmt.Sprintf("%s is not healthy, keeping %s, %s", next, current, err.Error()),
+			emit,
+			action,
+			domain.StepDockerHealthWait,
+		)
+		e.teardownSlot(appID, next, emit)
+		return err
+	}
+
+	if err := e.docker.SetActiveProject(appID, next); err != nil {
+		e.logFatalHandler(
+			fmt.Sprintf("failed to record active slot %s, %s", next, err.Error()),
+			emit,
+			action,
+			domain.StepDockerSwap,
+		)
+		e.teardownSlot(appID, next, emit)
+		return err
+	}
+
+	e.logStreamHandler(emit, action, domain.StepDockerSwap)(
+		fmt.Sprintf("switched from %s to %s", current, next),
+		domain.StreamStdout,
+		domain.LogInfo,
+	)
+
+	// The new slot already serves, a failure here only leaves the old
+	// containers behind.
+	if _, err := e.docker.ProjectDown(ctx, appID, current, false, e.logStreamHandler(
+		emit,
+		action,
+		domain.StepDockerTeardown,
+	)); err != nil {
+		e.logStreamHandler(emit, action, domain.StepDockerTeardown)(
+			fmt.Sprintf("failed to remove %s, %s", current, err.Error()),
+			domain.StreamStderr,
+			domain.LogWarn,
+		)
+	}
+
+	return nil
+}
+
+// waitSlotHealthy polls the containers of project until they aggregate to
+// running. Failed containers end the wait early.
+func (e *Executor) waitSlotHealthy(ctx context.Context, appID int64, project string, emit EmitHandler) error {
+	ctx, cancel := context.WithTimeout(ctx, blueGreenHealthTimeout)
+	defer cancel()
+
+	ticker := time.NewTicker(blueGreenHealthInterval)
+	defer ticker.Stop()
+
+	log := e.logStreamHandler(emit, domain.ActionAppDeploy, domain.StepDockerHealthWait)
+	last := domain.AppStatusUnknown
+
+	for {
+		output, err := e.docker.ProjectPs(ctx, appID, project, true)
+		if err == nil {
+			containers, parseErr := docker.ParseContainers(output)
+			if parseErr != nil {
+				return parseErr
+			}
+
+			status := domain.AggregateHealth(e.serviceHealths(ctx, appID, containers))
+			if status != last {
+				log(fmt.Sprintf("%s is %s", project, status), domain.StreamStdout, domain.LogInfo)
+				last = status
+			}
+
+			switch status {
+			case domain.AppStatusRunning:
+				return nil
+			case domain.AppStatusFailed, domain.AppStatusStopped:
+				return fmt.Errorf("%w: %s", errSlotUnhealthy, status)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return fmt.Errorf("%w: still %s after %s", errSlotUnhealthy, last, blueGreenHealthTimeout)
+			}
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// teardownSlot removes a slot that failed to take over. It runs on its own
+// context so a cancelled deploy still cleans up.
+func (e *Executor) teardownSlot(appID int64, project string, emit EmitHandler) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	handler := e.logStreamHandler(emit, domain.ActionAppDeploy, domain.StepDockerTeardown)
+	if _, err := e.docker.ProjectDown(ctx, appID, project, false, handler); err != nil {
+		handler(
+			fmt.Sprintf("failed to remove %s, %s", project, err.Error()),
+			domain.StreamStderr,
+			domain.LogWarn,
+		)
+	}
+}
+
+// nextSlot alternates between the blue and green slots of a base project.
+func nextSlot(base, current string) string {
+	if strings.HasSuffix(current, "-blue") {
+		return base + "-green"
+	}
+
+	return base + "-blue"
+}
@@ -4,13 +4,17 @@ package executor
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"strings"
+	"sync"
 	"time"
 
 	"horizonx/internal/agent/command"
 	"horizonx/internal/agent/docker"
 	"horizonx/internal/agent/git"
+	"horizonx/internal/agent/manifest"
 	"horizonx/internal/domain"
 	"horizonx/internal/logger"
 )
@@ -54,12 +58,47 @@ func (e *Executor) Initialize() error {
 func (e *Executor) Execute(ctx context.Context, job *domain.Job, emit EmitHandler) error {
 	e.log.Debug("executing job", "job_id", job.ID)
 
+	// Remember the step of the latest log line so a timeout can be reported
+	// against the step that was running when the deadline hit.
+	var (
+		lastStep   domain.LogStep
+		lastStepMu sync.Mutex
+	)
+	trackStep := func(event any) {
+		if evt, ok := event.(domain.EventLogEmitted); ok && evt.Context != nil && evt.Context.Step != "" {
+			lastStepMu.Lock()
+			lastStep = evt.Context.Step
+			lastStepMu.Unlock()
+		}
+		emit(event)
+	}
+
+	err := e.execute(ctx, job, trackStep)
+	if err != nil && errors.Is(context.Cause(ctx), domain.ErrJobTimeout) {
+		lastStepMu.Lock()
+		step := lastStep
+		lastStepMu.Unlock()
+
+		message := fmt.Sprintf("job timed out after %s", job.Timeout())
+		if step != "" {
+			message = fmt.Sprintf("step %s timed out after %s", step, job.Timeout())
+		}
+
+		e.logFatalHandler(message, emit, domain.LogAction(job.Type), step)
+	}
+
+	return err
+}
+
+func (e *Executor) execute(ctx context.Context, job *domain.Job, emit EmitHandler) error {
 	switch job.Type {
 	case domain.JobTypeMetricsCollect:
 		emit(e.metrics())
 		return nil
 	case domain.JobTypeAppHealthCheck:
 		return e.checkAppHealths(ctx, job, emit)
+	case domain.JobTypeAppGitPoll:
+		return e.pollGitRemotes(ctx, job, emit)
 	case domain.JobTypeAppDeploy:
 		return e.deployApp(ctx, job, emit)
 	case domain.JobTypeAppStart:
@@ -68,6 +107,8 @@ func (e *Executor) Execute(ctx context.Context, job *domain.Job, emit EmitHandle
 		return e.stopApp(ctx, job, emit)
 	case domain.JobTypeAppRestart:
 		return e.restartApp(ctx, job, emit)
+	case domain.JobTypeAppCommand:
+		return e.runCommand(ctx, job, emit)
 	default:
 		return fmt.Errorf("unknown job type: %s", job.Type)
 	}
@@ -122,6 +163,10 @@ func (e *Executor) checkAppHealths(ctx context.Context, job *domain.Job, emit Em
 	reports := make([]domain.ApplicationHealth, 0, len(payload.ApplicationsIDs))
 
 	for _, appID := range payload.ApplicationsIDs {
+		if ctx.Err() != nil {
+			return context.Cause(ctx)
+		}
+
 		output, err := e.docker.ComposePs(ctx, appID, true)
 		if err != nil {
 			// TODO: implement application docker container status
@@ -139,16 +184,8 @@ func (e *Executor) checkAppHealths(ctx context.Context, job *domain.Job, emit Em
 			continue
 		}
 
-		if output == "" {
-			reports = append(reports, domain.ApplicationHealth{
-				ApplicationID: appID,
-				Status:        domain.AppStatusUnknown,
-			})
-			continue
-		}
-
-		var c docker.Container
-		if err := json.Unmarshal([]byte(output), &c); err != nil {
+		containers, err := docker.ParseContainers(output)
+		if err != nil {
 			e.logFatalHandler(
 				fmt.Sprintf(
 					"failed to parse compose ps output server_id=%s app_id=%d err=%v",
@@ -169,38 +206,11 @@ func (e *Executor) checkAppHealths(ctx context.Context, job *domain.Job, emit Em
 			continue
 		}
 
-		var status domain.ApplicationStatus
-
-		switch c.State {
-		case "running":
-			switch c.Health {
-			case "unhealthy":
-				status = domain.AppStatusFailed
-			case "starting":
-				status = domain.AppStatusStarting
-			default:
-				status = domain.AppStatusRunning
-			}
-		case "restarting":
-			status = domain.AppStatusRestarting
-		case "exited":
-			switch c.ExitCode {
-			case 0:
-				status = domain.AppStatusStopped
-			default:
-				status = domain.AppStatusFailed
-			}
-		case "paused":
-			status = domain.AppStatusUnknown
-		case "dead":
-			status = domain.AppStatusFailed
-		default:
-			status = domain.AppStatusUnknown
-		}
-
+		services := e.serviceHealths(ctx, appID, containers)
 		reports = append(reports, domain.ApplicationHealth{
 			ApplicationID: appID,
-			Status:        status,
+			Status:        domain.AggregateHealth(services),
+			Services:      services,
 		})
 	}
 
@@ -209,12 +219,147 @@ func (e *Executor) checkAppHealths(ctx context.Context, job *domain.Job, emit Em
 	return nil
 }
 
+// pollGitRemotes reads the branch head of every target. A remote that
+// cannot be reached is reported with its error, the others still are.
+func (e *Executor) pollGitRemotes(ctx context.Context, job *domain.Job, emit EmitHandler) error {
+	var payload domain.AppGitPollPayload
+	if err := json.Unmarshal(job.Payload, &payload); err != nil {
+		return err
+	}
+
+	heads := make([]domain.GitRemoteHead, 0, len(payload.Targets))
+
+	for _, target := range payload.Targets {
+		if ctx.Err() != nil {
+			return context.Cause(ctx)
+		}
+
+		head := domain.GitRemoteHead{ApplicationID: target.ApplicationID}
+
+		hash, err := e.lsRemote(ctx, target)
+		switch {
+		case err != nil:
+			head.Error = err.Error()
+		case hash == "":
+			head.Error = fmt.Sprintf("branch %s not found on remote", target.Branch)
+		default:
+			head.CommitHash = hash
+		}
+
+		if head.Error != "" {
+			e.logStreamHandler(emit, domain.ActionAppGitPoll, domain.StepGitLsRemote)(
+				fmt.Sprintf("app_id=%d: %s", target.ApplicationID, head.Error),
+				domain.StreamStderr,
+				domain.LogWarn,
+			)
+		}
+
+		heads = append(heads, head)
+	}
+
+	emit(heads)
+
+	return nil
+}
+
+func (e *Executor) lsRemote(ctx context.Context, target domain.GitPollTarget) (string, error) {
+	auth, err := git.NewAuth(target.GitAuth)
+	if err != nil {
+		return "", err
+	}
+	defer auth.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, domain.GitRemoteTimeout)
+	defer cancel()
+
+	return e.git.LsRemote(ctx, target.RepoURL, target.Branch, auth)
+}
+
+// serviceHealths reports every container of the application, one entry per
+// replica.
+func (e *Executor) serviceHealths(ctx context.Context, appID int64, containers []docker.Container) []domain.ServiceHealth {
+	ids := make([]string, 0, len(containers))
+	for _, c := range containers {
+		ids = append(ids, c.ID)
+	}
+
+	// Restart counts and start times are nice to have, a failed inspect
+	// still leaves a usable report.
+	inspected := make(map[string]docker.ContainerInspect, len(containers))
+	details, err := e.docker.InspectContainers(ctx, ids...)
+	if err != nil {
+		e.log.Debug("failed to inspect containers", "app_id", appID, "err", err.Error())
+	}
+	for _, d := range details {
+		inspected[d.ID] = d
+	}
+
+	now := time.Now().UTC()
+	services := make([]domain.ServiceHealth, 0, len(containers))
+
+	for _, c := range containers {
+		svc := domain.ServiceHealth{
+			Service:   c.Service,
+			Container: c.Name,
+			State:     c.State,
+			Health:    c.Health,
+			ExitCode:  c.ExitCode,
+			Ports:     make([]domain.ServicePort, 0, len(c.Publishers)),
+		}
+
+		for _, p := range c.Publishers {
+			svc.Ports = append(svc.Ports, domain.ServicePort{
+				HostIP:        p.URL,
+				PublishedPort: p.PublishedPort,
+				TargetPort:    p.TargetPort,
+				Protocol:      p.Protocol,
+			})
+		}
+
+		if d, ok := inspectedByID(inspected, c.ID); ok {
+			svc.RestartCount = d.RestartCount
+
+			if !d.State.StartedAt.IsZero() {
+				startedAt := d.State.StartedAt.UTC()
+				svc.StartedAt = &startedAt
+
+				if c.State == "running" {
+					svc.UptimeSeconds = int64(now.Sub(startedAt).Seconds())
+				}
+			}
+		}
+
+		services = append(services, svc)
+	}
+
+	return services
+}
+
+// inspectedByID looks a container up by the short ID compose ps prints.
+func inspectedByID(inspected map[string]docker.ContainerInspect, id string) (docker.ContainerInspect, bool) {
+	if d, ok := inspected[id]; ok {
+		return d, true
+	}
+
+	for fullID, d := range inspected {
+		if strings.HasPrefix(fullID, id) {
+			return d, true
+		}
+	}
+
+	return docker.ContainerInspect{}, false
+}
+
 func (e *Executor) deployApp(ctx context.Context, job *domain.Job, emit EmitHandler) error {
 	var payload domain.DeployAppPayload
 	if err := json.Unmarshal(job.Payload, &payload); err != nil {
 		return err
 	}
 
+	if payload.Source == domain.AppSourceImage {
+		return e.deployImage(ctx, &payload, emit)
+	}
+
 	appID := payload.ApplicationID
 	appDir := e.git.GetAppDir(appID)
 	action := domain.ActionAppDeploy
@@ -224,8 +369,21 @@ func (e *Executor) deployApp(ctx context.Context, job *domain.Job, emit EmitHand
 		return err
 	}
 
+	// Repository credentials, removed from disk when the deploy ends
+	auth, err := git.NewAuth(payload.GitAuth)
+	if err != nil {
+		e.logFatalHandler(
+			fmt.Sprintf("failed to prepare git credentials, %s", err.Error()),
+			emit,
+			action,
+			domain.StepGitClone,
+		)
+		return err
+	}
+	defer auth.Close()
+
 	// Git clone or pull
-	if _, err := e.git.CloneOrPull(ctx, appID, payload.RepoURL, payload.Branch, e.logStreamHandler(
+	if _, err := e.git.CloneOrPull(ctx, appID, payload.RepoURL, payload.Branch, auth, e.logStreamHandler(
 		emit,
 		action,
 		domain.StepGitClone,
@@ -240,6 +398,23 @@ func (e *Executor) deployApp(ctx context.Context, job *domain.Job, emit EmitHand
 		return err
 	}
 
+	// Deploy a pinned commit or tag instead of the branch head
+	if payload.Ref != "" {
+		if _, err := e.git.CheckoutRef(ctx, appID, payload.Ref, auth, e.logStreamHandler(
+			emit,
+			action,
+			domain.StepGitClone,
+		)); err != nil {
+			e.logFatalHandler(
+				fmt.Sprintf("failed to check out %s, %s", payload.Ref, err.Error()),
+				emit,
+				action,
+				domain.StepGitClone,
+			)
+			return err
+		}
+	}
+
 	// Get git commit info
 	if job.DeploymentID != nil {
 		hash, err := e.git.GetCurrentCommit(ctx, appID)
@@ -267,13 +442,46 @@ func (e *Executor) deployApp(ctx context.Context, job *domain.Job, emit EmitHand
 
 		emit(domain.EventCommitInfoEmitted{
 			DeploymentID: *job.DeploymentID,
-			Hash:         hash[:8],
+			Hash:         strings.TrimSpace(hash),
 			Message:      message,
 		})
 	}
 
+	// Deployment manifest, optional
+	mf, err := manifest.Read(appDir)
+	if err != nil {
+		e.logFatalHandler(
+			fmt.Sprintf("failed to read deployment manifest, %s", err.Error()),
+			emit,
+			action,
+			domain.StepManifestRead,
+		)
+		return err
+	}
+	if mf == nil {
+		mf = &manifest.Manifest{}
+	} else {
+		e.logStreamHandler(emit, action, domain.StepManifestRead)(
+			fmt.Sprintf("using %s", mf.File),
+			domain.StreamStdout,
+			domain.LogInfo,
+		)
+	}
+
+	if missing := mf.MissingEnv(payload.EnvVars); len(missing) > 0 {
+		err := fmt.Errorf("required env vars not set: %s", strings.Join(missing, ", "))
+		e.logFatalHandler(err.Error(), emit, action, domain.StepManifestRead)
+		return err
+	}
+
+	// The compose path of the application wins over the manifest
+	compose := payload.Compose
+	if compose.Path == "" {
+		compose.Path = mf.ComposeFile
+	}
+
 	// Validate docker compose file
-	if err := e.docker.ValidateDockerComposeFile(appID); err != nil {
+	if err := e.docker.ValidateDockerComposeFile(appID, compose); err != nil {
 		e.logFatalHandler(
 			fmt.Sprintf("failed to validate docker compose file, %s", err.Error()),
 			emit,
@@ -283,9 +491,19 @@ func (e *Executor) deployApp(ctx context.Context, job *domain.Job, emit EmitHand
 		return err
 	}
 
+	if err := e.applyComposeConfig(ctx, appID, compose, emit); err != nil {
+		e.logFatalHandler(
+			fmt.Sprintf("failed to apply compose settings, %s", err.Error()),
+			emit,
+			action,
+			domain.StepBuildPrepare,
+		)
+		return err
+	}
+
 	// Write env
 	if len(payload.EnvVars) > 0 {
-		if err := e.docker.WriteEnvFile(appID, payload.EnvVars); err != nil {
+		if err := e.docker.WriteEnvFile(appID, compose, payload.EnvVars); err != nil {
 			e.logFatalHandler(
 				fmt.Sprintf("failed to write env, %s", err.Error()),
 				emit,
@@ -296,38 +514,62 @@ func (e *Executor) deployApp(ctx context.Context, job *domain.Job, emit EmitHand
 		}
 	}
 
-	// Docker compose down
-	if _, err := e.docker.ComposeDown(ctx, appID, false, e.logStreamHandler(
-		emit,
-		action,
-		domain.StepDockerStop,
-	),
-	); err != nil {
-		e.logFatalHandler(
-			fmt.Sprintf("failed to run docker compose down, %s", err.Error()),
-			emit,
-			action,
-			domain.StepDockerStop,
-		)
+	switch payload.Strategy {
+	case domain.DeployBuildThenSwap:
+		err = e.deployBuildThenSwap(ctx, appID, mf, emit)
+	case domain.DeployBlueGreen:
+		err = e.deployBlueGreen(ctx, appID, mf, emit)
+	default:
+		err = e.deployRecreate(ctx, appID, mf, emit)
+	}
+	if err != nil {
 		return err
 	}
 
-	// Docker compose up
-	if _, err := e.docker.ComposeUp(ctx, appID, true, true, e.logStreamHandler(
-		emit,
-		action,
-		domain.StepDockerBuild,
-	)); err != nil {
-		e.logFatalHandler(
-			fmt.Sprintf("failed to run docker compose up, %s", err.Error()),
-			emit,
-			action,
-			domain.StepDockerBuild,
+	return e.finishDeploy(ctx, appID, mf, emit)
+}
+
+// applyComposeConfig records the compose settings of a deploy. When the
+// project name changed, the containers of the old project are removed first,
+// the deploy starts the application under the new name. Volumes of the old
+// project are not carried over, they are kept and named in a warning.
+func (e *Executor) applyComposeConfig(ctx context.Context, appID int64, cfg domain.ComposeConfig, emit EmitHandler) error {
+	project := cfg.Project(appID)
+	if e.docker.BaseProject(appID) == project {
+		return e.docker.SetComposeConfig(appID, cfg)
+	}
+
+	old := e.docker.ActiveProject(appID)
+	handler := e.logStreamHandler(emit, domain.ActionAppDeploy, domain.StepBuildPrepare)
+	handler(
+		fmt.Sprintf("compose project renamed to %s, removing %s", project, old),
+		domain.StreamStdout,
+		domain.LogInfo,
+	)
+
+	if _, err := e.docker.RemoveProject(ctx, old, handler); err != nil {
+		return fmt.Errorf("failed to remove %s: %w", old, err)
+	}
+
+	volumes, err := e.docker.ProjectVolumes(ctx, old)
+	if err != nil {
+		return fmt.Errorf("failed to list volumes of %s: %w", old, err)
+	}
+	if len(volumes) > 0 {
+		handler(
+			fmt.Sprintf("volumes of %s are kept but not used by %s, copy their data over or name them in the compose file: %s",
+				old, project, strings.Join(volumes, ", ")),
+			domain.StreamStderr,
+			domain.LogWarn,
 		)
+	}
+
+	if err := e.docker.SetComposeConfig(appID, cfg); err != nil {
 		return err
 	}
 
-	return nil
+	// The slot of the old project does not carry over.
+	return e.docker.SetActiveProject(appID, project)
 }
 
 func (e *Executor) startApp(ctx context.Context, job *domain.Job, emit EmitHandler) error {
@@ -404,3 +646,28 @@ func (e *Executor) restartApp(ctx context.Context, job *domain.Job, emit EmitHan
 
 	return nil
 }
+
+func (e *Executor) runCommand(ctx context.Context, job *domain.Job, emit EmitHandler) error {
+	var payload domain.RunCommandAppPayload
+	if err := json.Unmarshal(job.Payload, &payload); err != nil {
+		return err
+	}
+
+	appID := payload.ApplicationID
+
+	if _, err := e.docker.ComposeExec(ctx, appID, payload.Service, payload.Command, e.logStreamHandler(
+		emit,
+		domain.ActionAppCommand,
+		domain.StepDockerExec,
+	)); err != nil {
+		e.logFatalHandler(
+			fmt.Sprintf("failed to run command in service %s, %s", payload.Service, err.Error()),
+			emit,
+			domain.ActionAppCommand,
+			domain.StepDockerExec,
+		)
+		return err
+	}
+
+	return nil
+}
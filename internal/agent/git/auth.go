@@ -0,0 +1,125 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"horizonx/internal/domain"
+)
+
+// credentialHelper answers git credential requests from the environment so
+// the token never reaches a file or the remote URL.
+const credentialHelper = `!f() { test "$1" = get && printf 'username=%s\npassword=%s\n' "$HORIZONX_GIT_USERNAME" "$HORIZONX_GIT_TOKEN"; }; f`
+
+// Auth is the environment that lets git reach a private repository. SSH keys
+// live in a private temporary directory until Close.
+type Auth struct {
+	env []string
+	dir string
+}
+
+// NewAuth prepares auth for git commands, a nil auth means the repository
+// is public.
+func NewAuth(auth *domain.GitAuth) (*Auth, error) {
+	if auth == nil {
+		return nil, nil
+	}
+
+	// Never fall back to an interactive prompt, a job has no terminal.
+	a := &Auth{env: []string{"GIT_TERMINAL_PROMPT=0"}}
+
+	switch auth.Type {
+	case domain.GitCredentialSSHKey:
+		if err := a.prepareSSH(auth); err != nil {
+			a.Close()
+			return nil, err
+		}
+
+	case domain.GitCredentialHTTPSToken:
+		// The empty helper first clears any helper from the host config.
+		a.env = append(a.env,
+			"GIT_CONFIG_COUNT=2",
+			"GIT_CONFIG_KEY_0=credential.helper",
+			"GIT_CONFIG_VALUE_0=",
+			"GIT_CONFIG_KEY_1=credential.helper",
+			"GIT_CONFIG_VALUE_1="+credentialHelper,
+			"HORIZONX_GIT_USERNAME="+auth.Username,
+			"HORIZONX_GIT_TOKEN="+auth.Secret,
+		)
+
+	default:
+		return nil, fmt.Errorf("unsupported git credential type %q", auth.Type)
+	}
+
+	return a, nil
+}
+
+func (a *Auth) prepareSSH(auth *domain.GitAuth) error {
+	// Accepting whatever key the first connection offers verifies nothing,
+	// the server refuses SSH credentials without pinned host keys.
+	if strings.TrimSpace(auth.KnownHosts) == "" {
+		return fmt.Errorf("ssh credential has no known hosts, refusing to connect to an unverified host")
+	}
+
+	dir, err := os.MkdirTemp("", "horizonx-git-")
+	if err != nil {
+		return fmt.Errorf("failed to create key directory: %w", err)
+	}
+	a.dir = dir
+
+	keyPath := filepath.Join(dir, "id")
+	key := strings.TrimSpace(auth.Secret) + "\n"
+	if err := os.WriteFile(keyPath, []byte(key), 0o600); err != nil {
+		return fmt.Errorf("failed to write key: %w", err)
+	}
+
+	ssh := []string{
+		"ssh",
+		"-i", shellQuote(keyPath),
+		"-o", "IdentitiesOnly=yes",
+		"-o", "IdentityAgent=none",
+		"-o", "BatchMode=yes",
+	}
+
+	// Only the pinned host keys are trusted, the known_hosts of the agent
+	// user is never read nor written.
+	knownHostsPath := filepath.Join(dir, "known_hosts")
+	if err := os.WriteFile(knownHostsPath, []byte(strings.TrimSpace(auth.KnownHosts)+"\n"), 0o600); err != nil {
+		return fmt.Errorf("failed to write known hosts: %w", err)
+	}
+	ssh = append(ssh,
+		"-o", "UserKnownHostsFile="+shellQuote(knownHostsPath),
+		"-o", "GlobalKnownHostsFile=/dev/null",
+		"-o", "StrictHostKeyChecking=yes",
+	)
+
+	a.env = append(a.env, "GIT_SSH_COMMAND="+strings.Join(ssh, " "))
+
+	return nil
+}
+
+// Env returns the variables to add to git commands, none for a nil auth.
+func (a *Auth) Env() []string {
+	if a == nil {
+		return nil
+	}
+
+	return a.env
+}
+
+// Close removes the temporary key files.
+func (a *Auth) Close() error {
+	if a == nil || a.dir == "" {
+		return nil
+	}
+
+	err := os.RemoveAll(a.dir)
+	a.dir = ""
+	return err
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -7,8 +7,11 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
+	"sync"
 
 	"horizonx/internal/agent/command"
+	"horizonx/internal/domain"
 )
 
 type Manager struct {
@@ -23,25 +26,27 @@ func (m *Manager) GetAppDir(appID int64) string {
 	return filepath.Join(m.workDir, fmt.Sprintf("app-%d", appID))
 }
 
-func (m *Manager) CloneOrPull(ctx context.Context, appID int64, remoteURL, branch string, handlers ...command.StreamHandler) (string, error) {
+// CloneOrPull, Clone, Pull and CheckoutRef reach the remote with auth, nil
+// for public repositories.
+func (m *Manager) CloneOrPull(ctx context.Context, appID int64, remoteURL, branch string, auth *Auth, handlers ...command.StreamHandler) (string, error) {
 	appDir := m.GetAppDir(appID)
 
 	if yes := m.IsGitRepo(appDir); yes {
-		return m.Pull(ctx, appID, branch, handlers...)
+		return m.Pull(ctx, appID, branch, auth, handlers...)
 	}
 
-	return m.Clone(ctx, appID, remoteURL, branch, handlers...)
+	return m.Clone(ctx, appID, remoteURL, branch, auth, handlers...)
 }
 
-func (m *Manager) Clone(ctx context.Context, appID int64, remoteURL, branch string, handlers ...command.StreamHandler) (string, error) {
+func (m *Manager) Clone(ctx context.Context, appID int64, remoteURL, branch string, auth *Auth, handlers ...command.StreamHandler) (string, error) {
 	appDir := m.GetAppDir(appID)
 	args := []string{"clone", "--branch", branch, "--depth", "1", remoteURL, appDir}
 
-	cmd := command.NewCommand(appDir, "git", args...)
+	cmd := command.NewCommand(appDir, "git", args...).WithEnv(auth.Env()...)
 	return cmd.Run(ctx, handlers...)
 }
 
-func (m *Manager) Pull(ctx context.Context, appID int64, branch string, handlers ...command.StreamHandler) (string, error) {
+func (m *Manager) Pull(ctx context.Context, appID int64, branch string, auth *Auth, handlers ...command.StreamHandler) (string, error) {
 	appDir := m.GetAppDir(appID)
 
 	checkout := command.NewCommand(appDir, "git", "checkout", branch)
@@ -49,10 +54,114 @@ func (m *Manager) Pull(ctx context.Context, appID int64, branch string, handlers
 		return output, err
 	}
 
-	pull := command.NewCommand(appDir, "git", "pull", "origin", branch)
+	pull := command.NewCommand(appDir, "git", "pull", "origin", branch).WithEnv(auth.Env()...)
 	return pull.Run(ctx, handlers...)
 }
 
+// CheckoutRef fetches a single commit SHA or tag, which a shallow clone may
+// not hold, and checks it out detached. Servers only fetch full SHAs, an
+// abbreviated one, as recorded by older deployments, is looked up after
+// fetching the whole history.
+func (m *Manager) CheckoutRef(ctx context.Context, appID int64, ref string, auth *Auth, handlers ...command.StreamHandler) (string, error) {
+	appDir := m.GetAppDir(appID)
+
+	target := "FETCH_HEAD"
+	fetch := command.NewCommand(appDir, "git", "fetch", "--depth", "1", "--no-tags", "--", "origin", ref).WithEnv(auth.Env()...)
+	if output, err := fetch.Run(ctx, handlers...); err != nil {
+		if !isAbbrevSHA(ref) {
+			return output, err
+		}
+
+		sha, err := m.resolveAbbrevSHA(ctx, appDir, ref, auth, handlers...)
+		if err != nil {
+			return "", err
+		}
+		target = sha
+	}
+
+	checkout := command.NewCommand(appDir, "git", "checkout", "--detach", target)
+	return checkout.Run(ctx, handlers...)
+}
+
+// resolveAbbrevSHA fetches every branch with its full history and expands
+// ref to the commit it abbreviates.
+func (m *Manager) resolveAbbrevSHA(ctx context.Context, appDir, ref string, auth *Auth, handlers ...command.StreamHandler) (string, error) {
+	args := []string{"fetch", "--no-tags"}
+
+	shallow, err := command.NewCommand(appDir, "git", "rev-parse", "--is-shallow-repository").Run(ctx)
+	if err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(shallow) == "true" {
+		args = append(args, "--unshallow")
+	}
+	args = append(args, "--", "origin", "+refs/heads/*:refs/remotes/origin/*")
+
+	fetch := command.NewCommand(appDir, "git", args...).WithEnv(auth.Env()...)
+	if output, err := fetch.Run(ctx, handlers...); err != nil {
+		return output, err
+	}
+
+	sha, err := command.NewCommand(appDir, "git", "rev-parse", "--verify", "--quiet", "--end-of-options", ref+"^{commit}").Run(ctx)
+	if err != nil {
+		return "", fmt.Errorf("commit %s not found on any branch: %w", ref, err)
+	}
+
+	return strings.TrimSpace(sha), nil
+}
+
+// isAbbrevSHA reports whether ref looks like a shortened commit SHA, git
+// needs at least 4 hex digits to expand one.
+func isAbbrevSHA(ref string) bool {
+	if len(ref) < 4 || len(ref) >= 40 {
+		return false
+	}
+
+	for _, c := range ref {
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f') {
+			return false
+		}
+	}
+
+	return true
+}
+
+// LsRemote returns the commit the branch points to on the remote, empty when
+// the branch does not exist. Nothing is fetched.
+func (m *Manager) LsRemote(ctx context.Context, remoteURL, branch string, auth *Auth) (string, error) {
+	ref := "refs/heads/" + branch
+
+	// stdout and stderr are read concurrently.
+	var (
+		mu     sync.Mutex
+		hash   string
+		stderr []string
+	)
+
+	cmd := command.NewCommand(m.workDir, "git", "ls-remote", "--", remoteURL, ref).WithEnv(auth.Env()...)
+	err := cmd.Stream(ctx, func(line string, stream domain.LogStream, _ domain.LogLevel) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if stream != domain.StreamStdout {
+			stderr = append(stderr, line)
+			return
+		}
+
+		if sha, name, ok := strings.Cut(line, "\t"); ok && name == ref {
+			hash = sha
+		}
+	})
+	if err != nil {
+		if len(stderr) > 0 {
+			return "", fmt.Errorf("%w: %s", err, strings.Join(stderr, "; "))
+		}
+		return "", err
+	}
+
+	return hash, nil
+}
+
 func (m *Manager) GetCurrentCommit(ctx context.Context, appID int64, handlers ...command.StreamHandler) (string, error) {
 	appDir := m.GetAppDir(appID)
 
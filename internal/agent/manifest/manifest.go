@@ -0,0 +1,158 @@
+// Package manifest
+package manifest
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"horizonx/internal/domain"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FileNames are looked up at the repository root in this order.
+var FileNames = []string{".horizonx.yaml", ".horizonx.yml"}
+
+// DefaultHealthCheckTimeout bounds the health check when the manifest does
+// not set a timeout.
+const DefaultHealthCheckTimeout = time.Minute
+
+// Manifest is the optional deployment manifest of a repository. It adds to
+// what the application settings describe, settings made on the application
+// win over the manifest.
+type Manifest struct {
+	// File is the name the manifest was read from.
+	File string `yaml:"-"`
+
+	ComposeFile string            `yaml:"compose_file"`
+	BuildArgs   map[string]string `yaml:"build_args"`
+
+	// PreDeploy runs after the images are built and before the new
+	// containers start, PostDeploy once they serve. Each hook runs in a
+	// one-off container of its service.
+	PreDeploy  []Hook `yaml:"pre_deploy"`
+	PostDeploy []Hook `yaml:"post_deploy"`
+
+	HealthCheck *HealthCheck `yaml:"health_check"`
+	RequiredEnv []string     `yaml:"required_env"`
+}
+
+type Hook struct {
+	Service string `yaml:"service"`
+	Command string `yaml:"command"`
+}
+
+// HealthCheck is polled from the server until it answers with a 2xx or 3xx
+// status, the deploy fails when it does not within Timeout.
+type HealthCheck struct {
+	URL     string        `yaml:"url"`
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+// Read loads the manifest at the root of dir. It returns nil without an
+// error when the repository has none.
+func Read(dir string) (*Manifest, error) {
+	for _, name := range FileNames {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		m, err := parse(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		m.File = name
+
+		return m, nil
+	}
+
+	return nil, nil
+}
+
+func parse(data []byte) (*Manifest, error) {
+	m := &Manifest{}
+
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(m); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	if err := m.validate(); err != nil {
+		return nil, err
+	}
+
+	if m.HealthCheck != nil && m.HealthCheck.Timeout == 0 {
+		m.HealthCheck.Timeout = DefaultHealthCheckTimeout
+	}
+
+	return m, nil
+}
+
+func (m *Manifest) validate() error {
+	if m.ComposeFile != "" && !domain.ValidComposeFile(m.ComposeFile) {
+		return fmt.Errorf("compose_file %s is not a path inside the repository", m.ComposeFile)
+	}
+
+	for key := range m.BuildArgs {
+		if key == "" {
+			return errors.New("build_args has an empty name")
+		}
+	}
+
+	if err := validateHooks("pre_deploy", m.PreDeploy); err != nil {
+		return err
+	}
+	if err := validateHooks("post_deploy", m.PostDeploy); err != nil {
+		return err
+	}
+
+	if hc := m.HealthCheck; hc != nil {
+		u, err := url.Parse(hc.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("health_check url %q is not an http or https URL", hc.URL)
+		}
+		if hc.Timeout < 0 {
+			return errors.New("health_check timeout must not be negative")
+		}
+	}
+
+	if slices.Contains(m.RequiredEnv, "") {
+		return errors.New("required_env has an empty name")
+	}
+
+	return nil
+}
+
+func validateHooks(name string, hooks []Hook) error {
+	for i, h := range hooks {
+		if h.Service == "" || h.Command == "" {
+			return fmt.Errorf("%s[%d] needs a service and a command", name, i)
+		}
+	}
+
+	return nil
+}
+
+// MissingEnv returns the required env keys without a value in env.
+func (m *Manifest) MissingEnv(env map[string]string) []string {
+	var missing []string
+	for _, key := range m.RequiredEnv {
+		if env[key] == "" {
+			missing = append(missing, key)
+		}
+	}
+
+	return missing
+}
@@ -0,0 +1,162 @@
+package manifest
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{name: "empty", input: ""},
+		{
+			name: "full",
+			input: `
+compose_file: deploy/compose.yml
+build_args:
+  NODE_ENV: production
+pre_deploy:
+  - service: web
+    command: ./migrate
+post_deploy:
+  - service: worker
+    command: ./warm-cache
+health_check:
+  url: https://example.com/healthz
+  timeout: 30s
+required_env:
+  - DATABASE_URL
+`,
+		},
+		{name: "unknown field", input: "compose_fil: compose.yml\n", wantErr: "compose_fil"},
+		{name: "misplaced field", input: "health_check:\n  url: http://x\n  retries: 3\n", wantErr: "retries"},
+		{name: "compose file outside repo", input: "compose_file: ../compose.yml\n", wantErr: "compose_file"},
+		{name: "absolute compose file", input: "compose_file: /etc/compose.yml\n", wantErr: "compose_file"},
+		{name: "empty build arg", input: "build_args:\n  \"\": x\n", wantErr: "build_args"},
+		{name: "hook without command", input: "pre_deploy:\n  - service: web\n", wantErr: "pre_deploy[0]"},
+		{name: "hook without service", input: "post_deploy:\n  - command: ls\n", wantErr: "post_deploy[0]"},
+		{name: "health check without scheme", input: "health_check:\n  url: example.com/healthz\n", wantErr: "health_check url"},
+		{name: "health check other scheme", input: "health_check:\n  url: ftp://example.com\n", wantErr: "health_check url"},
+		{name: "health check without host", input: "health_check:\n  url: http://\n", wantErr: "health_check url"},
+		{name: "negative timeout", input: "health_check:\n  url: http://x\n  timeout: -1s\n", wantErr: "timeout"},
+		{name: "empty required env", input: "required_env: [A, \"\"]\n", wantErr: "required_env"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parse([]byte(tt.input))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("parse() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("parse() error = %v, want one mentioning %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseHealthCheckTimeout(t *testing.T) {
+	tests := []struct {
+		input string
+		want  time.Duration
+	}{
+		{"health_check:\n  url: http://x\n", DefaultHealthCheckTimeout},
+		{"health_check:\n  url: http://x\n  timeout: 10s\n", 10 * time.Second},
+	}
+
+	for _, tt := range tests {
+		m, err := parse([]byte(tt.input))
+		if err != nil {
+			t.Fatalf("parse(%q): %v", tt.input, err)
+		}
+		if m.HealthCheck.Timeout != tt.want {
+			t.Errorf("parse(%q) timeout = %s, want %s", tt.input, m.HealthCheck.Timeout, tt.want)
+		}
+	}
+}
+
+func TestRead(t *testing.T) {
+	t.Run("missing", func(t *testing.T) {
+		m, err := Read(t.TempDir())
+		if err != nil || m != nil {
+			t.Fatalf("Read() = %v, %v; want nil, nil", m, err)
+		}
+	})
+
+	t.Run("yml", func(t *testing.T) {
+		dir := t.TempDir()
+		writeFile(t, dir, ".horizonx.yml", "compose_file: compose.yml\n")
+
+		m, err := Read(dir)
+		if err != nil {
+			t.Fatalf("Read(): %v", err)
+		}
+		if m.File != ".horizonx.yml" || m.ComposeFile != "compose.yml" {
+			t.Errorf("Read() = %+v", m)
+		}
+	})
+
+	t.Run("yaml wins over yml", func(t *testing.T) {
+		dir := t.TempDir()
+		writeFile(t, dir, ".horizonx.yaml", "compose_file: a.yml\n")
+		writeFile(t, dir, ".horizonx.yml", "compose_file: b.yml\n")
+
+		m, err := Read(dir)
+		if err != nil {
+			t.Fatalf("Read(): %v", err)
+		}
+		if m.File != ".horizonx.yaml" || m.ComposeFile != "a.yml" {
+			t.Errorf("Read() = %+v", m)
+		}
+	})
+
+	t.Run("invalid names the file", func(t *testing.T) {
+		dir := t.TempDir()
+		writeFile(t, dir, ".horizonx.yaml", "bogus: true\n")
+
+		if _, err := Read(dir); err == nil || !strings.HasPrefix(err.Error(), ".horizonx.yaml:") {
+			t.Fatalf("Read() error = %v, want one prefixed with the file name", err)
+		}
+	})
+}
+
+func TestMissingEnv(t *testing.T) {
+	m := &Manifest{RequiredEnv: []string{"DATABASE_URL", "SECRET_KEY", "PORT"}}
+
+	tests := []struct {
+		name string
+		env  map[string]string
+		want []string
+	}{
+		{"nil env", nil, []string{"DATABASE_URL", "SECRET_KEY", "PORT"}},
+		{"all set", map[string]string{"DATABASE_URL": "x", "SECRET_KEY": "y", "PORT": "80"}, nil},
+		{"empty value", map[string]string{"DATABASE_URL": "x", "SECRET_KEY": "", "PORT": "80"}, []string{"SECRET_KEY"}},
+		{"missing key", map[string]string{"SECRET_KEY": "y"}, []string{"DATABASE_URL", "PORT"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := m.MissingEnv(tt.env); !slices.Equal(got, tt.want) {
+				t.Errorf("MissingEnv() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func writeFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+		t.Fatalf("write %s: %v", name, err)
+	}
+}
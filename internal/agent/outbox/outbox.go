@@ -0,0 +1,291 @@
+// Package outbox
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"horizonx/internal/logger"
+
+	"github.com/google/uuid"
+)
+
+const (
+	entriesFile = "outbox.jsonl"
+	offsetFile  = "outbox.offset"
+
+	minRetryDelay = time.Second
+	maxRetryDelay = time.Minute
+	idleInterval  = 5 * time.Second
+
+	// maxReadChunk bounds how much of a large backlog is held in memory per
+	// replay pass.
+	maxReadChunk = 4 << 20
+)
+
+// ErrDrop tells the outbox that an entry can never be delivered and should be
+// skipped instead of retried.
+var ErrDrop = errors.New("outbox: drop entry")
+
+type Kind string
+
+const (
+	// KindLog holds a single log line, only written by older agents; kept so
+	// their outbox can still be replayed.
+	KindLog         Kind = "log"
+	KindLogBatch    Kind = "log_batch"
+	KindJobFinished Kind = "job_finished"
+	KindCommitInfo  Kind = "commit_info"
+)
+
+type Entry struct {
+	ID        uuid.UUID       `json:"id"`
+	Kind      Kind            `json:"kind"`
+	CreatedAt time.Time       `json:"created_at"`
+	Payload   json.RawMessage `json:"payload"`
+}
+
+type DeliverFunc = func(ctx context.Context, entry Entry) error
+
+// Outbox is an append-only file of entries waiting to be delivered to the
+// control plane. Entries are replayed in the order they were appended and
+// the replay position survives agent restarts.
+type Outbox struct {
+	dir  string
+	log  logger.Logger
+	file *os.File
+
+	mu     sync.Mutex
+	notify chan struct{}
+}
+
+func Open(dir string, log logger.Logger) (*Outbox, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create outbox dir: %w", err)
+	}
+
+	file, err := os.OpenFile(filepath.Join(dir, entriesFile), os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open outbox: %w", err)
+	}
+
+	return &Outbox{
+		dir:    dir,
+		log:    log,
+		file:   file,
+		notify: make(chan struct{}, 1),
+	}, nil
+}
+
+func (o *Outbox) Close() error {
+	return o.file.Close()
+}
+
+// Append stores a new entry. Job results and commit info are synced to disk
+// right away; log lines are left to the page cache so a chatty build does
+// not pay an fsync per line.
+func (o *Outbox) Append(kind Kind, payload any) error {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	line, err := json.Marshal(Entry{
+		ID:        uuid.New(),
+		Kind:      kind,
+		CreatedAt: time.Now().UTC(),
+		Payload:   raw,
+	})
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	o.mu.Lock()
+	_, err = o.file.Write(line)
+	if err == nil && kind != KindLog && kind != KindLogBatch {
+		err = o.file.Sync()
+	}
+	o.mu.Unlock()
+
+	if err != nil {
+		return fmt.Errorf("failed to append outbox entry: %w", err)
+	}
+
+	select {
+	case o.notify <- struct{}{}:
+	default:
+	}
+
+	return nil
+}
+
+// Run delivers pending entries until ctx is done. A failed delivery is
+// retried with exponential backoff and blocks the entries behind it, so the
+// server always sees them in order.
+func (o *Outbox) Run(ctx context.Context, deliver DeliverFunc) error {
+	offset, err := o.readOffset()
+	if err != nil {
+		return err
+	}
+
+	// The agent stopped between truncating the outbox and resetting the
+	// offset, everything in the file is new.
+	if info, err := o.file.Stat(); err == nil && info.Size() < offset {
+		offset = 0
+	}
+
+	ticker := time.NewTicker(idleInterval)
+	defer ticker.Stop()
+
+	for {
+		offset, err = o.replay(ctx, offset, deliver)
+		if err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-o.notify:
+		case <-ticker.C:
+		}
+	}
+}
+
+func (o *Outbox) replay(ctx context.Context, offset int64, deliver DeliverFunc) (int64, error) {
+	pending, err := o.pending(offset)
+	if err != nil {
+		return offset, err
+	}
+
+	for len(pending) > 0 {
+		// A line without its newline is still being written
+		end := bytes.IndexByte(pending, '\n')
+		if end < 0 {
+			break
+		}
+
+		line := pending[:end]
+		pending = pending[end+1:]
+
+		var entry Entry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			o.log.Error("outbox: skipping corrupt entry", "offset", offset, "error", err)
+		} else if err := o.deliverWithRetry(ctx, entry, deliver); err != nil {
+			return offset, err
+		}
+
+		offset += int64(end + 1)
+		if err := o.writeOffset(offset); err != nil {
+			return offset, err
+		}
+	}
+
+	return o.compact(offset)
+}
+
+func (o *Outbox) deliverWithRetry(ctx context.Context, entry Entry, deliver DeliverFunc) error {
+	delay := minRetryDelay
+
+	for {
+		err := deliver(ctx, entry)
+		if err == nil {
+			return nil
+		}
+
+		if errors.Is(err, ErrDrop) {
+			o.log.Warn("outbox: dropping undeliverable entry", "id", entry.ID, "kind", entry.Kind, "error", err)
+			return nil
+		}
+
+		o.log.Warn("outbox: delivery failed, retrying", "id", entry.ID, "kind", entry.Kind, "retry_in", delay, "error", err)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+
+		delay = min(delay*2, maxRetryDelay)
+	}
+}
+
+func (o *Outbox) pending(offset int64) ([]byte, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	info, err := o.file.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	if info.Size() <= offset {
+		return nil, nil
+	}
+
+	buf := make([]byte, min(info.Size()-offset, maxReadChunk))
+	if _, err := o.file.ReadAt(buf, offset); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to read outbox: %w", err)
+	}
+
+	return buf, nil
+}
+
+// compact truncates the outbox once every entry has been delivered.
+func (o *Outbox) compact(offset int64) (int64, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	info, err := o.file.Stat()
+	if err != nil {
+		return offset, err
+	}
+
+	if offset == 0 || info.Size() != offset {
+		return offset, nil
+	}
+
+	if err := o.file.Truncate(0); err != nil {
+		return offset, fmt.Errorf("failed to compact outbox: %w", err)
+	}
+
+	return 0, o.writeOffset(0)
+}
+
+func (o *Outbox) readOffset() (int64, error) {
+	raw, err := os.ReadFile(filepath.Join(o.dir, offsetFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read outbox offset: %w", err)
+	}
+
+	offset, err := strconv.ParseInt(string(bytes.TrimSpace(raw)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid outbox offset: %w", err)
+	}
+
+	return offset, nil
+}
+
+func (o *Outbox) writeOffset(offset int64) error {
+	path := filepath.Join(o.dir, offsetFile)
+	tmp := path + ".tmp"
+
+	if err := os.WriteFile(tmp, []byte(strconv.FormatInt(offset, 10)), 0o600); err != nil {
+		return fmt.Errorf("failed to write outbox offset: %w", err)
+	}
+
+	return os.Rename(tmp, path)
+}
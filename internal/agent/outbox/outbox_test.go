@@ -0,0 +1,316 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+type nopLogger struct{}
+
+func (nopLogger) Debug(msg string, args ...any) {}
+func (nopLogger) Info(msg string, args ...any)  {}
+func (nopLogger) Warn(msg string, args ...any)  {}
+func (nopLogger) Error(msg string, args ...any) {}
+
+// recorder collects the payloads of delivered entries.
+type recorder struct {
+	mu        sync.Mutex
+	delivered []string
+	calls     map[string]int
+	fail      func(payload string, call int) error
+}
+
+func (r *recorder) deliver(ctx context.Context, entry Entry) error {
+	var payload string
+	if err := json.Unmarshal(entry.Payload, &payload); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.calls == nil {
+		r.calls = make(map[string]int)
+	}
+	r.calls[payload]++
+
+	if r.fail != nil {
+		if err := r.fail(payload, r.calls[payload]); err != nil {
+			return err
+		}
+	}
+
+	r.delivered = append(r.delivered, payload)
+	return nil
+}
+
+func (r *recorder) snapshot() ([]string, map[string]int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	calls := make(map[string]int, len(r.calls))
+	for k, v := range r.calls {
+		calls[k] = v
+	}
+
+	return append([]string(nil), r.delivered...), calls
+}
+
+func openOutbox(t *testing.T, dir string) *Outbox {
+	t.Helper()
+
+	o, err := Open(dir, nopLogger{})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	t.Cleanup(func() { o.Close() })
+
+	return o
+}
+
+func appendAll(t *testing.T, o *Outbox, payloads ...string) {
+	t.Helper()
+
+	for _, p := range payloads {
+		if err := o.Append(KindJobFinished, p); err != nil {
+			t.Fatalf("Append(%q): %v", p, err)
+		}
+	}
+}
+
+// runUntil runs the outbox until done reports true, then stops it.
+func runUntil(t *testing.T, o *Outbox, deliver DeliverFunc, done func() bool) {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() { errCh <- o.Run(ctx, deliver) }()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !done() {
+		if time.Now().After(deadline) {
+			cancel()
+			<-errCh
+			t.Fatal("timed out waiting for the outbox")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	cancel()
+	if err := <-errCh; !errors.Is(err, context.Canceled) {
+		t.Fatalf("Run: %v", err)
+	}
+}
+
+func fileSize(t *testing.T, path string) int64 {
+	t.Helper()
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("stat %s: %v", path, err)
+	}
+
+	return info.Size()
+}
+
+func storedOffset(t *testing.T, dir string) string {
+	t.Helper()
+
+	raw, err := os.ReadFile(filepath.Join(dir, offsetFile))
+	if err != nil {
+		t.Fatalf("read offset: %v", err)
+	}
+
+	return string(raw)
+}
+
+func TestRunDeliversInOrderAndCompacts(t *testing.T) {
+	dir := t.TempDir()
+	o := openOutbox(t, dir)
+	appendAll(t, o, "a", "b", "c")
+
+	rec := &recorder{}
+	entries := filepath.Join(dir, entriesFile)
+	runUntil(t, o, rec.deliver, func() bool {
+		got, _ := rec.snapshot()
+		return len(got) == 3 && fileSize(t, entries) == 0
+	})
+
+	got, _ := rec.snapshot()
+	if want := []string{"a", "b", "c"}; !slices.Equal(got, want) {
+		t.Errorf("delivered %v, want %v", got, want)
+	}
+	if off := storedOffset(t, dir); off != "0" {
+		t.Errorf("offset after compaction = %q, want 0", off)
+	}
+}
+
+func TestRunResumesFromStoredOffset(t *testing.T) {
+	dir := t.TempDir()
+	o := openOutbox(t, dir)
+	appendAll(t, o, "a")
+	first := fileSize(t, filepath.Join(dir, entriesFile))
+	appendAll(t, o, "b", "c")
+	o.Close()
+
+	// A previous run delivered "a" and stopped before compacting.
+	if err := o.writeOffset(first); err != nil {
+		t.Fatalf("writeOffset: %v", err)
+	}
+
+	o = openOutbox(t, dir)
+	rec := &recorder{}
+	runUntil(t, o, rec.deliver, func() bool {
+		got, _ := rec.snapshot()
+		return len(got) == 2
+	})
+
+	got, _ := rec.snapshot()
+	if want := []string{"b", "c"}; !slices.Equal(got, want) {
+		t.Errorf("delivered %v, want %v", got, want)
+	}
+}
+
+func TestRunResetsOffsetPastEndOfFile(t *testing.T) {
+	dir := t.TempDir()
+	o := openOutbox(t, dir)
+
+	// The agent stopped after truncating the file but before resetting the
+	// offset; anything appended since must still be delivered.
+	if err := o.writeOffset(1 << 20); err != nil {
+		t.Fatalf("writeOffset: %v", err)
+	}
+	appendAll(t, o, "a", "b")
+
+	rec := &recorder{}
+	runUntil(t, o, rec.deliver, func() bool {
+		got, _ := rec.snapshot()
+		return len(got) == 2
+	})
+
+	got, _ := rec.snapshot()
+	if want := []string{"a", "b"}; !slices.Equal(got, want) {
+		t.Errorf("delivered %v, want %v", got, want)
+	}
+}
+
+func TestRunRejectsInvalidOffset(t *testing.T) {
+	dir := t.TempDir()
+	o := openOutbox(t, dir)
+
+	if err := os.WriteFile(filepath.Join(dir, offsetFile), []byte("garbage"), 0o600); err != nil {
+		t.Fatalf("write offset: %v", err)
+	}
+
+	if err := o.Run(context.Background(), (&recorder{}).deliver); err == nil {
+		t.Fatal("Run with a corrupt offset file succeeded")
+	}
+}
+
+func TestRunSkipsCorruptEntries(t *testing.T) {
+	dir := t.TempDir()
+	o := openOutbox(t, dir)
+	appendAll(t, o, "a")
+	if _, err := o.file.Write([]byte("{not json\n")); err != nil {
+		t.Fatalf("write corrupt line: %v", err)
+	}
+	appendAll(t, o, "b")
+
+	rec := &recorder{}
+	runUntil(t, o, rec.deliver, func() bool {
+		got, _ := rec.snapshot()
+		return len(got) == 2
+	})
+
+	got, _ := rec.snapshot()
+	if want := []string{"a", "b"}; !slices.Equal(got, want) {
+		t.Errorf("delivered %v, want %v", got, want)
+	}
+}
+
+func TestRunDropsUndeliverableEntries(t *testing.T) {
+	dir := t.TempDir()
+	o := openOutbox(t, dir)
+	appendAll(t, o, "a", "bad", "c")
+
+	rec := &recorder{
+		fail: func(payload string, call int) error {
+			if payload == "bad" {
+				return fmt.Errorf("%w: rejected with 400", ErrDrop)
+			}
+			return nil
+		},
+	}
+	entries := filepath.Join(dir, entriesFile)
+	runUntil(t, o, rec.deliver, func() bool {
+		got, _ := rec.snapshot()
+		return len(got) == 2 && fileSize(t, entries) == 0
+	})
+
+	got, calls := rec.snapshot()
+	if want := []string{"a", "c"}; !slices.Equal(got, want) {
+		t.Errorf("delivered %v, want %v", got, want)
+	}
+	if calls["bad"] != 1 {
+		t.Errorf("dropped entry attempted %d times, want 1", calls["bad"])
+	}
+}
+
+func TestRunRetriesTransientFailuresInOrder(t *testing.T) {
+	dir := t.TempDir()
+	o := openOutbox(t, dir)
+	appendAll(t, o, "a", "b")
+
+	rec := &recorder{
+		fail: func(payload string, call int) error {
+			if payload == "a" && call == 1 {
+				return errors.New("connection refused")
+			}
+			return nil
+		},
+	}
+	runUntil(t, o, rec.deliver, func() bool {
+		got, _ := rec.snapshot()
+		return len(got) == 2
+	})
+
+	got, calls := rec.snapshot()
+	if want := []string{"a", "b"}; !slices.Equal(got, want) {
+		t.Errorf("delivered %v, want %v", got, want)
+	}
+	if calls["a"] != 2 {
+		t.Errorf("failed entry attempted %d times, want 2", calls["a"])
+	}
+	if calls["b"] != 1 {
+		t.Errorf("entry behind the failure attempted %d times, want 1", calls["b"])
+	}
+}
+
+func TestOffsetRoundTrip(t *testing.T) {
+	o := openOutbox(t, t.TempDir())
+
+	if off, err := o.readOffset(); err != nil || off != 0 {
+		t.Fatalf("readOffset without a file = %d, %v; want 0, nil", off, err)
+	}
+
+	for _, want := range []int64{0, 42, 1 << 40} {
+		if err := o.writeOffset(want); err != nil {
+			t.Fatalf("writeOffset(%d): %v", want, err)
+		}
+		got, err := o.readOffset()
+		if err != nil {
+			t.Fatalf("readOffset: %v", err)
+		}
+		if got != want {
+			t.Errorf("readOffset = %d, want %d", got, want)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package agent
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"horizonx/internal/domain"
+)
+
+// lightLaneSize is the number of lightweight jobs that may run at once,
+// independent of the main pool so they never wait behind a long build.
+const lightLaneSize = 2
+
+// jobPool runs jobs concurrently up to a fixed limit. Jobs of the same
+// application are queued and run one at a time in the order they were
+// submitted, since they share the app-N working directory.
+type jobPool struct {
+	slots      chan struct{}
+	lightSlots chan struct{}
+
+	appQueues map[int64][]func()
+	appMu     sync.Mutex
+
+	// pending and lightPending count the submitted jobs of each lane that
+	// have not finished, running or waiting for a slot.
+	pending      atomic.Int64
+	lightPending atomic.Int64
+
+	wg sync.WaitGroup
+}
+
+func newJobPool(size int) *jobPool {
+	if size <= 0 {
+		size = 1
+	}
+
+	return &jobPool{
+		slots:      make(chan struct{}, size),
+		lightSlots: make(chan struct{}, lightLaneSize),
+		appQueues:  make(map[int64][]func()),
+	}
+}
+
+func (p *jobPool) submit(job domain.Job, run func()) {
+	p.wg.Add(1)
+
+	if job.Type.IsLight() {
+		p.lightPending.Add(1)
+		go p.runIn(p.lightSlots, &p.lightPending, run)
+		return
+	}
+
+	p.pending.Add(1)
+
+	if job.ApplicationID == nil {
+		go p.runIn(p.slots, &p.pending, run)
+		return
+	}
+
+	appID := *job.ApplicationID
+
+	p.appMu.Lock()
+	queue, active := p.appQueues[appID]
+	p.appQueues[appID] = append(queue, run)
+	p.appMu.Unlock()
+
+	if !active {
+		go p.drainApp(appID)
+	}
+}
+
+// free returns how many more jobs the main pool and the lightweight lane
+// can each start right away.
+func (p *jobPool) free() (int, int) {
+	return max(cap(p.slots)-int(p.pending.Load()), 0),
+		max(cap(p.lightSlots)-int(p.lightPending.Load()), 0)
+}
+
+// wait blocks until every submitted job has finished.
+func (p *jobPool) wait() {
+	p.wg.Wait()
+}
+
+func (p *jobPool) runIn(slots chan struct{}, pending *atomic.Int64, run func()) {
+	defer p.wg.Done()
+	defer pending.Add(-1)
+
+	slots <- struct{}{}
+	defer func() { <-slots }()
+
+	run()
+}
+
+func (p *jobPool) drainApp(appID int64) {
+	for {
+		p.appMu.Lock()
+		queue := p.appQueues[appID]
+		if len(queue) == 0 {
+			delete(p.appQueues, appID)
+			p.appMu.Unlock()
+			return
+		}
+		run := queue[0]
+		p.appQueues[appID] = queue[1:]
+		p.appMu.Unlock()
+
+		p.runIn(p.slots, &p.pending, run)
+	}
+}
@@ -3,22 +3,59 @@ package agent
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"path/filepath"
 	"sync"
 	"time"
 
 	"horizonx/internal/agent/executor"
+	"horizonx/internal/agent/outbox"
 	"horizonx/internal/config"
 	"horizonx/internal/domain"
 	"horizonx/internal/event"
 	"horizonx/internal/logger"
 )
 
+const (
+	appsDir = "/var/horizonx/apps"
+
+	pollInterval         = 5 * time.Second
+	fallbackPollInterval = 30 * time.Second
+
+	// Log lines are shipped in batches, flushed when full or after
+	// logFlushInterval, whichever comes first.
+	logBatchSize     = 200
+	logFlushInterval = time.Second
+)
+
 type JobWorker struct {
 	cfg      *config.Config
 	log      logger.Logger
 	client   *Client
 	executor *executor.Executor
+
+	wake       chan struct{}
+	pushActive func() bool
+	lastPoll   time.Time
+
+	running   map[int64]context.CancelCauseFunc
+	runningMu sync.Mutex
+
+	pool   *jobPool
+	outbox *outbox.Outbox
+}
+
+type jobFinishedEntry struct {
+	JobID  int64            `json:"job_id"`
+	Status domain.JobStatus `json:"status"`
+}
+
+type commitInfoEntry struct {
+	DeploymentID int64  `json:"deployment_id"`
+	Hash         string `json:"hash"`
+	Message      string `json:"message"`
 }
 
 func NewJobWorker(cfg *config.Config, log logger.Logger, metrics func() *domain.Metrics) *JobWorker {
@@ -26,27 +63,70 @@ func NewJobWorker(cfg *config.Config, log logger.Logger, metrics func() *domain.
 		cfg:      cfg,
 		log:      log,
 		client:   NewClient(cfg),
-		executor: executor.NewExecutor("/var/horizonx/apps", metrics, log),
+		executor: executor.NewExecutor(appsDir, metrics, log),
+
+		wake:    make(chan struct{}, 1),
+		running: make(map[int64]context.CancelCauseFunc),
+
+		pool: newJobPool(cfg.AgentMaxJobs),
+	}
+}
+
+// UsePush tells the worker that job notifications are being pushed over the
+// agent websocket. While isActive reports true, HTTP polling only runs every
+// fallbackPollInterval as a safety net.
+func (w *JobWorker) UsePush(isActive func() bool) {
+	w.pushActive = isActive
+}
+
+// Notify wakes the worker up to fetch pending jobs immediately.
+func (w *JobWorker) Notify() {
+	select {
+	case w.wake <- struct{}{}:
+	default:
 	}
 }
 
 func (w *JobWorker) Initialize() error {
+	ob, err := outbox.Open(filepath.Join(w.cfg.AgentStateDir, "outbox"), w.log)
+	if err != nil {
+		return err
+	}
+	w.outbox = ob
+
 	return w.executor.Initialize()
 }
 
 func (w *JobWorker) Start(ctx context.Context) error {
-	ticker := time.NewTicker(5 * time.Second)
+	ticker := time.NewTicker(pollInterval)
 	defer ticker.Stop()
 
-	w.log.Info("job worker started, polling for jobs...")
+	go func() {
+		if err := w.outbox.Run(ctx, w.deliver); err != nil && !errors.Is(err, context.Canceled) {
+			w.log.Error("outbox replay stopped", "error", err)
+		}
+	}()
+
+	w.log.Info("job worker started, waiting for jobs...")
 
 	for {
 		select {
 		case <-ctx.Done():
-			w.log.Info("job worker stopping...")
+			w.log.Info("job worker stopping, waiting for running jobs...")
+			w.pool.wait()
 			return ctx.Err()
 
+		case <-w.wake:
+			w.log.Debug("job worker notified, fetching jobs")
+			if err := w.pollAndExecuteJobs(ctx); err != nil {
+				w.log.Error("failed to fetch notified jobs", "error", err)
+			}
+
 		case <-ticker.C:
+			if w.pushActive != nil && w.pushActive() && time.Since(w.lastPoll) < fallbackPollInterval {
+				continue
+			}
+
 			if err := w.pollAndExecuteJobs(ctx); err != nil {
 				w.log.Error("failed to poll jobs", "error", err)
 			}
@@ -54,55 +134,141 @@ func (w *JobWorker) Start(ctx context.Context) error {
 	}
 }
 
+// Cancel stops the job with the given ID if it is running on this agent.
+func (w *JobWorker) Cancel(jobID int64) bool {
+	w.runningMu.Lock()
+	cancel, ok := w.running[jobID]
+	w.runningMu.Unlock()
+
+	if !ok {
+		return false
+	}
+
+	w.log.Info("cancelling job", "job_id", jobID)
+	cancel(context.Canceled)
+
+	return true
+}
+
 func (w *JobWorker) pollAndExecuteJobs(ctx context.Context) error {
-	jobs, err := w.client.GetPendingJobs(ctx)
+	w.lastPoll = time.Now()
+
+	// Only claim what can start right away, a leased job waiting for a slot
+	// blocks other agents from taking it.
+	free, lightFree := w.pool.free()
+	if free == 0 && lightFree == 0 {
+		return nil
+	}
+
+	jobs, err := w.client.ClaimJobs(ctx, min(free, domain.JobClaimLimit), min(lightFree, domain.JobClaimLimit))
 	if err != nil {
-		return fmt.Errorf("failed to fetch jobs: %w", err)
+		return fmt.Errorf("failed to claim jobs: %w", err)
 	}
 
 	if len(jobs) == 0 {
 		return nil
 	}
 
-	w.log.Debug("received jobs", "count", len(jobs))
+	w.log.Debug("claimed jobs", "count", len(jobs))
 
-	for _, job := range jobs {
-		if err := w.processJob(ctx, job); err != nil {
-			w.log.Error("failed to process job", "job_id", job.ID, "error", err)
-		}
+	// Every claimed job is leased to this agent from now on, so keep all of
+	// the leases alive while the batch is worked through.
+	jobCtxs := make([]context.Context, len(jobs))
+	for i, job := range jobs {
+		jobCtx, cancel := context.WithCancelCause(context.Background())
+		w.trackJob(job.ID, cancel)
+		go w.keepLease(jobCtx, job.ID, cancel)
+		jobCtxs[i] = jobCtx
+	}
+
+	// Reporting results must still work while the worker shuts down.
+	reportCtx := context.WithoutCancel(ctx)
+
+	for i, job := range jobs {
+		jobCtx := jobCtxs[i]
+		w.pool.submit(job, func() {
+			defer w.untrackJob(job.ID)
+
+			if err := w.processJob(reportCtx, jobCtx, job); err != nil {
+				w.log.Error("failed to process job", "job_id", job.ID, "error", err)
+			}
+		})
 	}
 
 	return nil
 }
 
-func (w *JobWorker) processJob(ctx context.Context, job domain.Job) error {
+// keepLease sends heartbeats for a claimed job until jobCtx is done. When the
+// server reports the lease as lost the job is aborted.
+func (w *JobWorker) keepLease(jobCtx context.Context, jobID int64, cancel context.CancelCauseFunc) {
+	ticker := time.NewTicker(domain.JobHeartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-jobCtx.Done():
+			return
+
+		case <-ticker.C:
+			err := w.client.HeartbeatJob(jobCtx, jobID)
+			if errors.Is(err, ErrJobLeaseLost) {
+				w.log.Warn("job lease lost, aborting job", "job_id", jobID)
+				cancel(ErrJobLeaseLost)
+				return
+			}
+			if err != nil && jobCtx.Err() == nil {
+				w.log.Error("failed to send job heartbeat", "job_id", jobID, "error", err)
+			}
+		}
+	}
+}
+
+func (w *JobWorker) processJob(ctx context.Context, jobCtx context.Context, job domain.Job) error {
 	w.log.Debug("processing job", "job_id", job.ID)
 
-	if err := w.client.StartJob(ctx, job.ID); err != nil {
-		w.log.Error("failed to mark job as running", "job_id", job.ID, "error", err)
-		return err
+	var execErr error
+	if jobCtx.Err() != nil {
+		execErr = context.Cause(jobCtx)
+	} else {
+		execErr = w.execute(jobCtx, job)
 	}
 
-	execErr := w.execute(job)
+	// The server already took the job back, whatever it reports now would
+	// overwrite the reaper's decision.
+	if errors.Is(execErr, ErrJobLeaseLost) {
+		w.log.Warn("job abandoned after losing its lease", "job_id", job.ID)
+		return nil
+	}
 
 	status := domain.JobSuccess
-	if execErr != nil {
+	if errors.Is(execErr, context.Canceled) {
+		status = domain.JobCancelled
+		w.log.Info("job cancelled", "job_id", job.ID)
+	} else if errors.Is(execErr, domain.ErrJobTimeout) {
+		status = domain.JobExpired
+		w.log.Warn("job timed out", "job_id", job.ID, "timeout", job.Timeout())
+	} else if execErr != nil {
 		status = domain.JobFailed
 		w.log.Error("job execution failed", "job_id", job.ID, "error", execErr)
 	} else {
 		w.log.Debug("job executed successfully", "job_id", job.ID)
 	}
 
-	if err := w.client.FinishJob(ctx, job.ID, status); err != nil {
-		w.log.Error("failed to mark job as finished", "job_id", job.ID, "error", err)
+	if err := w.outbox.Append(outbox.KindJobFinished, jobFinishedEntry{
+		JobID:  job.ID,
+		Status: status,
+	}); err != nil {
+		w.log.Error("failed to queue job result", "job_id", job.ID, "error", err)
 		return err
 	}
 
 	return execErr
 }
 
-func (w *JobWorker) execute(job domain.Job) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+func (w *JobWorker) execute(jobCtx context.Context, job domain.Job) error {
+	// Shipping logs must outlive the job deadline, otherwise the lines that
+	// explain a timeout would never reach the server.
+	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
 	logCh := make(chan domain.EventLogEmitted, 200)
@@ -113,36 +279,18 @@ func (w *JobWorker) execute(job domain.Job) error {
 
 	go func() {
 		defer wg.Done()
-		for evt := range logCh {
-			err := w.client.SendLog(ctx, &domain.LogEmitRequest{
-				Timestamp:     evt.Timestamp,
-				Level:         evt.Level,
-				Source:        evt.Source,
-				Action:        evt.Action,
-				TraceID:       job.TraceID,
-				JobID:         &job.ID,
-				ServerID:      &job.ServerID,
-				ApplicationID: job.ApplicationID,
-				DeploymentID:  job.DeploymentID,
-				Message:       evt.Message,
-				Context:       evt.Context,
-			})
-			if err != nil {
-				w.log.Error("failed to send log", "error", err)
-			}
-		}
+		w.shipLogs(job, logCh)
 	}()
 
 	go func() {
 		defer wg.Done()
 		for evt := range commitCh {
-			if err := w.client.SendCommitInfo(
-				ctx,
-				evt.DeploymentID,
-				evt.Hash,
-				evt.Message,
-			); err != nil {
-				w.log.Error("failed to send commit info", "error", err)
+			if err := w.outbox.Append(outbox.KindCommitInfo, commitInfoEntry{
+				DeploymentID: evt.DeploymentID,
+				Hash:         evt.Hash,
+				Message:      evt.Message,
+			}); err != nil {
+				w.log.Error("failed to queue commit info", "error", err)
 			}
 		}
 	}()
@@ -171,6 +319,17 @@ func (w *JobWorker) execute(job domain.Job) error {
 		}
 	})
 
+	bus.Subscribe("git_heads", func(event any) {
+		heads, ok := event.([]domain.GitRemoteHead)
+		if !ok {
+			return
+		}
+
+		if err := w.client.SendGitRemoteHeads(ctx, heads); err != nil {
+			w.log.Error("failed to send git remote heads", "error", err)
+		}
+	})
+
 	bus.Subscribe("log", func(event any) {
 		evt, ok := event.(domain.EventLogEmitted)
 		if !ok {
@@ -195,6 +354,8 @@ func (w *JobWorker) execute(job domain.Job) error {
 			bus.Publish("metrics", event)
 		case []domain.ApplicationHealth:
 			bus.Publish("app_healths", event)
+		case []domain.GitRemoteHead:
+			bus.Publish("git_heads", event)
 		case domain.EventLogEmitted:
 			bus.Publish("log", event)
 		case domain.EventCommitInfoEmitted:
@@ -202,7 +363,13 @@ func (w *JobWorker) execute(job domain.Job) error {
 		}
 	}
 
-	err := w.executor.Execute(context.Background(), &job, onEmit)
+	runCtx, cancelRun := context.WithTimeoutCause(jobCtx, job.Timeout(), domain.ErrJobTimeout)
+	defer cancelRun()
+
+	err := w.executor.Execute(runCtx, &job, onEmit)
+	if err != nil && runCtx.Err() != nil {
+		err = fmt.Errorf("%w: %w", context.Cause(runCtx), err)
+	}
 
 	close(logCh)
 	close(commitCh)
@@ -211,3 +378,114 @@ func (w *JobWorker) execute(job domain.Job) error {
 
 	return err
 }
+
+func (w *JobWorker) shipLogs(job domain.Job, logCh <-chan domain.EventLogEmitted) {
+	ticker := time.NewTicker(logFlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]domain.LogEmitRequest, 0, logBatchSize)
+
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+
+		if err := w.outbox.Append(outbox.KindLogBatch, batch); err != nil {
+			w.log.Error("failed to queue logs", "job_id", job.ID, "count", len(batch), "error", err)
+		}
+
+		batch = batch[:0]
+	}
+
+	for {
+		select {
+		case evt, ok := <-logCh:
+			if !ok {
+				flush()
+				return
+			}
+
+			batch = append(batch, domain.LogEmitRequest{
+				Timestamp:     evt.Timestamp,
+				Level:         evt.Level,
+				Source:        evt.Source,
+				Action:        evt.Action,
+				TraceID:       job.TraceID,
+				JobID:         &job.ID,
+				ServerID:      &job.ServerID,
+				ApplicationID: job.ApplicationID,
+				DeploymentID:  job.DeploymentID,
+				Message:       evt.Message,
+				Context:       evt.Context,
+			})
+
+			if len(batch) >= logBatchSize {
+				flush()
+			}
+
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+// deliver sends an outbox entry to the server. Requests the server rejects
+// outright are dropped, anything else is retried by the outbox.
+func (w *JobWorker) deliver(ctx context.Context, entry outbox.Entry) error {
+	var err error
+
+	switch entry.Kind {
+	case outbox.KindLog:
+		var req domain.LogEmitRequest
+		if err := json.Unmarshal(entry.Payload, &req); err != nil {
+			return fmt.Errorf("%w: %w", outbox.ErrDrop, err)
+		}
+		err = w.client.SendLog(ctx, entry.ID, &req)
+
+	case outbox.KindLogBatch:
+		var reqs []domain.LogEmitRequest
+		if err := json.Unmarshal(entry.Payload, &reqs); err != nil {
+			return fmt.Errorf("%w: %w", outbox.ErrDrop, err)
+		}
+		err = w.client.SendLogs(ctx, entry.ID, reqs)
+
+	case outbox.KindJobFinished:
+		var e jobFinishedEntry
+		if err := json.Unmarshal(entry.Payload, &e); err != nil {
+			return fmt.Errorf("%w: %w", outbox.ErrDrop, err)
+		}
+		err = w.client.FinishJob(ctx, entry.ID, e.JobID, e.Status)
+
+	case outbox.KindCommitInfo:
+		var e commitInfoEntry
+		if err := json.Unmarshal(entry.Payload, &e); err != nil {
+			return fmt.Errorf("%w: %w", outbox.ErrDrop, err)
+		}
+		err = w.client.SendCommitInfo(ctx, entry.ID, e.DeploymentID, e.Hash, e.Message)
+
+	default:
+		return fmt.Errorf("%w: unknown kind %q", outbox.ErrDrop, entry.Kind)
+	}
+
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) && statusErr.Permanent() {
+		return fmt.Errorf("%w: %w", outbox.ErrDrop, err)
+	}
+
+	return err
+}
+
+func (w *JobWorker) trackJob(jobID int64, cancel context.CancelCauseFunc) {
+	w.runningMu.Lock()
+	w.running[jobID] = cancel
+	w.runningMu.Unlock()
+}
+
+func (w *JobWorker) untrackJob(jobID int64) {
+	w.runningMu.Lock()
+	if cancel, ok := w.running[jobID]; ok {
+		cancel(nil)
+		delete(w.running, jobID)
+	}
+	w.runningMu.Unlock()
+}
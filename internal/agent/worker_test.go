@@ -0,0 +1,108 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"horizonx/internal/agent/outbox"
+	"horizonx/internal/config"
+	"horizonx/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+func TestStatusErrorPermanent(t *testing.T) {
+	tests := []struct {
+		status int
+		want   bool
+	}{
+		{http.StatusBadRequest, true},
+		{http.StatusUnauthorized, true},
+		{http.StatusNotFound, true},
+		{http.StatusConflict, true},
+		{http.StatusUnprocessableEntity, true},
+		{http.StatusRequestTimeout, false},
+		{http.StatusTooManyRequests, false},
+		{http.StatusInternalServerError, false},
+		{http.StatusBadGateway, false},
+		{http.StatusServiceUnavailable, false},
+	}
+
+	for _, tt := range tests {
+		err := &StatusError{Op: "test", StatusCode: tt.status}
+		if got := err.Permanent(); got != tt.want {
+			t.Errorf("Permanent() for %d = %v, want %v", tt.status, got, tt.want)
+		}
+	}
+}
+
+func TestDeliverDropsPermanentRejections(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		wantErr  bool
+		wantDrop bool
+	}{
+		{"accepted", http.StatusOK, false, false},
+		{"bad request", http.StatusBadRequest, true, true},
+		{"not found", http.StatusNotFound, true, true},
+		{"conflict", http.StatusConflict, true, true},
+		{"rate limited", http.StatusTooManyRequests, true, false},
+		{"server error", http.StatusInternalServerError, true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+			}))
+			defer srv.Close()
+
+			w := &JobWorker{client: NewClient(&config.Config{
+				AgentTargetAPIURL: srv.URL,
+				AgentServerID:     uuid.New(),
+			})}
+
+			payload, err := json.Marshal(jobFinishedEntry{JobID: 1, Status: domain.JobSuccess})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = w.deliver(context.Background(), outbox.Entry{
+				ID:      uuid.New(),
+				Kind:    outbox.KindJobFinished,
+				Payload: payload,
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("deliver() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := errors.Is(err, outbox.ErrDrop); got != tt.wantDrop {
+				t.Errorf("deliver() dropped = %v, want %v (err: %v)", got, tt.wantDrop, err)
+			}
+		})
+	}
+}
+
+func TestDeliverDropsMalformedEntries(t *testing.T) {
+	w := &JobWorker{}
+
+	tests := []struct {
+		name  string
+		entry outbox.Entry
+	}{
+		{"unknown kind", outbox.Entry{Kind: "bogus", Payload: json.RawMessage(`{}`)}},
+		{"bad payload", outbox.Entry{Kind: outbox.KindJobFinished, Payload: json.RawMessage(`"x"`)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := w.deliver(context.Background(), tt.entry); !errors.Is(err, outbox.ErrDrop) {
+				t.Errorf("deliver() = %v, want ErrDrop", err)
+			}
+		})
+	}
+}
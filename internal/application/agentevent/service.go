@@ -0,0 +1,31 @@
+// Package agentevent
+package agentevent
+
+import (
+	"context"
+	"time"
+
+	"horizonx/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+type Service struct {
+	repo domain.AgentEventRepository
+}
+
+func NewService(repo domain.AgentEventRepository) domain.AgentEventService {
+	return &Service{repo: repo}
+}
+
+func (s *Service) Processed(ctx context.Context, eventID uuid.UUID) (bool, error) {
+	return s.repo.Exists(ctx, eventID)
+}
+
+func (s *Service) Record(ctx context.Context, serverID uuid.UUID, eventID uuid.UUID) error {
+	return s.repo.Record(ctx, serverID, eventID)
+}
+
+func (s *Service) Prune(ctx context.Context) (int64, error) {
+	return s.repo.DeleteBefore(ctx, time.Now().UTC().Add(-domain.AgentEventRetention))
+}
@@ -63,18 +63,26 @@ func (l *Listener) handleJobFinished(event any) {
 		return
 	}
 
-	if evt.ApplicationID == nil {
+	// A one-off command says nothing about the state of the application
+	if evt.ApplicationID == nil || evt.WillRetry || evt.Type == domain.JobTypeAppCommand {
 		return
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	if evt.Status == domain.JobFailed {
+	if evt.Status == domain.JobFailed || evt.Status == domain.JobExpired {
 		_ = l.svc.UpdateStatus(ctx, *evt.ApplicationID, domain.AppStatusFailed)
 		return
 	}
 
+	// A cancelled job may leave the app half way through a transition,
+	// the next health check will report its real state.
+	if evt.Status == domain.JobCancelled {
+		_ = l.updateStatus(ctx, *evt.ApplicationID, domain.AppStatusUnknown)
+		return
+	}
+
 	switch evt.Type {
 	case domain.JobTypeAppDeploy:
 		_ = l.updateStatus(ctx, *evt.ApplicationID, domain.AppStatusRunning)
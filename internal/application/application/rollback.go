@@ -0,0 +1,130 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"horizonx/internal/domain"
+	"horizonx/internal/event"
+	"horizonx/internal/logger"
+)
+
+// RollbackListener applies the auto rollback policy of applications.
+type RollbackListener struct {
+	svc           domain.ApplicationService
+	deploymentSvc domain.DeploymentService
+	log           logger.Logger
+
+	// mu serializes the decisions so concurrent health reports do not
+	// queue the same rollback twice.
+	mu sync.Mutex
+}
+
+func NewRollbackListener(svc domain.ApplicationService, deploymentSvc domain.DeploymentService, log logger.Logger) *RollbackListener {
+	return &RollbackListener{
+		svc:           svc,
+		deploymentSvc: deploymentSvc,
+		log:           log,
+	}
+}
+
+func (l *RollbackListener) Register(bus *event.Bus) {
+	bus.Subscribe("deployment_finished", l.handleDeploymentFinished)
+	bus.Subscribe("application_health_reported", l.handleHealthReported)
+}
+
+func (l *RollbackListener) handleDeploymentFinished(event any) {
+	evt, ok := event.(domain.EventDeploymentFinished)
+	if !ok {
+		l.log.Warn("invalid event payload for deployment_finished", "event", event)
+		return
+	}
+
+	if evt.Status != domain.DeploymentFailed {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	app, err := l.svc.GetByID(ctx, evt.ApplicationID)
+	if err != nil || !app.AutoRollback {
+		return
+	}
+
+	l.rollback(ctx, evt.DeploymentID, domain.RollbackDeployFailed)
+}
+
+// handleHealthReported rolls back a deployment whose application failed
+// within the rollback window after it finished.
+func (l *RollbackListener) handleHealthReported(event any) {
+	evt, ok := event.(domain.EventApplicationHealthReported)
+	if !ok {
+		l.log.Warn("invalid event payload for application_health_reported", "event", event)
+		return
+	}
+
+	if evt.Status != domain.AppStatusFailed {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	app, err := l.svc.GetByID(ctx, evt.ApplicationID)
+	if err != nil || !app.AutoRollback || app.RollbackWindowMinutes <= 0 {
+		return
+	}
+
+	latest, err := l.deploymentSvc.List(ctx, domain.DeploymentListOptions{
+		ListOptions:   domain.ListOptions{Limit: 1},
+		ApplicationID: &app.ID,
+	})
+	if err != nil {
+		l.log.Error("failed to get latest deployment", "app_id", app.ID, "error", err)
+		return
+	}
+	if len(latest.Data) == 0 {
+		return
+	}
+
+	// Once the rollback is queued it is the latest deployment, so later
+	// reports stop here.
+	d := latest.Data[0]
+	if d.Status != domain.DeploymentSuccess || d.FinishedAt == nil || d.RollbackOf != nil {
+		return
+	}
+
+	window := time.Duration(app.RollbackWindowMinutes) * time.Minute
+	if time.Since(*d.FinishedAt) > window {
+		return
+	}
+
+	l.rollback(ctx, d.ID, domain.RollbackUnhealthy)
+}
+
+func (l *RollbackListener) rollback(ctx context.Context, deploymentID int64, reason domain.RollbackReason) {
+	d, err := l.svc.Rollback(ctx, deploymentID, reason)
+	if err != nil {
+		if errors.Is(err, domain.ErrNoRollbackTarget) || errors.Is(err, domain.ErrRollbackOfRollback) {
+			l.log.Warn("auto rollback skipped", "deployment_id", deploymentID, "reason", err.Error())
+			return
+		}
+		l.log.Error("failed to roll back deployment", "deployment_id", deploymentID, "error", err)
+		return
+	}
+
+	l.log.Info("deployment rolled back",
+		"deployment_id", deploymentID,
+		"rollback_id", d.ID,
+		"reason", reason,
+	)
+}
@@ -4,7 +4,9 @@ package application
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"time"
 
 	"horizonx/internal/domain"
 	"horizonx/internal/event"
@@ -17,6 +19,9 @@ type Service struct {
 	serverSvc     domain.ServerService
 	jobSvc        domain.JobService
 	deploymentSvc domain.DeploymentService
+	gitCredSvc    domain.GitCredentialService
+	registrySvc   domain.RegistryCredentialService
+	logSvc        domain.LogService
 	bus           *event.Bus
 }
 
@@ -25,6 +30,9 @@ func NewService(
 	serverSvc domain.ServerService,
 	jobSvc domain.JobService,
 	deploymentSvc domain.DeploymentService,
+	gitCredSvc domain.GitCredentialService,
+	registrySvc domain.RegistryCredentialService,
+	logSvc domain.LogService,
 	bus *event.Bus,
 ) domain.ApplicationService {
 	return &Service{
@@ -32,6 +40,9 @@ func NewService(
 		serverSvc:     serverSvc,
 		jobSvc:        jobSvc,
 		deploymentSvc: deploymentSvc,
+		gitCredSvc:    gitCredSvc,
+		registrySvc:   registrySvc,
+		logSvc:        logSvc,
 		bus:           bus,
 	}
 }
@@ -77,12 +88,51 @@ func (s *Service) Create(ctx context.Context, req domain.ApplicationCreateReques
 		return nil, fmt.Errorf("server not found: %w", err)
 	}
 
+	if err := s.checkGitCredential(ctx, req.GitCredentialID); err != nil {
+		return nil, err
+	}
+	if err := s.checkRegistryCredential(ctx, req.RegistryCredentialID); err != nil {
+		return nil, err
+	}
+
 	app := &domain.Application{
 		ServerID: req.ServerID,
 		Name:     req.Name,
+		Source:   req.Source,
 		RepoURL:  req.RepoURL,
 		Branch:   req.Branch,
 		Status:   domain.AppStatusStopped,
+
+		GitCredentialID: req.GitCredentialID,
+
+		JobTimeouts:    req.JobTimeouts,
+		DeployStrategy: req.DeployStrategy,
+
+		AutoRollback:          req.AutoRollback,
+		RollbackWindowMinutes: req.RollbackWindowMinutes,
+
+		GitPollIntervalSeconds: req.GitPollIntervalSeconds,
+		GitPollDebounceSeconds: req.GitPollDebounceSeconds,
+
+		ComposePath:        req.ComposePath,
+		ComposeOverrides:   req.ComposeOverrides,
+		ComposeProfiles:    req.ComposeProfiles,
+		ComposeProjectName: req.ComposeProjectName,
+
+		ComposeFile:          req.ComposeFile,
+		RegistryCredentialID: req.RegistryCredentialID,
+	}
+	if app.Source == "" {
+		app.Source = domain.AppSourceGit
+	}
+	if app.DeployStrategy == "" {
+		app.DeployStrategy = domain.DeployRecreate
+	}
+	if err := checkSource(app); err != nil {
+		return nil, err
+	}
+	if err := s.checkComposeProject(ctx, app.ServerID, 0, app.ComposeProjectName); err != nil {
+		return nil, err
 	}
 	created, err := s.repo.Create(ctx, app)
 	if err != nil {
@@ -112,15 +162,98 @@ func (s *Service) Create(ctx context.Context, req domain.ApplicationCreateReques
 }
 
 func (s *Service) Update(ctx context.Context, req domain.ApplicationUpdateRequest, appID int64) error {
-	_, err := s.repo.GetByID(ctx, appID)
+	existing, err := s.repo.GetByID(ctx, appID)
 	if err != nil {
 		return err
 	}
 
 	app := &domain.Application{
 		Name:    req.Name,
-		RepoURL: req.RepoURL,
-		Branch:  req.Branch,
+		Source:  existing.Source,
+		RepoURL: existing.RepoURL,
+		Branch:  existing.Branch,
+
+		GitCredentialID: existing.GitCredentialID,
+
+		JobTimeouts:    existing.JobTimeouts,
+		DeployStrategy: req.DeployStrategy,
+
+		AutoRollback:          existing.AutoRollback,
+		RollbackWindowMinutes: existing.RollbackWindowMinutes,
+
+		GitPollIntervalSeconds: existing.GitPollIntervalSeconds,
+		GitPollDebounceSeconds: existing.GitPollDebounceSeconds,
+
+		ComposePath:        existing.ComposePath,
+		ComposeOverrides:   existing.ComposeOverrides,
+		ComposeProfiles:    existing.ComposeProfiles,
+		ComposeProjectName: existing.ComposeProjectName,
+
+		ComposeFile:          existing.ComposeFile,
+		RegistryCredentialID: existing.RegistryCredentialID,
+	}
+	if req.RepoURL != nil {
+		app.RepoURL = *req.RepoURL
+	}
+	if req.Branch != nil {
+		app.Branch = *req.Branch
+	}
+	if req.ComposeFile != nil {
+		app.ComposeFile = *req.ComposeFile
+	}
+	if req.GitCredentialID != nil {
+		app.GitCredentialID = nil
+		if *req.GitCredentialID != 0 {
+			if err := s.checkGitCredential(ctx, req.GitCredentialID); err != nil {
+				return err
+			}
+			app.GitCredentialID = req.GitCredentialID
+		}
+	}
+	if req.RegistryCredentialID != nil {
+		app.RegistryCredentialID = nil
+		if *req.RegistryCredentialID != 0 {
+			if err := s.checkRegistryCredential(ctx, req.RegistryCredentialID); err != nil {
+				return err
+			}
+			app.RegistryCredentialID = req.RegistryCredentialID
+		}
+	}
+	if req.JobTimeouts != nil {
+		app.JobTimeouts = *req.JobTimeouts
+	}
+	if app.DeployStrategy == "" {
+		app.DeployStrategy = existing.DeployStrategy
+	}
+	if req.AutoRollback != nil {
+		app.AutoRollback = *req.AutoRollback
+	}
+	if req.RollbackWindowMinutes != nil {
+		app.RollbackWindowMinutes = *req.RollbackWindowMinutes
+	}
+	if req.GitPollIntervalSeconds != nil {
+		app.GitPollIntervalSeconds = *req.GitPollIntervalSeconds
+	}
+	if req.GitPollDebounceSeconds != nil {
+		app.GitPollDebounceSeconds = *req.GitPollDebounceSeconds
+	}
+	if req.ComposePath != nil {
+		app.ComposePath = *req.ComposePath
+	}
+	if req.ComposeOverrides != nil {
+		app.ComposeOverrides = *req.ComposeOverrides
+	}
+	if req.ComposeProfiles != nil {
+		app.ComposeProfiles = *req.ComposeProfiles
+	}
+	if req.ComposeProjectName != nil {
+		app.ComposeProjectName = *req.ComposeProjectName
+	}
+	if err := checkSource(app); err != nil {
+		return err
+	}
+	if err := s.checkComposeProject(ctx, existing.ServerID, appID, app.ComposeProjectName); err != nil {
+		return err
 	}
 	if err := s.repo.Update(ctx, app, appID); err != nil {
 		return err
@@ -134,6 +267,10 @@ func (s *Service) Update(ctx context.Context, req domain.ApplicationUpdateReques
 			IsPreview: env.IsPreview,
 		})
 	}
+	envVars, err = s.keepMaskedValues(ctx, appID, envVars)
+	if err != nil {
+		return err
+	}
 	if err := s.repo.SyncEnvVars(ctx, appID, envVars); err != nil {
 		return err
 	}
@@ -141,6 +278,70 @@ func (s *Service) Update(ctx context.Context, req domain.ApplicationUpdateReques
 	return nil
 }
 
+func (s *Service) checkGitCredential(ctx context.Context, credentialID *int64) error {
+	if credentialID == nil {
+		return nil
+	}
+
+	_, err := s.gitCredSvc.GetByID(ctx, *credentialID)
+	return err
+}
+
+func (s *Service) checkRegistryCredential(ctx context.Context, credentialID *int64) error {
+	if credentialID == nil {
+		return nil
+	}
+
+	_, err := s.registrySvc.GetByID(ctx, *credentialID)
+	return err
+}
+
+// checkSource rejects settings that do not apply to the source of app.
+func checkSource(app *domain.Application) error {
+	if app.Source == domain.AppSourceImage {
+		switch {
+		case app.ComposeFile == "":
+			return fmt.Errorf("%w: an image application needs a compose file", domain.ErrInvalidApplicationSource)
+		case app.RepoURL != "" || app.GitCredentialID != nil || app.GitPollIntervalSeconds > 0:
+			return fmt.Errorf("%w: an image application has no git repository", domain.ErrInvalidApplicationSource)
+		case app.ComposePath != "" || len(app.ComposeOverrides) > 0:
+			return fmt.Errorf("%w: an image application has a single compose file", domain.ErrInvalidApplicationSource)
+		}
+		return nil
+	}
+
+	switch {
+	case app.RepoURL == "" || app.Branch == "":
+		return fmt.Errorf("%w: a git application needs a repository and a branch", domain.ErrInvalidApplicationSource)
+	case app.ComposeFile != "" || app.RegistryCredentialID != nil:
+		return fmt.Errorf("%w: compose file and registry credential are for image applications", domain.ErrInvalidApplicationSource)
+	}
+
+	return nil
+}
+
+// checkComposeProject makes sure no other application of the server runs
+// under the same custom compose project, they would replace each other's
+// containers.
+func (s *Service) checkComposeProject(ctx context.Context, serverID uuid.UUID, appID int64, project string) error {
+	if project == "" {
+		return nil
+	}
+
+	apps, err := s.List(ctx, domain.ApplicationListOptions{ServerID: &serverID})
+	if err != nil {
+		return err
+	}
+
+	for _, app := range apps.Data {
+		if app.ID != appID && app.ComposeProjectName == project {
+			return domain.ErrComposeProjectTaken
+		}
+	}
+
+	return nil
+}
+
 func (s *Service) Delete(ctx context.Context, appID int64) error {
 	app, err := s.repo.GetByID(ctx, appID)
 	if err != nil {
@@ -184,27 +385,139 @@ func (s *Service) UpdateLastDeployment(ctx context.Context, appID int64) error {
 	return s.repo.UpdateLastDeployment(ctx, appID)
 }
 
-func (s *Service) Deploy(ctx context.Context, appID int64, deployedBy int64) (*domain.Deployment, error) {
+func (s *Service) Deploy(ctx context.Context, appID int64, deployedBy *int64, req domain.ApplicationDeployRequest) (*domain.Deployment, error) {
 	app, err := s.repo.GetByID(ctx, appID)
 	if err != nil {
 		return nil, err
 	}
 
+	create := domain.DeploymentCreateRequest{
+		Branch:     app.Branch,
+		DeployedBy: deployedBy,
+	}
+
+	image := app.Source == domain.AppSourceImage
+	if (image && (req.Ref != "" || req.Push != nil)) || (!image && req.Image != "") {
+		return nil, fmt.Errorf("%w: image applications deploy an image tag or digest, git applications a ref", domain.ErrInvalidApplicationSource)
+	}
+
+	switch {
+	case req.Push != nil:
+		// The pushed commit is pinned, a later push to the branch must not
+		// be picked up by this deployment.
+		push := req.Push
+		create.Branch = push.Branch
+		create.Ref = &push.CommitHash
+		create.CommitHash = &push.CommitHash
+		if push.CommitMessage != "" {
+			create.CommitMessage = &push.CommitMessage
+		}
+		if push.Pusher != "" {
+			create.PushedBy = &push.Pusher
+		}
+
+	case req.DeploymentID != nil:
+		past, err := s.deploymentSvc.GetByID(ctx, *req.DeploymentID)
+		if err != nil {
+			return nil, err
+		}
+		if past.ApplicationID != appID {
+			return nil, domain.ErrDeploymentNotFound
+		}
+
+		switch {
+		case image && past.Image == nil:
+			return nil, domain.ErrDeploymentNoImage
+		case image:
+			create.Image = past.Image
+		case past.CommitHash == nil:
+			return nil, domain.ErrDeploymentNoCommit
+		default:
+			create.Branch = past.Branch
+			create.Ref = past.CommitHash
+		}
+
+	case req.Ref != "":
+		create.Ref = &req.Ref
+
+	case req.Image != "":
+		create.Image = &req.Image
+	}
+
+	return s.deploy(ctx, app, create)
+}
+
+// Rollback redeploys the commit of the last successful deployment before
+// deploymentID. The new deployment is linked to the one it replaces.
+func (s *Service) Rollback(ctx context.Context, deploymentID int64, reason domain.RollbackReason) (*domain.Deployment, error) {
+	failed, err := s.deploymentSvc.GetByID(ctx, deploymentID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Rolling back a rollback could bounce between two broken commits.
+	if failed.RollbackOf != nil {
+		return nil, domain.ErrRollbackOfRollback
+	}
+
+	app, err := s.repo.GetByID(ctx, failed.ApplicationID)
+	if err != nil {
+		return nil, err
+	}
+
+	target, err := s.deploymentSvc.GetLastSuccessful(ctx, app.ID, failed.ID)
+	if err != nil {
+		if errors.Is(err, domain.ErrDeploymentNotFound) {
+			return nil, domain.ErrNoRollbackTarget
+		}
+		return nil, err
+	}
+
+	deployment, err := s.deploy(ctx, app, domain.DeploymentCreateRequest{
+		Branch:     target.Branch,
+		Ref:        target.CommitHash,
+		Image:      target.Image,
+		DeployedBy: failed.DeployedBy,
+		RollbackOf: &failed.ID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if s.bus != nil {
+		evt := domain.EventDeploymentRolledBack{
+			DeploymentID:  failed.ID,
+			ApplicationID: app.ID,
+			RollbackID:    deployment.ID,
+			Reason:        reason,
+		}
+		if target.CommitHash != nil {
+			evt.CommitHash = *target.CommitHash
+		}
+		if target.Image != nil {
+			evt.Image = *target.Image
+		}
+		s.bus.Publish("deployment_rolled_back", evt)
+	}
+
+	return deployment, nil
+}
+
+func (s *Service) deploy(ctx context.Context, app *domain.Application, req domain.DeploymentCreateRequest) (*domain.Deployment, error) {
+	appID := app.ID
+
 	envVars, err := s.repo.ListEnvVars(ctx, appID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch env vars: %w", err)
 	}
 
-	envMap := make(map[string]string)
+	envKeys := make([]string, 0, len(envVars))
 	for _, env := range envVars {
-		envMap[env.Key] = env.Value
+		envKeys = append(envKeys, env.Key)
 	}
 
-	deployment, err := s.deploymentSvc.Create(ctx, domain.DeploymentCreateRequest{
-		ApplicationID: appID,
-		Branch:        app.Branch,
-		DeployedBy:    &deployedBy,
-	})
+	req.ApplicationID = appID
+	deployment, err := s.deploymentSvc.Create(ctx, req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create deployment record: %w", err)
 	}
@@ -213,8 +526,21 @@ func (s *Service) Deploy(ctx context.Context, appID int64, deployedBy int64) (*d
 		ApplicationID: appID,
 		DeploymentID:  deployment.ID,
 		RepoURL:       app.RepoURL,
-		Branch:        app.Branch,
-		EnvVars:       envMap,
+		Branch:        req.Branch,
+		Strategy:      app.DeployStrategy,
+		Compose:       app.ComposeConfig(),
+		Source:        app.Source,
+		ComposeFile:   app.ComposeFile,
+
+		GitCredentialID:      app.GitCredentialID,
+		RegistryCredentialID: app.RegistryCredentialID,
+		EnvKeys:              envKeys,
+	}
+	if req.Ref != nil {
+		payload.Ref = *req.Ref
+	}
+	if req.Image != nil {
+		payload.Image = *req.Image
 	}
 
 	payloadBytes, err := json.Marshal(payload)
@@ -223,22 +549,58 @@ func (s *Service) Deploy(ctx context.Context, appID int64, deployedBy int64) (*d
 	}
 
 	job := &domain.Job{
-		TraceID:       uuid.New(),
+		TraceID:       domain.TraceIDFromContext(ctx),
 		ServerID:      app.ServerID,
 		ApplicationID: &appID,
 		DeploymentID:  &deployment.ID,
 		Type:          domain.JobTypeAppDeploy,
 		Payload:       payloadBytes,
+
+		TimeoutSeconds: int(app.JobTimeout(domain.JobTypeAppDeploy).Seconds()),
 	}
 
-	if _, err := s.jobSvc.Create(ctx, job); err != nil {
+	job, superseded, err := s.jobSvc.CreateDeploy(ctx, job)
+	if err != nil {
 		s.repo.UpdateStatus(ctx, appID, domain.AppStatusFailed)
 		return nil, fmt.Errorf("failed to create deployment job: %w", err)
 	}
 
+	s.publishSuperseded(job, superseded)
+
 	return deployment, nil
 }
 
+// publishSuperseded reports the deployments dropped from the queue by job,
+// only the newest deploy request is worth running.
+func (s *Service) publishSuperseded(job *domain.Job, superseded []*domain.Job) {
+	if s.bus == nil {
+		return
+	}
+
+	for _, j := range superseded {
+		if j.DeploymentID == nil || *j.DeploymentID == *job.DeploymentID {
+			continue
+		}
+		s.bus.Publish("deployment_status_changed", domain.EventDeploymentStatusChanged{
+			DeploymentID:  *j.DeploymentID,
+			ApplicationID: *j.ApplicationID,
+			Status:        domain.DeploymentSuperseded,
+		})
+	}
+}
+
+// createLifecycleJob queues a start, stop or restart job unless a deploy is
+// queued or running, it would race it on the same containers. The status set
+// for the transition is undone when the job is refused.
+func (s *Service) createLifecycleJob(ctx context.Context, app *domain.Application, job *domain.Job) error {
+	if _, err := s.jobSvc.CreateUnlessDeploying(ctx, job); err != nil {
+		_ = s.repo.UpdateStatus(ctx, app.ID, app.Status)
+		return err
+	}
+
+	return nil
+}
+
 func (s *Service) Start(ctx context.Context, appID int64) error {
 	app, err := s.repo.GetByID(ctx, appID)
 	if err != nil {
@@ -263,15 +625,16 @@ func (s *Service) Start(ctx context.Context, appID int64) error {
 	}
 
 	job := &domain.Job{
-		TraceID:       uuid.New(),
+		TraceID:       domain.TraceIDFromContext(ctx),
 		ServerID:      app.ServerID,
 		ApplicationID: &appID,
 		Type:          domain.JobTypeAppStart,
 		Payload:       payloadBytes,
+
+		TimeoutSeconds: int(app.JobTimeout(domain.JobTypeAppStart).Seconds()),
 	}
 
-	_, err = s.jobSvc.Create(ctx, job)
-	return err
+	return s.createLifecycleJob(ctx, app, job)
 }
 
 func (s *Service) Stop(ctx context.Context, appID int64) error {
@@ -294,15 +657,16 @@ func (s *Service) Stop(ctx context.Context, appID int64) error {
 	}
 
 	job := &domain.Job{
-		TraceID:       uuid.New(),
+		TraceID:       domain.TraceIDFromContext(ctx),
 		ServerID:      app.ServerID,
 		ApplicationID: &appID,
 		Type:          domain.JobTypeAppStop,
 		Payload:       payloadBytes,
+
+		TimeoutSeconds: int(app.JobTimeout(domain.JobTypeAppStop).Seconds()),
 	}
 
-	_, err = s.jobSvc.Create(ctx, job)
-	return err
+	return s.createLifecycleJob(ctx, app, job)
 }
 
 func (s *Service) Restart(ctx context.Context, appID int64) error {
@@ -325,15 +689,46 @@ func (s *Service) Restart(ctx context.Context, appID int64) error {
 	}
 
 	job := &domain.Job{
-		TraceID:       uuid.New(),
+		TraceID:       domain.TraceIDFromContext(ctx),
 		ServerID:      app.ServerID,
 		ApplicationID: &appID,
 		Type:          domain.JobTypeAppRestart,
 		Payload:       payloadBytes,
+
+		TimeoutSeconds: int(app.JobTimeout(domain.JobTypeAppRestart).Seconds()),
 	}
 
-	_, err = s.jobSvc.Create(ctx, job)
-	return err
+	return s.createLifecycleJob(ctx, app, job)
+}
+
+func (s *Service) RunCommand(ctx context.Context, appID int64, req domain.ApplicationCommandRequest) (*domain.Job, error) {
+	app, err := s.repo.GetByID(ctx, appID)
+	if err != nil {
+		return nil, err
+	}
+
+	payload := domain.RunCommandAppPayload{
+		ApplicationID: appID,
+		Service:       req.Service,
+		Command:       req.Command,
+	}
+
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	job := &domain.Job{
+		TraceID:       domain.TraceIDFromContext(ctx),
+		ServerID:      app.ServerID,
+		ApplicationID: &appID,
+		Type:          domain.JobTypeAppCommand,
+		Payload:       payloadBytes,
+
+		TimeoutSeconds: int(app.JobTimeout(domain.JobTypeAppCommand).Seconds()),
+	}
+
+	return s.jobSvc.Create(ctx, job)
 }
 
 func (s *Service) ListEnvVars(ctx context.Context, appID int64) ([]domain.EnvironmentVariable, error) {
@@ -342,7 +737,16 @@ func (s *Service) ListEnvVars(ctx context.Context, appID int64) ([]domain.Enviro
 		return nil, err
 	}
 
-	return s.repo.ListEnvVars(ctx, appID)
+	envVars, err := s.repo.ListEnvVars(ctx, appID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range envVars {
+		envVars[i].Mask()
+	}
+
+	return envVars, nil
 }
 
 func (s *Service) AddEnvVar(ctx context.Context, appID int64, req domain.EnvironmentVariableRequest) error {
@@ -367,14 +771,52 @@ func (s *Service) UpdateEnvVar(ctx context.Context, appID int64, key string, req
 		return err
 	}
 
-	env := &domain.EnvironmentVariable{
+	envVars, err := s.keepMaskedValues(ctx, appID, []domain.EnvironmentVariable{{
 		ApplicationID: appID,
 		Key:           key,
 		Value:         req.Value,
 		IsPreview:     req.IsPreview,
+	}})
+	if err != nil {
+		return err
+	}
+	if len(envVars) == 0 {
+		return domain.ErrEnvVarNotFound
 	}
 
-	return s.repo.UpdateEnvVar(ctx, env)
+	return s.repo.UpdateEnvVar(ctx, &envVars[0])
+}
+
+// keepMaskedValues puts the stored value back into env vars sent with the
+// mask, so saving what ListEnvVars returned leaves the secrets untouched. A
+// masked env var that is not stored is dropped.
+func (s *Service) keepMaskedValues(ctx context.Context, appID int64, envVars []domain.EnvironmentVariable) ([]domain.EnvironmentVariable, error) {
+	masked := false
+	for i := range envVars {
+		masked = masked || envVars[i].IsMasked()
+	}
+	if !masked {
+		return envVars, nil
+	}
+
+	values, err := s.repo.ListEnvVarValues(ctx, appID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch env vars: %w", err)
+	}
+
+	kept := envVars[:0]
+	for _, env := range envVars {
+		if env.IsMasked() {
+			value, ok := values[env.Key]
+			if !ok {
+				continue
+			}
+			env.Value = value
+		}
+		kept = append(kept, env)
+	}
+
+	return kept, nil
 }
 
 func (s *Service) DeleteEnvVar(ctx context.Context, appID int64, key string) error {
@@ -386,6 +828,53 @@ func (s *Service) DeleteEnvVar(ctx context.Context, appID int64, key string) err
 	return s.repo.DeleteEnvVar(ctx, appID, key)
 }
 
+func (s *Service) RevealEnvVar(ctx context.Context, appID int64, key string, revealedBy int64) (*domain.EnvironmentVariable, error) {
+	app, err := s.repo.GetByID(ctx, appID)
+	if err != nil {
+		return nil, err
+	}
+
+	env, err := s.repo.GetEnvVar(ctx, appID, key)
+	if err != nil {
+		return nil, err
+	}
+
+	if !env.IsPreview {
+		return nil, domain.ErrEnvVarNotRevealable
+	}
+
+	// No reveal goes unrecorded, the value is only returned once the audit
+	// entry is written.
+	if _, err := s.logSvc.Create(ctx, &domain.Log{
+		Timestamp:     time.Now().UTC(),
+		Level:         domain.LogWarn,
+		Source:        domain.LogServer,
+		Action:        domain.ActionAppEnvReveal,
+		TraceID:       domain.TraceIDFromContext(ctx),
+		ServerID:      &app.ServerID,
+		ApplicationID: &appID,
+		Message:       fmt.Sprintf("env var %s revealed by user #%d", key, revealedBy),
+	}); err != nil {
+		return nil, fmt.Errorf("failed to record env var reveal: %w", err)
+	}
+
+	return env, nil
+}
+
 func (s *Service) UpdateHealth(ctx context.Context, serverID uuid.UUID, reports []domain.ApplicationHealth) error {
-	return s.repo.UpdateHealth(ctx, serverID, reports)
+	if err := s.repo.UpdateHealth(ctx, serverID, reports); err != nil {
+		return err
+	}
+
+	if s.bus != nil {
+		for _, r := range reports {
+			s.bus.Publish("application_health_reported", domain.EventApplicationHealthReported{
+				ApplicationID: r.ApplicationID,
+				ServerID:      serverID,
+				Status:        r.Status,
+			})
+		}
+	}
+
+	return nil
 }
@@ -0,0 +1,346 @@
+package application
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"testing"
+
+	"horizonx/internal/domain"
+	"horizonx/internal/event"
+)
+
+// fakeRepo keeps the env vars of a single application in memory, methods
+// the tests do not reach panic through the nil embedded interface.
+type fakeRepo struct {
+	domain.ApplicationRepository
+
+	app  *domain.Application
+	envs map[string]domain.EnvironmentVariable
+}
+
+func newFakeRepo(app *domain.Application, envs ...domain.EnvironmentVariable) *fakeRepo {
+	r := &fakeRepo{app: app, envs: make(map[string]domain.EnvironmentVariable)}
+	for _, env := range envs {
+		env.ApplicationID = app.ID
+		r.envs[env.Key] = env
+	}
+	return r
+}
+
+func (r *fakeRepo) GetByID(ctx context.Context, appID int64) (*domain.Application, error) {
+	if appID != r.app.ID {
+		return nil, domain.ErrApplicationNotFound
+	}
+	app := *r.app
+	return &app, nil
+}
+
+func (r *fakeRepo) Update(ctx context.Context, app *domain.Application, appID int64) error {
+	app.ID = appID
+	app.ServerID = r.app.ServerID
+	r.app = app
+	return nil
+}
+
+func (r *fakeRepo) SyncEnvVars(ctx context.Context, appID int64, envVars []domain.EnvironmentVariable) error {
+	if len(envVars) == 0 {
+		return nil
+	}
+	r.envs = make(map[string]domain.EnvironmentVariable)
+	for _, env := range envVars {
+		env.ApplicationID = appID
+		r.envs[env.Key] = env
+	}
+	return nil
+}
+
+func (r *fakeRepo) UpdateEnvVar(ctx context.Context, env *domain.EnvironmentVariable) error {
+	if _, ok := r.envs[env.Key]; !ok {
+		return domain.ErrEnvVarNotFound
+	}
+	r.envs[env.Key] = *env
+	return nil
+}
+
+func (r *fakeRepo) ListEnvVars(ctx context.Context, appID int64) ([]domain.EnvironmentVariable, error) {
+	envVars := make([]domain.EnvironmentVariable, 0, len(r.envs))
+	for _, env := range r.envs {
+		envVars = append(envVars, env)
+	}
+	sort.Slice(envVars, func(i, j int) bool { return envVars[i].Key < envVars[j].Key })
+	return envVars, nil
+}
+
+func (r *fakeRepo) ListEnvVarValues(ctx context.Context, appID int64) (map[string]string, error) {
+	values := make(map[string]string, len(r.envs))
+	for key, env := range r.envs {
+		values[key] = env.Value
+	}
+	return values, nil
+}
+
+// fakeDeployments serves the deployments a rollback looks up and records
+// the ones it creates.
+type fakeDeployments struct {
+	domain.DeploymentService
+
+	deployments map[int64]*domain.Deployment
+	created     []domain.DeploymentCreateRequest
+}
+
+func (d *fakeDeployments) GetByID(ctx context.Context, deploymentID int64) (*domain.Deployment, error) {
+	deployment, ok := d.deployments[deploymentID]
+	if !ok {
+		return nil, domain.ErrDeploymentNotFound
+	}
+	return deployment, nil
+}
+
+func (d *fakeDeployments) GetLastSuccessful(ctx context.Context, appID int64, beforeID int64) (*domain.Deployment, error) {
+	var last *domain.Deployment
+	for _, deployment := range d.deployments {
+		if deployment.ApplicationID != appID || deployment.ID >= beforeID || deployment.Status != domain.DeploymentSuccess {
+			continue
+		}
+		if last == nil || deployment.ID > last.ID {
+			last = deployment
+		}
+	}
+	if last == nil {
+		return nil, domain.ErrDeploymentNotFound
+	}
+	return last, nil
+}
+
+func (d *fakeDeployments) Create(ctx context.Context, req domain.DeploymentCreateRequest) (*domain.Deployment, error) {
+	d.created = append(d.created, req)
+	deployment := &domain.Deployment{
+		ID:            int64(100 + len(d.created)),
+		ApplicationID: req.ApplicationID,
+		Branch:        req.Branch,
+		Image:         req.Image,
+		RollbackOf:    req.RollbackOf,
+		Status:        domain.DeploymentPending,
+	}
+	return deployment, nil
+}
+
+// fakeJobs records the jobs created by deploys.
+type fakeJobs struct {
+	domain.JobService
+
+	created []*domain.Job
+}
+
+func (j *fakeJobs) Create(ctx context.Context, job *domain.Job) (*domain.Job, error) {
+	job.ID = int64(len(j.created) + 1)
+	j.created = append(j.created, job)
+	return job, nil
+}
+
+func (j *fakeJobs) CreateDeploy(ctx context.Context, job *domain.Job) (*domain.Job, []*domain.Job, error) {
+	job, err := j.Create(ctx, job)
+	return job, nil, err
+}
+
+func gitApp() *domain.Application {
+	return &domain.Application{
+		ID:      1,
+		Name:    "web",
+		Source:  domain.AppSourceGit,
+		RepoURL: "https://example.com/web.git",
+		Branch:  "main",
+	}
+}
+
+func TestUpdateKeepsMaskedEnvVars(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeRepo(gitApp(),
+		domain.EnvironmentVariable{Key: "API_TOKEN", Value: "s3cret"},
+		domain.EnvironmentVariable{Key: "DEBUG", Value: "false", IsPreview: true},
+	)
+	svc := NewService(repo, nil, nil, nil, nil, nil, nil, nil)
+
+	listed, err := svc.ListEnvVars(ctx, 1)
+	if err != nil {
+		t.Fatalf("ListEnvVars: %v", err)
+	}
+
+	// what a client gets from GET and sends back with PUT
+	raw, err := json.Marshal(listed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var envVars []domain.EnvironmentVariableRequest
+	if err := json.Unmarshal(raw, &envVars); err != nil {
+		t.Fatal(err)
+	}
+	for _, env := range envVars {
+		if env.Value != domain.EnvValueMask {
+			t.Fatalf("listed %s = %q, want the mask", env.Key, env.Value)
+		}
+	}
+	envVars[1].Value = "true"
+	envVars = append(envVars, domain.EnvironmentVariableRequest{Key: "GHOST", Value: domain.EnvValueMask})
+
+	err = svc.Update(ctx, domain.ApplicationUpdateRequest{
+		Name:    gitApp().Name,
+		EnvVars: envVars,
+	}, 1)
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+
+	want := map[string]string{
+		"API_TOKEN": "s3cret",
+		"DEBUG":     "true",
+	}
+	got, _ := repo.ListEnvVarValues(ctx, 1)
+	if len(got) != len(want) {
+		t.Fatalf("stored %v, want %v", got, want)
+	}
+	for key, value := range want {
+		if got[key] != value {
+			t.Errorf("stored %s = %q, want %q", key, got[key], value)
+		}
+	}
+	if !repo.envs["DEBUG"].IsPreview {
+		t.Error("DEBUG lost is_preview")
+	}
+}
+
+func TestUpdateKeepsOmittedSettings(t *testing.T) {
+	ctx := context.Background()
+	credentialID := int64(3)
+	app := gitApp()
+	app.GitCredentialID = &credentialID
+	app.JobTimeouts = domain.JobTimeouts{domain.JobTypeAppDeploy: 600}
+	app.DeployStrategy = domain.DeployBlueGreen
+	repo := newFakeRepo(app)
+	svc := NewService(repo, nil, nil, nil, nil, nil, nil, nil)
+
+	if err := svc.Update(ctx, domain.ApplicationUpdateRequest{Name: "web-renamed"}, 1); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+
+	got := repo.app
+	if got.Name != "web-renamed" {
+		t.Errorf("name = %q, want web-renamed", got.Name)
+	}
+	if got.RepoURL != app.RepoURL || got.Branch != app.Branch {
+		t.Errorf("repo %q branch %q, want %q %q", got.RepoURL, got.Branch, app.RepoURL, app.Branch)
+	}
+	if got.GitCredentialID == nil || *got.GitCredentialID != credentialID {
+		t.Errorf("git credential = %v, want %d", got.GitCredentialID, credentialID)
+	}
+	if got.JobTimeouts[domain.JobTypeAppDeploy] != 600 {
+		t.Errorf("job timeouts = %v, want app_deploy 600", got.JobTimeouts)
+	}
+	if got.DeployStrategy != domain.DeployBlueGreen {
+		t.Errorf("deploy strategy = %q, want blue_green", got.DeployStrategy)
+	}
+
+	detach := int64(0)
+	branch := "release"
+	err := svc.Update(ctx, domain.ApplicationUpdateRequest{
+		Name:            "web",
+		Branch:          &branch,
+		GitCredentialID: &detach,
+		JobTimeouts:     &domain.JobTimeouts{},
+	}, 1)
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+
+	got = repo.app
+	if got.Branch != "release" || got.RepoURL != app.RepoURL {
+		t.Errorf("repo %q branch %q, want %q release", got.RepoURL, got.Branch, app.RepoURL)
+	}
+	if got.GitCredentialID != nil {
+		t.Errorf("git credential = %d, want it detached", *got.GitCredentialID)
+	}
+	if len(got.JobTimeouts) != 0 {
+		t.Errorf("job timeouts = %v, want them cleared", got.JobTimeouts)
+	}
+}
+
+func TestUpdateEnvVarKeepsMaskedValue(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeRepo(gitApp(), domain.EnvironmentVariable{Key: "API_TOKEN", Value: "s3cret"})
+	svc := NewService(repo, nil, nil, nil, nil, nil, nil, nil)
+
+	err := svc.UpdateEnvVar(ctx, 1, "API_TOKEN", domain.EnvironmentVariableRequest{
+		Key:       "API_TOKEN",
+		Value:     domain.EnvValueMask,
+		IsPreview: true,
+	})
+	if err != nil {
+		t.Fatalf("UpdateEnvVar: %v", err)
+	}
+
+	env := repo.envs["API_TOKEN"]
+	if env.Value != "s3cret" || !env.IsPreview {
+		t.Errorf("stored %+v, want the old value with is_preview set", env)
+	}
+
+	err = svc.UpdateEnvVar(ctx, 1, "MISSING", domain.EnvironmentVariableRequest{
+		Key:   "MISSING",
+		Value: domain.EnvValueMask,
+	})
+	if !errors.Is(err, domain.ErrEnvVarNotFound) {
+		t.Errorf("masked update of a missing key: err = %v, want ErrEnvVarNotFound", err)
+	}
+}
+
+func TestRollbackImageApplication(t *testing.T) {
+	ctx := context.Background()
+	image := "registry.example.com/web:1.4.0"
+	repo := newFakeRepo(&domain.Application{
+		ID:          1,
+		Name:        "web",
+		Source:      domain.AppSourceImage,
+		ComposeFile: "services:\n  web:\n    image: ${HORIZONX_IMAGE_TAG}\n",
+	})
+	deployments := &fakeDeployments{deployments: map[int64]*domain.Deployment{
+		10: {ID: 10, ApplicationID: 1, Status: domain.DeploymentSuccess, Image: &image},
+		11: {ID: 11, ApplicationID: 1, Status: domain.DeploymentFailed},
+	}}
+	jobs := &fakeJobs{}
+	bus := event.New()
+
+	var rolledBack []domain.EventDeploymentRolledBack
+	bus.Subscribe("deployment_rolled_back", func(evt any) {
+		rolledBack = append(rolledBack, evt.(domain.EventDeploymentRolledBack))
+	})
+
+	svc := NewService(repo, nil, jobs, deployments, nil, nil, nil, bus)
+
+	deployment, err := svc.Rollback(ctx, 11, domain.RollbackDeployFailed)
+	if err != nil {
+		t.Fatalf("Rollback: %v", err)
+	}
+	if deployment.RollbackOf == nil || *deployment.RollbackOf != 11 {
+		t.Errorf("rollback_of = %v, want 11", deployment.RollbackOf)
+	}
+
+	if len(jobs.created) != 1 {
+		t.Fatalf("created %d jobs, want 1", len(jobs.created))
+	}
+	var payload domain.DeployAppPayload
+	if err := json.Unmarshal(jobs.created[0].Payload, &payload); err != nil {
+		t.Fatal(err)
+	}
+	if payload.Image != image || payload.Ref != "" {
+		t.Errorf("payload image %q ref %q, want image %q and no ref", payload.Image, payload.Ref, image)
+	}
+
+	if len(rolledBack) != 1 {
+		t.Fatalf("published %d rollback events, want 1", len(rolledBack))
+	}
+	evt := rolledBack[0]
+	if evt.Image != image || evt.CommitHash != "" || evt.RollbackID != deployment.ID {
+		t.Errorf("rollback event = %+v", evt)
+	}
+}
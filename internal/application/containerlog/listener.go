@@ -0,0 +1,37 @@
+package containerlog
+
+import (
+	"horizonx/internal/domain"
+	"horizonx/internal/event"
+	"horizonx/internal/logger"
+)
+
+type Listener struct {
+	svc domain.ContainerLogService
+	log logger.Logger
+}
+
+func NewListener(svc domain.ContainerLogService, log logger.Logger) *Listener {
+	return &Listener{
+		svc: svc,
+		log: log,
+	}
+}
+
+func (l *Listener) Register(bus *event.Bus) {
+	bus.Subscribe("server_status_changed", l.handleServerStatusChanged)
+}
+
+// handleServerStatusChanged forgets the streams of an agent that went away,
+// its log processes die with the websocket session.
+func (l *Listener) handleServerStatusChanged(event any) {
+	evt, ok := event.(domain.EventServerStatusChanged)
+	if !ok {
+		l.log.Warn("invalid event payload for server_status_changed", "event", event)
+		return
+	}
+
+	if !evt.IsOnline {
+		l.svc.DropServerStreams(evt.ServerID)
+	}
+}
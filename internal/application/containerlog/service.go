@@ -0,0 +1,309 @@
+// Package containerlog
+package containerlog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"horizonx/internal/domain"
+	"horizonx/internal/event"
+	"horizonx/internal/logger"
+
+	"github.com/google/uuid"
+)
+
+type Service struct {
+	appSvc    domain.ApplicationService
+	serverSvc domain.ServerService
+	presence  domain.WsPresence
+	bus       *event.Bus
+	log       logger.Logger
+
+	mu      sync.Mutex
+	pending map[uuid.UUID]*pendingRequest
+	streams map[string]*stream
+}
+
+// pendingRequest collects the chunks of a one-off request until the agent
+// marks it done.
+type pendingRequest struct {
+	serverID uuid.UUID
+	lines    []domain.ContainerLogLine
+	done     chan error
+}
+
+// stream is a follow request running on an agent. It is shared by every
+// viewer of the same channel.
+type stream struct {
+	requestID uuid.UUID
+	serverID  uuid.UUID
+	appID     int64
+	channel   string
+}
+
+func NewService(
+	appSvc domain.ApplicationService,
+	serverSvc domain.ServerService,
+	presence domain.WsPresence,
+	bus *event.Bus,
+	log logger.Logger,
+) domain.ContainerLogService {
+	return &Service{
+		appSvc:    appSvc,
+		serverSvc: serverSvc,
+		presence:  presence,
+		bus:       bus,
+		log:       log,
+
+		pending: make(map[uuid.UUID]*pendingRequest),
+		streams: make(map[string]*stream),
+	}
+}
+
+func (s *Service) Get(ctx context.Context, appID int64, opts domain.ContainerLogOptions) (*domain.ContainerLogs, error) {
+	app, err := s.appSvc.GetByID(ctx, appID)
+	if err != nil {
+		return nil, err
+	}
+
+	server, err := s.serverSvc.GetByID(ctx, app.ServerID)
+	if err != nil {
+		return nil, err
+	}
+	if !server.IsOnline {
+		return nil, domain.ErrAgentOffline
+	}
+
+	switch {
+	case opts.Tail <= 0 && opts.Since != "":
+		opts.Tail = domain.ContainerLogsMaxTail
+	case opts.Tail <= 0:
+		opts.Tail = domain.ContainerLogsDefaultTail
+	}
+	opts.Tail = min(opts.Tail, domain.ContainerLogsMaxTail)
+
+	result := &domain.ContainerLogs{ApplicationID: appID}
+
+	// The live stream starts before the backlog is read so no line falls in
+	// between, at the cost of an occasional duplicate.
+	if opts.Follow {
+		result.Channel = s.follow(app, opts.Service)
+	}
+
+	lines, err := s.fetch(ctx, app.ServerID, appID, opts)
+	if err != nil {
+		return nil, err
+	}
+	result.Lines = lines
+
+	return result, nil
+}
+
+func (s *Service) HandleChunk(serverID uuid.UUID, chunk domain.ContainerLogsChunk) {
+	s.mu.Lock()
+
+	if p, ok := s.pending[chunk.RequestID]; ok {
+		if p.serverID != serverID {
+			s.mu.Unlock()
+			s.log.Warn("container logs: chunk from unexpected server", "server_id", serverID, "request_id", chunk.RequestID)
+			return
+		}
+
+		p.lines = append(p.lines, chunk.Lines...)
+		s.mu.Unlock()
+
+		if chunk.Done {
+			var err error
+			if chunk.Error != "" {
+				err = fmt.Errorf("%w: %s", domain.ErrContainerLogsFailed, chunk.Error)
+			}
+			select {
+			case p.done <- err:
+			default:
+			}
+		}
+		return
+	}
+
+	st := s.streamByRequest(chunk.RequestID)
+	if st == nil || st.serverID != serverID {
+		s.mu.Unlock()
+
+		// Chunks of a stream we no longer know about mean the agent missed
+		// the stop message, so tell it again.
+		if !chunk.Done {
+			s.publishStop(serverID, chunk.RequestID)
+		}
+		return
+	}
+
+	if chunk.Done {
+		delete(s.streams, st.channel)
+	}
+	s.mu.Unlock()
+
+	if len(chunk.Lines) == 0 && !chunk.Done {
+		return
+	}
+
+	s.bus.Publish("container_logs_received", domain.EventContainerLogsReceived{
+		ApplicationID: st.appID,
+		Channel:       st.channel,
+		Lines:         chunk.Lines,
+		Done:          chunk.Done,
+		Error:         chunk.Error,
+	})
+}
+
+func (s *Service) ChannelEmpty(channel string) {
+	s.mu.Lock()
+	st, ok := s.streams[channel]
+	if ok {
+		delete(s.streams, channel)
+	}
+	s.mu.Unlock()
+
+	if ok {
+		s.publishStop(st.serverID, st.requestID)
+	}
+}
+
+func (s *Service) DropServerStreams(serverID uuid.UUID) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for channel, st := range s.streams {
+		if st.serverID == serverID {
+			delete(s.streams, channel)
+		}
+	}
+}
+
+// follow makes sure a stream feeds the channel of app and service and
+// returns the channel name.
+func (s *Service) follow(app *domain.Application, service string) string {
+	channel := domain.ContainerLogsChannel(app.ID, service)
+
+	s.mu.Lock()
+	if _, ok := s.streams[channel]; ok {
+		s.mu.Unlock()
+		return channel
+	}
+
+	st := &stream{
+		requestID: uuid.New(),
+		serverID:  app.ServerID,
+		appID:     app.ID,
+		channel:   channel,
+	}
+	s.streams[channel] = st
+	s.mu.Unlock()
+
+	s.bus.Publish("container_logs_requested", domain.EventContainerLogsRequested{
+		ServerID: app.ServerID,
+		Request: domain.ContainerLogsRequest{
+			RequestID:     st.requestID,
+			ApplicationID: app.ID,
+			Options: domain.ContainerLogOptions{
+				Service: service,
+				Follow:  true,
+			},
+		},
+	})
+
+	// The hub only reports channels that emptied, so a caller that never
+	// subscribes has to be caught here.
+	time.AfterFunc(domain.ContainerLogsSubscribeTimeout, func() {
+		if s.presence.Subscribers(channel) > 0 {
+			return
+		}
+
+		s.mu.Lock()
+		current, ok := s.streams[channel]
+		if !ok || current != st {
+			s.mu.Unlock()
+			return
+		}
+		delete(s.streams, channel)
+		s.mu.Unlock()
+
+		s.publishStop(st.serverID, st.requestID)
+	})
+
+	return channel
+}
+
+func (s *Service) fetch(
+	ctx context.Context,
+	serverID uuid.UUID,
+	appID int64,
+	opts domain.ContainerLogOptions,
+) ([]domain.ContainerLogLine, error) {
+	opts.Follow = false
+	req := domain.ContainerLogsRequest{
+		RequestID:     uuid.New(),
+		ApplicationID: appID,
+		Options:       opts,
+	}
+
+	p := &pendingRequest{
+		serverID: serverID,
+		lines:    []domain.ContainerLogLine{},
+		done:     make(chan error, 1),
+	}
+
+	s.mu.Lock()
+	s.pending[req.RequestID] = p
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.pending, req.RequestID)
+		s.mu.Unlock()
+	}()
+
+	s.bus.Publish("container_logs_requested", domain.EventContainerLogsRequested{
+		ServerID: serverID,
+		Request:  req,
+	})
+
+	ctx, cancel := context.WithTimeout(ctx, domain.ContainerLogsTimeout)
+	defer cancel()
+
+	select {
+	case err := <-p.done:
+		if err != nil {
+			return nil, err
+		}
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return p.lines, nil
+
+	case <-ctx.Done():
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return nil, domain.ErrContainerLogsTimeout
+		}
+		return nil, ctx.Err()
+	}
+}
+
+func (s *Service) streamByRequest(requestID uuid.UUID) *stream {
+	for _, st := range s.streams {
+		if st.requestID == requestID {
+			return st
+		}
+	}
+
+	return nil
+}
+
+func (s *Service) publishStop(serverID uuid.UUID, requestID uuid.UUID) {
+	s.bus.Publish("container_logs_stopped", domain.EventContainerLogsStopped{
+		ServerID:  serverID,
+		RequestID: requestID,
+	})
+}
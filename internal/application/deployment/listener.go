@@ -82,12 +82,20 @@ func (l *Listener) handleJobFinished(event any) {
 		return
 	}
 
+	// Another attempt is queued, the deployment is not over yet
+	if evt.WillRetry {
+		return
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
 	status := domain.DeploymentSuccess
-	if evt.Status == domain.JobFailed {
+	switch evt.Status {
+	case domain.JobFailed, domain.JobExpired:
 		status = domain.DeploymentFailed
+	case domain.JobCancelled:
+		status = domain.DeploymentCancelled
 	}
 
 	_ = l.updateStatus(ctx, *evt.DeploymentID, status)
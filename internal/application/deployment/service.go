@@ -79,11 +79,21 @@ func (s *Service) GetByID(ctx context.Context, deploymentID int64) (*domain.Depl
 	return deployment, err
 }
 
+func (s *Service) GetLastSuccessful(ctx context.Context, appID int64, beforeID int64) (*domain.Deployment, error) {
+	return s.repo.GetLastSuccessful(ctx, appID, beforeID)
+}
+
 func (s *Service) Create(ctx context.Context, req domain.DeploymentCreateRequest) (*domain.Deployment, error) {
 	deployment := &domain.Deployment{
 		ApplicationID: req.ApplicationID,
 		Branch:        req.Branch,
+		Ref:           req.Ref,
+		CommitHash:    req.CommitHash,
+		CommitMessage: req.CommitMessage,
 		DeployedBy:    req.DeployedBy,
+		PushedBy:      req.PushedBy,
+		Image:         req.Image,
+		RollbackOf:    req.RollbackOf,
 		Status:        domain.DeploymentPending,
 	}
 
@@ -96,7 +106,8 @@ func (s *Service) Create(ctx context.Context, req domain.DeploymentCreateRequest
 		s.bus.Publish("deployment_created", domain.EventDeploymentCreated{
 			DeploymentID:  created.ID,
 			ApplicationID: created.ApplicationID,
-			DeployedBy:    *created.DeployedBy,
+			DeployedBy:    created.DeployedBy,
+			RollbackOf:    created.RollbackOf,
 			TriggeredAt:   created.TriggeredAt,
 		})
 	}
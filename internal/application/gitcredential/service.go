@@ -0,0 +1,196 @@
+// Package gitcredential
+package gitcredential
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"horizonx/internal/domain"
+	"horizonx/internal/secret"
+
+	"golang.org/x/crypto/ssh"
+)
+
+type Service struct {
+	repo domain.GitCredentialRepository
+	box  *secret.Box
+}
+
+// NewService takes a nil box when no secret key is configured, credentials
+// can then be listed but not created or used.
+func NewService(repo domain.GitCredentialRepository, box *secret.Box) domain.GitCredentialService {
+	return &Service{
+		repo: repo,
+		box:  box,
+	}
+}
+
+func (s *Service) List(ctx context.Context) ([]*domain.GitCredential, error) {
+	return s.repo.List(ctx)
+}
+
+func (s *Service) GetByID(ctx context.Context, credentialID int64) (*domain.GitCredential, error) {
+	return s.repo.GetByID(ctx, credentialID)
+}
+
+func (s *Service) Create(ctx context.Context, req domain.GitCredentialCreateRequest, createdBy int64) (*domain.GitCredential, error) {
+	c := &domain.GitCredential{
+		Name:       req.Name,
+		Type:       req.Type,
+		Username:   req.Username,
+		KnownHosts: strings.TrimSpace(req.KnownHosts),
+		CreatedBy:  &createdBy,
+	}
+
+	if err := checkKnownHosts(c); err != nil {
+		return nil, err
+	}
+
+	id, err := s.repo.NextID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c.ID = id
+
+	if err := s.setSecret(c, req.Secret); err != nil {
+		return nil, err
+	}
+
+	return s.repo.Create(ctx, c)
+}
+
+func (s *Service) Update(ctx context.Context, credentialID int64, req domain.GitCredentialUpdateRequest) (*domain.GitCredential, error) {
+	c, err := s.repo.GetByID(ctx, credentialID)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.Type == domain.GitCredentialHTTPSToken && req.Username == "" {
+		return nil, fmt.Errorf("%w: username is required for an https token", domain.ErrInvalidGitCredential)
+	}
+
+	c.Name = req.Name
+	c.Username = req.Username
+	c.KnownHosts = strings.TrimSpace(req.KnownHosts)
+
+	if err := checkKnownHosts(c); err != nil {
+		return nil, err
+	}
+
+	if req.Secret != "" {
+		if err := s.setSecret(c, req.Secret); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.repo.Update(ctx, c); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+func (s *Service) Delete(ctx context.Context, credentialID int64) error {
+	return s.repo.Delete(ctx, credentialID)
+}
+
+func (s *Service) Resolve(ctx context.Context, credentialID int64) (*domain.GitAuth, error) {
+	if s.box == nil {
+		return nil, domain.ErrSecretsDisabled
+	}
+
+	c, err := s.repo.GetByID(ctx, credentialID)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := s.box.Open(c.EncryptedSecret, secretAAD(c.ID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt git credential %d: %w", c.ID, err)
+	}
+
+	return &domain.GitAuth{
+		Type:       c.Type,
+		Username:   c.Username,
+		Secret:     string(plaintext),
+		KnownHosts: c.KnownHosts,
+	}, nil
+}
+
+// checkKnownHosts makes sure an SSH credential pins the host keys of the git
+// server, agents refuse to connect without them.
+func checkKnownHosts(c *domain.GitCredential) error {
+	if c.Type != domain.GitCredentialSSHKey {
+		return nil
+	}
+
+	if c.KnownHosts == "" {
+		return fmt.Errorf("%w: known_hosts is required for an ssh key, e.g. the output of ssh-keyscan", domain.ErrInvalidGitCredential)
+	}
+
+	rest := []byte(c.KnownHosts)
+	for entries := 0; ; entries++ {
+		_, _, _, _, next, err := ssh.ParseKnownHosts(rest)
+		if errors.Is(err, io.EOF) {
+			if entries == 0 {
+				return fmt.Errorf("%w: known_hosts has no host key", domain.ErrInvalidGitCredential)
+			}
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("%w: known_hosts: %s", domain.ErrInvalidGitCredential, err.Error())
+		}
+		rest = next
+	}
+}
+
+// setSecret checks and encrypts a new secret. SSH keys must be unencrypted,
+// the agent has no way to ask for a passphrase.
+func (s *Service) setSecret(c *domain.GitCredential, value string) error {
+	if s.box == nil {
+		return domain.ErrSecretsDisabled
+	}
+
+	switch c.Type {
+	case domain.GitCredentialSSHKey:
+		value = strings.TrimSpace(value) + "\n"
+
+		key, err := ssh.ParseRawPrivateKey([]byte(value))
+		if err != nil {
+			var missing *ssh.PassphraseMissingError
+			if errors.As(err, &missing) {
+				return fmt.Errorf("%w: ssh key must not be protected by a passphrase", domain.ErrInvalidGitCredential)
+			}
+			return fmt.Errorf("%w: %s", domain.ErrInvalidGitCredential, err.Error())
+		}
+
+		signer, err := ssh.NewSignerFromKey(key)
+		if err != nil {
+			return fmt.Errorf("%w: %s", domain.ErrInvalidGitCredential, err.Error())
+		}
+		c.PublicKey = strings.TrimSpace(string(ssh.MarshalAuthorizedKey(signer.PublicKey())))
+
+	case domain.GitCredentialHTTPSToken:
+		value = strings.TrimSpace(value)
+		if strings.ContainsAny(value, "\r\n") {
+			return fmt.Errorf("%w: token must be a single line", domain.ErrInvalidGitCredential)
+		}
+		c.PublicKey = ""
+	}
+
+	sealed, err := s.box.Seal([]byte(value), secretAAD(c.ID))
+	if err != nil {
+		return err
+	}
+	c.EncryptedSecret = sealed
+
+	return nil
+}
+
+// secretAAD binds a sealed secret to its credential.
+func secretAAD(credentialID int64) []byte {
+	return secret.AAD("git_credentials", "encrypted_secret", credentialID)
+}
@@ -0,0 +1,221 @@
+// Package gitpoll
+package gitpoll
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"horizonx/internal/domain"
+	"horizonx/internal/logger"
+
+	"github.com/google/uuid"
+)
+
+type Service struct {
+	repo          domain.GitPollRepository
+	appSvc        domain.ApplicationService
+	serverSvc     domain.ServerService
+	jobSvc        domain.JobService
+	deploymentSvc domain.DeploymentService
+	log           logger.Logger
+}
+
+func NewService(
+	repo domain.GitPollRepository,
+	appSvc domain.ApplicationService,
+	serverSvc domain.ServerService,
+	jobSvc domain.JobService,
+	deploymentSvc domain.DeploymentService,
+	log logger.Logger,
+) domain.GitPollService {
+	return &Service{
+		repo:          repo,
+		appSvc:        appSvc,
+		serverSvc:     serverSvc,
+		jobSvc:        jobSvc,
+		deploymentSvc: deploymentSvc,
+		log:           log,
+	}
+}
+
+func (s *Service) PollDue(ctx context.Context) (int, error) {
+	appIDs, err := s.repo.ClaimDue(ctx, time.Now().UTC(), domain.GitPollDueLimit)
+	if err != nil {
+		return 0, err
+	}
+
+	var errs []error
+	targets := make(map[uuid.UUID][]domain.GitPollTarget)
+
+	for _, appID := range appIDs {
+		app, err := s.appSvc.GetByID(ctx, appID)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("application %d: %w", appID, err))
+			continue
+		}
+
+		targets[app.ServerID] = append(targets[app.ServerID], domain.GitPollTarget{
+			ApplicationID:   app.ID,
+			RepoURL:         app.RepoURL,
+			Branch:          app.Branch,
+			GitCredentialID: app.GitCredentialID,
+		})
+	}
+
+	polled := 0
+	for serverID, serverTargets := range targets {
+		if err := s.enqueue(ctx, serverID, serverTargets); err != nil {
+			errs = append(errs, fmt.Errorf("server %s: %w", serverID, err))
+			continue
+		}
+		polled += len(serverTargets)
+	}
+
+	return polled, errors.Join(errs...)
+}
+
+// enqueue creates the poll job of a server. Offline servers and servers
+// still busy with the previous poll are skipped, their applications come
+// up again after the next interval.
+func (s *Service) enqueue(ctx context.Context, serverID uuid.UUID, targets []domain.GitPollTarget) error {
+	server, err := s.serverSvc.GetByID(ctx, serverID)
+	if err != nil {
+		return err
+	}
+	if !server.IsOnline {
+		return nil
+	}
+
+	jobs, err := s.jobSvc.List(ctx, domain.JobListOptions{
+		ListOptions: domain.ListOptions{Limit: 1},
+		ServerID:    &serverID,
+		Type:        string(domain.JobTypeAppGitPoll),
+		Statuses:    []string{string(domain.JobQueued), string(domain.JobRunning)},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list poll jobs: %w", err)
+	}
+	if len(jobs.Data) > 0 {
+		return nil
+	}
+
+	payload, err := json.Marshal(domain.AppGitPollPayload{Targets: targets})
+	if err != nil {
+		return fmt.Errorf("failed to marshal job payload: %w", err)
+	}
+
+	_, err = s.jobSvc.Create(ctx, &domain.Job{
+		TraceID:  uuid.New(),
+		ServerID: serverID,
+		Type:     domain.JobTypeAppGitPoll,
+		Payload:  payload,
+	})
+	return err
+}
+
+func (s *Service) HandleHeads(ctx context.Context, serverID uuid.UUID, heads []domain.GitRemoteHead) error {
+	var errs []error
+
+	for _, head := range heads {
+		if err := s.handleHead(ctx, serverID, head); err != nil {
+			errs = append(errs, fmt.Errorf("application %d: %w", head.ApplicationID, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (s *Service) handleHead(ctx context.Context, serverID uuid.UUID, head domain.GitRemoteHead) error {
+	app, err := s.appSvc.GetByID(ctx, head.ApplicationID)
+	if err != nil {
+		return err
+	}
+	if app.ServerID != serverID {
+		s.log.Warn("git poll: head from unexpected server", "server_id", serverID, "app_id", app.ID)
+		return nil
+	}
+	if app.GitPollIntervalSeconds == 0 {
+		return nil
+	}
+
+	if head.Error != "" {
+		s.log.Warn("git poll: failed to read remote", "app_id", app.ID, "branch", app.Branch, "error", head.Error)
+		return nil
+	}
+
+	state, err := s.repo.Get(ctx, app.ID)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	if !domain.SameCommit(head.CommitHash, state.RemoteHash) {
+		state.RemoteChangedAt = &now
+	}
+	state.RemoteHash = head.CommitHash
+
+	deploy, err := s.shouldDeploy(ctx, app, state, now)
+	if err != nil {
+		return err
+	}
+
+	if deploy {
+		deployment, err := s.appSvc.Deploy(ctx, app.ID, nil, domain.ApplicationDeployRequest{
+			Push: &domain.GitPush{
+				Branch:     app.Branch,
+				CommitHash: head.CommitHash,
+			},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to deploy %s: %w", head.CommitHash, err)
+		}
+		state.TriggeredHash = head.CommitHash
+
+		s.log.Info("git poll: deploying new commit",
+			"app_id", app.ID,
+			"commit", head.CommitHash,
+			"deployment_id", deployment.ID,
+		)
+	}
+
+	return s.repo.Save(ctx, state)
+}
+
+// shouldDeploy reports whether the remote head is new and has settled. A
+// head is only deployed once, a failed deploy waits for the next commit.
+func (s *Service) shouldDeploy(ctx context.Context, app *domain.Application, state *domain.GitPollState, now time.Time) (bool, error) {
+	if domain.SameCommit(state.RemoteHash, state.TriggeredHash) {
+		return false, nil
+	}
+
+	debounce := time.Duration(app.GitPollDebounceSeconds) * time.Second
+	if state.RemoteChangedAt != nil && now.Sub(*state.RemoteChangedAt) < debounce {
+		return false, nil
+	}
+
+	last, err := s.deploymentSvc.GetLastSuccessful(ctx, app.ID, math.MaxInt64)
+	switch {
+	case errors.Is(err, domain.ErrDeploymentNotFound):
+	case err != nil:
+		return false, err
+	case last.CommitHash != nil && domain.SameCommit(*last.CommitHash, state.RemoteHash):
+		return false, nil
+	}
+
+	// A deploy already on its way is left alone, the head is looked at
+	// again on the next poll.
+	running, err := s.deploymentSvc.List(ctx, domain.DeploymentListOptions{
+		ListOptions:   domain.ListOptions{Limit: 1},
+		ApplicationID: &app.ID,
+		Statuses:      []string{string(domain.DeploymentPending), string(domain.DeploymentDeploying)},
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return len(running.Data) == 0, nil
+}
@@ -0,0 +1,159 @@
+package gitpoll
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"horizonx/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+const (
+	fullHash  = "0d1a26e67d8f5eaf1f6ba5c57fc3c7d91ac0fd1c"
+	shortHash = "0d1a26e6"
+	nextHash  = "bffeb74224043ba2feb48d137756c8a9331c449a"
+)
+
+type nopLogger struct{}
+
+func (nopLogger) Debug(msg string, args ...any) {}
+func (nopLogger) Info(msg string, args ...any)  {}
+func (nopLogger) Warn(msg string, args ...any)  {}
+func (nopLogger) Error(msg string, args ...any) {}
+
+type fakeStates struct {
+	domain.GitPollRepository
+
+	state domain.GitPollState
+}
+
+func (r *fakeStates) Get(ctx context.Context, appID int64) (*domain.GitPollState, error) {
+	state := r.state
+	return &state, nil
+}
+
+func (r *fakeStates) Save(ctx context.Context, state *domain.GitPollState) error {
+	r.state = *state
+	return nil
+}
+
+// fakeApps serves one polled application and records its deploys.
+type fakeApps struct {
+	domain.ApplicationService
+
+	app      *domain.Application
+	deployed []string
+}
+
+func (a *fakeApps) GetByID(ctx context.Context, appID int64) (*domain.Application, error) {
+	return a.app, nil
+}
+
+func (a *fakeApps) Deploy(ctx context.Context, appID int64, deployedBy *int64, req domain.ApplicationDeployRequest) (*domain.Deployment, error) {
+	a.deployed = append(a.deployed, req.Push.CommitHash)
+	return &domain.Deployment{ID: int64(len(a.deployed)), ApplicationID: appID}, nil
+}
+
+// fakeDeployments has one successful deployment and none running.
+type fakeDeployments struct {
+	domain.DeploymentService
+
+	last *domain.Deployment
+}
+
+func (d *fakeDeployments) GetLastSuccessful(ctx context.Context, appID int64, beforeID int64) (*domain.Deployment, error) {
+	if d.last == nil || beforeID != math.MaxInt64 {
+		return nil, domain.ErrDeploymentNotFound
+	}
+	return d.last, nil
+}
+
+func (d *fakeDeployments) List(ctx context.Context, opts domain.DeploymentListOptions) (*domain.ListResult[*domain.Deployment], error) {
+	return &domain.ListResult[*domain.Deployment]{}, nil
+}
+
+func TestHandleHeadsShortStoredHashes(t *testing.T) {
+	serverID := uuid.New()
+	changedAt := time.Now().Add(-time.Hour).UTC()
+	short := shortHash
+
+	tests := []struct {
+		name       string
+		state      domain.GitPollState
+		lastHash   *string
+		head       string
+		wantDeploy []string
+	}{
+		{
+			name: "state from before full hashes",
+			state: domain.GitPollState{
+				RemoteHash:      shortHash,
+				RemoteChangedAt: &changedAt,
+				TriggeredHash:   shortHash,
+			},
+			lastHash: &short,
+			head:     fullHash,
+		},
+		{
+			name:     "deployed before polling was enabled",
+			lastHash: &short,
+			head:     fullHash,
+		},
+		{
+			name: "new head after a short hash",
+			state: domain.GitPollState{
+				RemoteHash:      shortHash,
+				RemoteChangedAt: &changedAt,
+				TriggeredHash:   shortHash,
+			},
+			lastHash:   &short,
+			head:       nextHash,
+			wantDeploy: []string{nextHash},
+		},
+		{
+			name:       "last deployment without a commit",
+			head:       fullHash,
+			wantDeploy: []string{fullHash},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.state.ApplicationID = 1
+			states := &fakeStates{state: tt.state}
+			apps := &fakeApps{app: &domain.Application{
+				ID:                     1,
+				ServerID:               serverID,
+				Branch:                 "main",
+				GitPollIntervalSeconds: 60,
+			}}
+			deployments := &fakeDeployments{last: &domain.Deployment{
+				ID:            7,
+				ApplicationID: 1,
+				Status:        domain.DeploymentSuccess,
+				CommitHash:    tt.lastHash,
+			}}
+
+			svc := NewService(states, apps, nil, nil, deployments, nopLogger{})
+			err := svc.HandleHeads(context.Background(), serverID, []domain.GitRemoteHead{
+				{ApplicationID: 1, CommitHash: tt.head},
+			})
+			if err != nil {
+				t.Fatalf("HandleHeads: %v", err)
+			}
+
+			if len(apps.deployed) != len(tt.wantDeploy) || (len(tt.wantDeploy) > 0 && apps.deployed[0] != tt.wantDeploy[0]) {
+				t.Errorf("deployed %v, want %v", apps.deployed, tt.wantDeploy)
+			}
+			if states.state.RemoteHash != tt.head {
+				t.Errorf("remote hash = %q, want %q", states.state.RemoteHash, tt.head)
+			}
+			if tt.state.RemoteChangedAt != nil && tt.wantDeploy == nil && !states.state.RemoteChangedAt.Equal(changedAt) {
+				t.Errorf("remote changed at moved to %s for the same commit", states.state.RemoteChangedAt)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package job
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"horizonx/internal/domain"
+)
+
+// SecretResolver fills in the credentials and env var values a stored job
+// payload only references. The resolved payload is handed to the agent and
+// never written back.
+type SecretResolver struct {
+	appRepo     domain.ApplicationRepository
+	gitCredSvc  domain.GitCredentialService
+	registrySvc domain.RegistryCredentialService
+}
+
+func NewSecretResolver(
+	appRepo domain.ApplicationRepository,
+	gitCredSvc domain.GitCredentialService,
+	registrySvc domain.RegistryCredentialService,
+) domain.JobSecretResolver {
+	return &SecretResolver{
+		appRepo:     appRepo,
+		gitCredSvc:  gitCredSvc,
+		registrySvc: registrySvc,
+	}
+}
+
+func (r *SecretResolver) Resolve(ctx context.Context, job *domain.Job) error {
+	switch job.Type {
+	case domain.JobTypeAppDeploy:
+		return r.resolveDeploy(ctx, job)
+	case domain.JobTypeAppGitPoll:
+		return r.resolveGitPoll(ctx, job)
+	}
+	return nil
+}
+
+func (r *SecretResolver) resolveDeploy(ctx context.Context, job *domain.Job) error {
+	var payload domain.DeployAppPayload
+	if err := json.Unmarshal(job.Payload, &payload); err != nil {
+		return fmt.Errorf("invalid deploy payload: %w", err)
+	}
+
+	if len(payload.EnvKeys) > 0 {
+		values, err := r.appRepo.ListEnvVarValues(ctx, payload.ApplicationID)
+		if err != nil {
+			return fmt.Errorf("failed to fetch env vars: %w", err)
+		}
+
+		// Only the keys the deploy was queued with, a var added since then
+		// waits for the next deploy.
+		payload.EnvVars = make(map[string]string, len(payload.EnvKeys))
+		for _, key := range payload.EnvKeys {
+			if value, ok := values[key]; ok {
+				payload.EnvVars[key] = value
+			}
+		}
+	}
+
+	if payload.GitCredentialID != nil {
+		auth, err := r.gitCredSvc.Resolve(ctx, *payload.GitCredentialID)
+		if err != nil {
+			return fmt.Errorf("failed to resolve git credential: %w", err)
+		}
+		payload.GitAuth = auth
+	}
+
+	if payload.RegistryCredentialID != nil {
+		auth, err := r.registrySvc.Resolve(ctx, *payload.RegistryCredentialID)
+		if err != nil {
+			return fmt.Errorf("failed to resolve registry credential: %w", err)
+		}
+		payload.RegistryAuth = auth
+	}
+
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	job.Payload = raw
+
+	return nil
+}
+
+func (r *SecretResolver) resolveGitPoll(ctx context.Context, job *domain.Job) error {
+	var payload domain.AppGitPollPayload
+	if err := json.Unmarshal(job.Payload, &payload); err != nil {
+		return fmt.Errorf("invalid git poll payload: %w", err)
+	}
+
+	for i, target := range payload.Targets {
+		if target.GitCredentialID == nil {
+			continue
+		}
+
+		auth, err := r.gitCredSvc.Resolve(ctx, *target.GitCredentialID)
+		if err != nil {
+			return fmt.Errorf("application %d: failed to resolve git credential: %w", target.ApplicationID, err)
+		}
+		payload.Targets[i].GitAuth = auth
+	}
+
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	job.Payload = raw
+
+	return nil
+}
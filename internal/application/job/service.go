@@ -3,6 +3,9 @@ package job
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"time"
 
 	"horizonx/internal/domain"
 	"horizonx/internal/event"
@@ -11,16 +14,18 @@ import (
 )
 
 type JobService struct {
-	repo   domain.JobRepository
-	logSvc domain.LogService
-	bus    *event.Bus
+	repo    domain.JobRepository
+	logSvc  domain.LogService
+	secrets domain.JobSecretResolver
+	bus     *event.Bus
 }
 
-func NewService(repo domain.JobRepository, logSvc domain.LogService, events *event.Bus) domain.JobService {
+func NewService(repo domain.JobRepository, logSvc domain.LogService, secrets domain.JobSecretResolver, events *event.Bus) domain.JobService {
 	return &JobService{
-		repo:   repo,
-		logSvc: logSvc,
-		bus:    events,
+		repo:    repo,
+		logSvc:  logSvc,
+		secrets: secrets,
+		bus:     events,
 	}
 }
 
@@ -55,10 +60,6 @@ func (s *JobService) List(ctx context.Context, opts domain.JobListOptions) (*dom
 	return res, nil
 }
 
-func (s *JobService) GetPending(ctx context.Context, serverID uuid.UUID) ([]*domain.Job, error) {
-	return s.repo.GetPending(ctx, serverID)
-}
-
 func (s *JobService) GetByID(ctx context.Context, jobID int64) (*domain.Job, error) {
 	job, err := s.repo.GetByID(ctx, jobID)
 	if err != nil {
@@ -86,42 +87,171 @@ func (s *JobService) GetByID(ctx context.Context, jobID int64) (*domain.Job, err
 }
 
 func (s *JobService) Create(ctx context.Context, j *domain.Job) (*domain.Job, error) {
-	job, err := s.repo.Create(ctx, j)
+	job, err := s.repo.Create(ctx, withDefaults(j))
 	if err != nil {
 		return nil, err
 	}
 
-	if s.bus != nil {
-		s.bus.Publish("job_created", domain.EventJobCreated{
-			JobID:         job.ID,
-			TraceID:       job.TraceID,
-			ServerID:      job.ServerID,
-			ApplicationID: job.ApplicationID,
-			DeploymentID:  job.DeploymentID,
-			Type:          job.Type,
-		})
+	s.publishCreated(job)
 
-		s.bus.Publish("job_status_changed", domain.EventJobStatusChanged{
-			JobID:   job.ID,
-			TraceID: job.TraceID,
-			Status:  job.Status,
-		})
+	return job, nil
+}
+
+func (s *JobService) CreateUnlessDeploying(ctx context.Context, j *domain.Job) (*domain.Job, error) {
+	job, err := s.repo.CreateUnlessDeploying(ctx, withDefaults(j))
+	if err != nil {
+		return nil, err
 	}
 
+	s.publishCreated(job)
+
 	return job, nil
 }
 
+// CreateDeploy cancels the deploy jobs queued for the application of j. They
+// never reached an agent and left the application untouched, so only their
+// status change is published.
+func (s *JobService) CreateDeploy(ctx context.Context, j *domain.Job) (*domain.Job, []*domain.Job, error) {
+	job, superseded, err := s.repo.CreateDeploy(ctx, withDefaults(j))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	s.publishCreated(job)
+
+	for _, sj := range superseded {
+		if _, err := s.logSvc.Create(ctx, &domain.Log{
+			Timestamp:     time.Now().UTC(),
+			Level:         domain.LogWarn,
+			Source:        domain.LogServer,
+			Action:        logActionFor(sj.Type),
+			TraceID:       sj.TraceID,
+			JobID:         &sj.ID,
+			ServerID:      &sj.ServerID,
+			ApplicationID: sj.ApplicationID,
+			DeploymentID:  sj.DeploymentID,
+			Message:       fmt.Sprintf("job superseded by job #%d", job.ID),
+			Context: &domain.LogContext{
+				Status: string(sj.Status),
+			},
+		}); err != nil {
+			return nil, nil, err
+		}
+
+		if s.bus != nil {
+			s.bus.Publish("job_status_changed", domain.EventJobStatusChanged{
+				JobID:   sj.ID,
+				TraceID: sj.TraceID,
+				Status:  sj.Status,
+			})
+		}
+	}
+
+	return job, superseded, nil
+}
+
+func withDefaults(j *domain.Job) *domain.Job {
+	if j.Attempt <= 0 {
+		j.Attempt = 1
+	}
+	if j.MaxAttempts <= 0 {
+		j.MaxAttempts = j.Type.RetryPolicy().MaxAttempts
+	}
+	if j.TimeoutSeconds <= 0 {
+		j.TimeoutSeconds = int(j.Type.DefaultTimeout().Seconds())
+	}
+
+	return j
+}
+
+func (s *JobService) publishCreated(job *domain.Job) {
+	if s.bus == nil {
+		return
+	}
+
+	s.bus.Publish("job_created", domain.EventJobCreated{
+		JobID:         job.ID,
+		TraceID:       job.TraceID,
+		ServerID:      job.ServerID,
+		ApplicationID: job.ApplicationID,
+		DeploymentID:  job.DeploymentID,
+		Type:          job.Type,
+	})
+
+	s.bus.Publish("job_status_changed", domain.EventJobStatusChanged{
+		JobID:   job.ID,
+		TraceID: job.TraceID,
+		Status:  job.Status,
+	})
+
+	// Delayed retries are picked up by the agent's regular poll once due.
+	if job.NextRunAt == nil || !job.NextRunAt.After(time.Now()) {
+		s.bus.Publish("job_queued", domain.EventJobQueued{
+			JobID:    job.ID,
+			TraceID:  job.TraceID,
+			ServerID: job.ServerID,
+			Type:     job.Type,
+		})
+	}
+}
+
 func (s *JobService) Delete(ctx context.Context, jobID int64) error {
 	return s.repo.Delete(ctx, jobID)
 }
 
-func (s *JobService) Retry(ctx context.Context, jobID int64, j *domain.Job) (*domain.Job, error) {
-	job, err := s.repo.Retry(ctx, jobID, j)
+func (s *JobService) ListAttempts(ctx context.Context, jobID int64) ([]*domain.Job, error) {
+	job, err := s.repo.GetByID(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	rootID := job.ID
+	if job.RetryOfID != nil {
+		rootID = *job.RetryOfID
+	}
+
+	return s.repo.ListAttempts(ctx, rootID)
+}
+
+func (s *JobService) Prune(ctx context.Context, jobType domain.JobType, before time.Time) (int64, error) {
+	return s.repo.DeleteFinishedBefore(ctx, jobType, before)
+}
+
+func (s *JobService) Finish(ctx context.Context, jobID int64, serverID uuid.UUID, status domain.JobStatus) (*domain.Job, error) {
+	job, changed, err := s.repo.MarkFinished(ctx, jobID, serverID, status)
+	if err != nil {
+		return nil, err
+	}
+
+	// The job was already finished, either elsewhere, e.g. cancelled by a
+	// user while the agent was still running it, or by an earlier finish.
+	if !changed {
+		return job, nil
+	}
+
+	willRetry, err := s.shouldRetry(ctx, job, status)
 	if err != nil {
 		return nil, err
 	}
+	if willRetry {
+		if _, err := s.scheduleRetry(ctx, job); err != nil {
+			return nil, err
+		}
+	}
 
 	if s.bus != nil {
+		s.bus.Publish("job_finished", domain.EventJobFinished{
+			JobID:         job.ID,
+			TraceID:       job.TraceID,
+			ServerID:      job.ServerID,
+			ApplicationID: job.ApplicationID,
+			DeploymentID:  job.DeploymentID,
+			Type:          job.Type,
+			Status:        status,
+			Attempt:       job.Attempt,
+			WillRetry:     willRetry,
+		})
+
 		s.bus.Publish("job_status_changed", domain.EventJobStatusChanged{
 			JobID:   job.ID,
 			TraceID: job.TraceID,
@@ -129,23 +259,53 @@ func (s *JobService) Retry(ctx context.Context, jobID int64, j *domain.Job) (*do
 		})
 	}
 
-	return job, nil
+	return job, err
 }
 
-func (s *JobService) Start(ctx context.Context, jobID int64) (*domain.Job, error) {
-	job, err := s.repo.MarkRunning(ctx, jobID)
+func (s *JobService) Cancel(ctx context.Context, jobID int64, cancelledBy int64) (*domain.Job, error) {
+	job, err := s.repo.MarkCancelled(ctx, jobID)
 	if err != nil {
 		return nil, err
 	}
 
+	if _, err := s.logSvc.Create(ctx, &domain.Log{
+		Timestamp:     time.Now().UTC(),
+		Level:         domain.LogWarn,
+		Source:        domain.LogServer,
+		Action:        logActionFor(job.Type),
+		TraceID:       job.TraceID,
+		JobID:         &job.ID,
+		ServerID:      &job.ServerID,
+		ApplicationID: job.ApplicationID,
+		DeploymentID:  job.DeploymentID,
+		Message:       fmt.Sprintf("job cancelled by user #%d", cancelledBy),
+		Context: &domain.LogContext{
+			Status: string(domain.JobCancelled),
+		},
+	}); err != nil {
+		return nil, err
+	}
+
 	if s.bus != nil {
-		s.bus.Publish("job_started", domain.EventJobStarted{
+		s.bus.Publish("job_cancelled", domain.EventJobCancelled{
+			JobID:         job.ID,
+			TraceID:       job.TraceID,
+			ServerID:      job.ServerID,
+			ApplicationID: job.ApplicationID,
+			DeploymentID:  job.DeploymentID,
+			Type:          job.Type,
+			CancelledBy:   cancelledBy,
+		})
+
+		s.bus.Publish("job_finished", domain.EventJobFinished{
 			JobID:         job.ID,
 			TraceID:       job.TraceID,
 			ServerID:      job.ServerID,
 			ApplicationID: job.ApplicationID,
 			DeploymentID:  job.DeploymentID,
 			Type:          job.Type,
+			Status:        job.Status,
+			Attempt:       job.Attempt,
 		})
 
 		s.bus.Publish("job_status_changed", domain.EventJobStatusChanged{
@@ -158,22 +318,164 @@ func (s *JobService) Start(ctx context.Context, jobID int64) (*domain.Job, error
 	return job, nil
 }
 
-func (s *JobService) Finish(ctx context.Context, jobID int64, status domain.JobStatus) (*domain.Job, error) {
-	job, err := s.repo.MarkFinished(ctx, jobID, status)
+func (s *JobService) Claim(ctx context.Context, serverID uuid.UUID, limit int, lightLimit int) ([]*domain.Job, error) {
+	limit = min(max(limit, 0), domain.JobClaimLimit)
+	lightLimit = min(max(lightLimit, 0), domain.JobClaimLimit)
+	if limit == 0 && lightLimit == 0 {
+		return nil, nil
+	}
+
+	jobs, err := s.repo.Claim(ctx, serverID, limit, lightLimit, domain.JobLeaseDuration)
 	if err != nil {
 		return nil, err
 	}
 
 	if s.bus != nil {
-		s.bus.Publish("job_finished", domain.EventJobFinished{
-			JobID:         job.ID,
+		for _, job := range jobs {
+			s.bus.Publish("job_started", domain.EventJobStarted{
+				JobID:         job.ID,
+				TraceID:       job.TraceID,
+				ServerID:      job.ServerID,
+				ApplicationID: job.ApplicationID,
+				DeploymentID:  job.DeploymentID,
+				Type:          job.Type,
+			})
+
+			s.bus.Publish("job_status_changed", domain.EventJobStatusChanged{
+				JobID:   job.ID,
+				TraceID: job.TraceID,
+				Status:  job.Status,
+			})
+		}
+	}
+
+	// Payloads only reference secrets, they are resolved for the claiming
+	// agent here. A job whose secrets are gone fails instead of running
+	// without them.
+	claimed := jobs[:0]
+	for _, job := range jobs {
+		if err := s.secrets.Resolve(ctx, job); err != nil {
+			if err := s.failUnresolved(ctx, job, err); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		claimed = append(claimed, job)
+	}
+
+	return claimed, nil
+}
+
+func (s *JobService) failUnresolved(ctx context.Context, job *domain.Job, cause error) error {
+	if _, err := s.logSvc.Create(ctx, &domain.Log{
+		Timestamp:     time.Now().UTC(),
+		Level:         domain.LogError,
+		Source:        domain.LogServer,
+		Action:        logActionFor(job.Type),
+		TraceID:       job.TraceID,
+		JobID:         &job.ID,
+		ServerID:      &job.ServerID,
+		ApplicationID: job.ApplicationID,
+		DeploymentID:  job.DeploymentID,
+		Message:       fmt.Sprintf("failed to resolve job secrets, %s", cause.Error()),
+		Context: &domain.LogContext{
+			Status: string(domain.JobFailed),
+		},
+	}); err != nil {
+		return err
+	}
+
+	_, err := s.Finish(ctx, job.ID, job.ServerID, domain.JobFailed)
+	return err
+}
+
+func (s *JobService) Heartbeat(ctx context.Context, jobID int64, serverID uuid.UUID) (*domain.Job, error) {
+	return s.repo.Heartbeat(ctx, jobID, serverID, domain.JobLeaseDuration)
+}
+
+// ReapExpiredLeases takes back running jobs whose agent stopped
+// heartbeating. Idempotent job types are queued again, everything else is
+// marked expired so a half-finished deploy is never replayed blindly.
+func (s *JobService) ReapExpiredLeases(ctx context.Context) (int, error) {
+	jobs, err := s.repo.ListExpiredLeases(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	reaped := 0
+	for _, j := range jobs {
+		requeue := j.Type.RequeueOnLeaseExpiry()
+
+		job, err := s.repo.ReleaseLease(ctx, j.ID, requeue)
+		if errors.Is(err, domain.ErrInvalidJobState) {
+			// heartbeat or finish raced the reaper
+			continue
+		}
+		if err != nil {
+			return reaped, err
+		}
+		reaped++
+
+		message := "job lease expired, agent stopped sending heartbeats"
+		if requeue {
+			message = "job lease expired, job queued again"
+		}
+
+		if _, err := s.logSvc.Create(ctx, &domain.Log{
+			Timestamp:     time.Now().UTC(),
+			Level:         domain.LogWarn,
+			Source:        domain.LogServer,
+			Action:        logActionFor(job.Type),
 			TraceID:       job.TraceID,
-			ServerID:      job.ServerID,
+			JobID:         &job.ID,
+			ServerID:      &job.ServerID,
 			ApplicationID: job.ApplicationID,
 			DeploymentID:  job.DeploymentID,
-			Type:          job.Type,
-			Status:        status,
-		})
+			Message:       message,
+			Context: &domain.LogContext{
+				Status: string(job.Status),
+			},
+		}); err != nil {
+			return reaped, err
+		}
+
+		willRetry := false
+		if !requeue {
+			willRetry, err = s.shouldRetry(ctx, job, job.Status)
+			if err != nil {
+				return reaped, err
+			}
+		}
+		if willRetry {
+			if _, err := s.scheduleRetry(ctx, job); err != nil {
+				return reaped, err
+			}
+		}
+
+		if s.bus == nil {
+			continue
+		}
+
+		if requeue {
+			s.bus.Publish("job_queued", domain.EventJobQueued{
+				JobID:    job.ID,
+				TraceID:  job.TraceID,
+				ServerID: job.ServerID,
+				Type:     job.Type,
+			})
+		} else {
+			s.bus.Publish("job_finished", domain.EventJobFinished{
+				JobID:         job.ID,
+				TraceID:       job.TraceID,
+				ServerID:      job.ServerID,
+				ApplicationID: job.ApplicationID,
+				DeploymentID:  job.DeploymentID,
+				Type:          job.Type,
+				Status:        job.Status,
+				Attempt:       job.Attempt,
+				WillRetry:     willRetry,
+			})
+		}
 
 		s.bus.Publish("job_status_changed", domain.EventJobStatusChanged{
 			JobID:   job.ID,
@@ -182,5 +484,102 @@ func (s *JobService) Finish(ctx context.Context, jobID int64, status domain.JobS
 		})
 	}
 
-	return job, err
+	return reaped, nil
+}
+
+// shouldRetry reports whether a finished attempt is followed by another
+// one. A failed deploy is not retried once a newer deploy of the application
+// is queued, the retry would run after it and roll it back.
+func (s *JobService) shouldRetry(ctx context.Context, job *domain.Job, status domain.JobStatus) (bool, error) {
+	if !job.CanRetry(status) {
+		return false, nil
+	}
+
+	if job.Type != domain.JobTypeAppDeploy || job.ApplicationID == nil {
+		return true, nil
+	}
+
+	queued, err := s.List(ctx, domain.JobListOptions{
+		ApplicationID: job.ApplicationID,
+		Type:          string(domain.JobTypeAppDeploy),
+		Statuses:      []string{string(domain.JobQueued)},
+	})
+	if err != nil {
+		return false, err
+	}
+
+	for _, j := range queued.Data {
+		if j.ID > job.ID {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// scheduleRetry queues the attempt following the given failed one, delayed
+// by the job type's backoff. Every attempt is its own row so its logs and
+// outcome stay visible in the history.
+func (s *JobService) scheduleRetry(ctx context.Context, job *domain.Job) (*domain.Job, error) {
+	delay := job.Type.RetryPolicy().Backoff(job.Attempt)
+	nextRunAt := time.Now().UTC().Add(delay)
+
+	rootID := job.ID
+	if job.RetryOfID != nil {
+		rootID = *job.RetryOfID
+	}
+
+	if _, err := s.logSvc.Create(ctx, &domain.Log{
+		Timestamp:     time.Now().UTC(),
+		Level:         domain.LogWarn,
+		Source:        domain.LogServer,
+		Action:        logActionFor(job.Type),
+		TraceID:       job.TraceID,
+		JobID:         &job.ID,
+		ServerID:      &job.ServerID,
+		ApplicationID: job.ApplicationID,
+		DeploymentID:  job.DeploymentID,
+		Message:       fmt.Sprintf("attempt %d of %d %s, retrying in %s", job.Attempt, job.MaxAttempts, job.Status, delay),
+		Context: &domain.LogContext{
+			Status: string(job.Status),
+		},
+	}); err != nil {
+		return nil, err
+	}
+
+	return s.Create(ctx, &domain.Job{
+		TraceID:       job.TraceID,
+		ServerID:      job.ServerID,
+		ApplicationID: job.ApplicationID,
+		DeploymentID:  job.DeploymentID,
+		Type:          job.Type,
+		Payload:       job.Payload,
+		Attempt:       job.Attempt + 1,
+		MaxAttempts:   job.MaxAttempts,
+		NextRunAt:     &nextRunAt,
+		RetryOfID:     &rootID,
+
+		TimeoutSeconds: job.TimeoutSeconds,
+	})
+}
+
+func logActionFor(jobType domain.JobType) domain.LogAction {
+	switch jobType {
+	case domain.JobTypeAppDeploy:
+		return domain.ActionAppDeploy
+	case domain.JobTypeAppStart:
+		return domain.ActionAppStart
+	case domain.JobTypeAppStop:
+		return domain.ActionAppStop
+	case domain.JobTypeAppRestart:
+		return domain.ActionAppRestart
+	case domain.JobTypeAppCommand:
+		return domain.ActionAppCommand
+	case domain.JobTypeAppHealthCheck:
+		return domain.ActionAppHealthCheck
+	case domain.JobTypeAppGitPoll:
+		return domain.ActionAppGitPoll
+	default:
+		return domain.LogAction(jobType)
+	}
 }
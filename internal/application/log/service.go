@@ -63,3 +63,17 @@ func (s *LogService) Create(ctx context.Context, l *domain.Log) (*domain.Log, er
 
 	return log, nil
 }
+
+func (s *LogService) CreateBatch(ctx context.Context, logs []*domain.Log) error {
+	if err := s.repo.CreateBatch(ctx, logs); err != nil {
+		return err
+	}
+
+	if s.bus != nil {
+		for _, l := range logs {
+			s.bus.Publish("log_received", l)
+		}
+	}
+
+	return nil
+}
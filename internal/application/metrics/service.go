@@ -31,6 +31,14 @@ type Service struct {
 	cpuUsageMu sync.RWMutex
 	netSpeedMu sync.RWMutex
 
+	// Application metrics are derived from the container metrics of each
+	// sample and share the server history retention.
+	appLatest      map[int64]domain.ApplicationMetrics
+	appCPUUsage    map[int64][]domain.CPUUsageSample
+	appMemoryUsage map[int64][]domain.MemoryUsageSample
+	appNetSpeed    map[int64][]domain.NetworkSpeedSample
+	appMu          sync.RWMutex
+
 	cpuUsageHistoryRetention time.Duration
 	netSpeedHistoryRetention time.Duration
 
@@ -52,6 +60,11 @@ func NewService(repo domain.MetricsRepository, bus *event.Bus, log logger.Logger
 		cpuUsageHistory: make(map[uuid.UUID][]domain.CPUUsageSample),
 		netSpeedHistory: make(map[uuid.UUID][]domain.NetworkSpeedSample),
 
+		appLatest:      make(map[int64]domain.ApplicationMetrics),
+		appCPUUsage:    make(map[int64][]domain.CPUUsageSample),
+		appMemoryUsage: make(map[int64][]domain.MemoryUsageSample),
+		appNetSpeed:    make(map[int64][]domain.NetworkSpeedSample),
+
 		cpuUsageHistoryRetention: 15 * time.Minute,
 		netSpeedHistoryRetention: 15 * time.Minute,
 
@@ -74,6 +87,7 @@ func (s *Service) Ingest(m domain.Metrics) error {
 	s.updateLatest(m)
 	s.recordCPUUsage(sid, m.CPU.Usage.EMA, at)
 	s.recordNetSpeed(sid, m.Network.RXSpeedMBs.EMA, m.Network.TXSpeedMBs.EMA, at)
+	s.recordAppMetrics(sid, m.Containers, at)
 
 	s.bufferMu.Lock()
 	s.buffer = append(s.buffer, m)
@@ -126,6 +140,54 @@ func (s *Service) NetSpeedHistory(serverID uuid.UUID) ([]domain.NetworkSpeedSamp
 	return speeds, nil
 }
 
+func (s *Service) AppLatest(appID int64) (*domain.ApplicationMetrics, error) {
+	s.appMu.RLock()
+	defer s.appMu.RUnlock()
+
+	metrics, ok := s.appLatest[appID]
+	if !ok {
+		return nil, domain.ErrMetricsNotFound
+	}
+
+	return &metrics, nil
+}
+
+func (s *Service) AppCPUUsageHistory(appID int64) ([]domain.CPUUsageSample, error) {
+	s.appMu.RLock()
+	defer s.appMu.RUnlock()
+
+	usages, ok := s.appCPUUsage[appID]
+	if !ok {
+		return []domain.CPUUsageSample{}, domain.ErrMetricsNotFound
+	}
+
+	return usages, nil
+}
+
+func (s *Service) AppMemoryUsageHistory(appID int64) ([]domain.MemoryUsageSample, error) {
+	s.appMu.RLock()
+	defer s.appMu.RUnlock()
+
+	usages, ok := s.appMemoryUsage[appID]
+	if !ok {
+		return []domain.MemoryUsageSample{}, domain.ErrMetricsNotFound
+	}
+
+	return usages, nil
+}
+
+func (s *Service) AppNetSpeedHistory(appID int64) ([]domain.NetworkSpeedSample, error) {
+	s.appMu.RLock()
+	defer s.appMu.RUnlock()
+
+	speeds, ok := s.appNetSpeed[appID]
+	if !ok {
+		return []domain.NetworkSpeedSample{}, domain.ErrMetricsNotFound
+	}
+
+	return speeds, nil
+}
+
 func (s *Service) Cleanup(ctx context.Context, serverID uuid.UUID, cutoff time.Time) error {
 	return s.repo.Cleanup(ctx, serverID, cutoff)
 }
@@ -177,6 +239,83 @@ func (s *Service) recordNetSpeed(serverID uuid.UUID, rxMBs float64, txMBs float6
 	s.netSpeedMu.Unlock()
 }
 
+// recordAppMetrics groups the containers of a sample per application. The
+// latest entry of an application whose containers are gone is dropped, its
+// history once it ages out.
+func (s *Service) recordAppMetrics(serverID uuid.UUID, containers []domain.ContainerMetric, at time.Time) {
+	byApp := make(map[int64][]domain.ContainerMetric)
+	for _, c := range containers {
+		byApp[c.ApplicationID] = append(byApp[c.ApplicationID], c)
+	}
+
+	s.appMu.Lock()
+	defer s.appMu.Unlock()
+
+	for appID, latest := range s.appLatest {
+		if _, ok := byApp[appID]; !ok && latest.ServerID == serverID {
+			delete(s.appLatest, appID)
+		}
+	}
+
+	cutoff := at.Add(-s.cpuUsageHistoryRetention)
+
+	for appID, points := range s.appCPUUsage {
+		if len(points) == 0 || !points[len(points)-1].At.After(cutoff) {
+			delete(s.appCPUUsage, appID)
+			delete(s.appMemoryUsage, appID)
+			delete(s.appNetSpeed, appID)
+		}
+	}
+
+	for appID, appContainers := range byApp {
+		s.appLatest[appID] = domain.ApplicationMetrics{
+			ApplicationID: appID,
+			ServerID:      serverID,
+			Containers:    appContainers,
+			RecordedAt:    at,
+		}
+
+		var (
+			cpu    float64
+			memory uint64
+			rx, tx float64
+		)
+		for _, c := range appContainers {
+			cpu += c.CPUPercent
+			memory += c.MemoryBytes
+			rx += c.RXSpeedMBs
+			tx += c.TXSpeedMBs
+		}
+
+		s.appCPUUsage[appID] = trimSamples(append(s.appCPUUsage[appID], domain.CPUUsageSample{
+			UsagePercent: cpu,
+			At:           at,
+		}), cutoff, func(p domain.CPUUsageSample) time.Time { return p.At })
+
+		s.appMemoryUsage[appID] = trimSamples(append(s.appMemoryUsage[appID], domain.MemoryUsageSample{
+			UsedBytes: memory,
+			At:        at,
+		}), cutoff, func(p domain.MemoryUsageSample) time.Time { return p.At })
+
+		s.appNetSpeed[appID] = trimSamples(append(s.appNetSpeed[appID], domain.NetworkSpeedSample{
+			RXMBs: rx,
+			TXMBs: tx,
+			At:    at,
+		}), cutoff, func(p domain.NetworkSpeedSample) time.Time { return p.At })
+	}
+}
+
+func trimSamples[T any](points []T, cutoff time.Time, at func(T) time.Time) []T {
+	i := 0
+	for ; i < len(points); i++ {
+		if at(points[i]).After(cutoff) {
+			break
+		}
+	}
+
+	return points[i:]
+}
+
 func (s *Service) backgroundFlusher() {
 	ticker := time.NewTicker(s.flushInterval)
 	defer ticker.Stop()
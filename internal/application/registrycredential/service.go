@@ -0,0 +1,122 @@
+// Package registrycredential
+package registrycredential
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"horizonx/internal/domain"
+	"horizonx/internal/secret"
+)
+
+type Service struct {
+	repo domain.RegistryCredentialRepository
+	box  *secret.Box
+}
+
+// NewService takes a nil box when no secret key is configured, credentials
+// can then be listed but not created or used.
+func NewService(repo domain.RegistryCredentialRepository, box *secret.Box) domain.RegistryCredentialService {
+	return &Service{
+		repo: repo,
+		box:  box,
+	}
+}
+
+func (s *Service) List(ctx context.Context) ([]*domain.RegistryCredential, error) {
+	return s.repo.List(ctx)
+}
+
+func (s *Service) GetByID(ctx context.Context, credentialID int64) (*domain.RegistryCredential, error) {
+	return s.repo.GetByID(ctx, credentialID)
+}
+
+func (s *Service) Create(ctx context.Context, req domain.RegistryCredentialCreateRequest, createdBy int64) (*domain.RegistryCredential, error) {
+	c := &domain.RegistryCredential{
+		Name:      req.Name,
+		Registry:  strings.ToLower(req.Registry),
+		Username:  req.Username,
+		CreatedBy: &createdBy,
+	}
+
+	id, err := s.repo.NextID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c.ID = id
+
+	if err := s.setPassword(c, req.Password); err != nil {
+		return nil, err
+	}
+
+	return s.repo.Create(ctx, c)
+}
+
+func (s *Service) Update(ctx context.Context, credentialID int64, req domain.RegistryCredentialUpdateRequest) (*domain.RegistryCredential, error) {
+	c, err := s.repo.GetByID(ctx, credentialID)
+	if err != nil {
+		return nil, err
+	}
+
+	c.Name = req.Name
+	c.Registry = strings.ToLower(req.Registry)
+	c.Username = req.Username
+
+	if req.Password != "" {
+		if err := s.setPassword(c, req.Password); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.repo.Update(ctx, c); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+func (s *Service) Delete(ctx context.Context, credentialID int64) error {
+	return s.repo.Delete(ctx, credentialID)
+}
+
+func (s *Service) Resolve(ctx context.Context, credentialID int64) (*domain.RegistryAuth, error) {
+	if s.box == nil {
+		return nil, domain.ErrSecretsDisabled
+	}
+
+	c, err := s.repo.GetByID(ctx, credentialID)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := s.box.Open(c.EncryptedPassword, passwordAAD(c.ID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt registry credential %d: %w", c.ID, err)
+	}
+
+	return &domain.RegistryAuth{
+		Registry: c.Registry,
+		Username: c.Username,
+		Password: string(plaintext),
+	}, nil
+}
+
+func (s *Service) setPassword(c *domain.RegistryCredential, value string) error {
+	if s.box == nil {
+		return domain.ErrSecretsDisabled
+	}
+
+	sealed, err := s.box.Seal([]byte(strings.TrimSpace(value)), passwordAAD(c.ID))
+	if err != nil {
+		return err
+	}
+	c.EncryptedPassword = sealed
+
+	return nil
+}
+
+// passwordAAD binds a sealed password to its credential.
+func passwordAAD(credentialID int64) []byte {
+	return secret.AAD("registry_credentials", "encrypted_password", credentialID)
+}
@@ -24,6 +24,8 @@ var roleHasPermissions = map[domain.RoleConst]map[domain.PermissionConst]bool{
 		domain.PermMemberWrite: true,
 		domain.PermAppRead:     true,
 		domain.PermAppWrite:    true,
+
+		domain.PermAppSecretsRead: true,
 	},
 	domain.RoleViewer: {
 		domain.PermMetricsRead: true,
@@ -0,0 +1,199 @@
+// Package schedule
+package schedule
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"horizonx/internal/cron"
+	"horizonx/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+type Service struct {
+	repo   domain.ScheduleRepository
+	appSvc domain.ApplicationService
+	loc    *time.Location
+}
+
+func NewService(repo domain.ScheduleRepository, appSvc domain.ApplicationService, loc *time.Location) domain.ScheduleService {
+	return &Service{
+		repo:   repo,
+		appSvc: appSvc,
+		loc:    loc,
+	}
+}
+
+func (s *Service) List(ctx context.Context, appID int64) ([]*domain.Schedule, error) {
+	if _, err := s.appSvc.GetByID(ctx, appID); err != nil {
+		return nil, err
+	}
+
+	return s.repo.List(ctx, appID)
+}
+
+func (s *Service) GetByID(ctx context.Context, appID int64, scheduleID int64) (*domain.Schedule, error) {
+	return s.repo.GetByID(ctx, appID, scheduleID)
+}
+
+func (s *Service) Create(ctx context.Context, appID int64, req domain.ScheduleRequest, createdBy int64) (*domain.Schedule, error) {
+	if _, err := s.appSvc.GetByID(ctx, appID); err != nil {
+		return nil, err
+	}
+
+	schedule := &domain.Schedule{
+		ApplicationID: appID,
+		CreatedBy:     &createdBy,
+	}
+	if err := s.apply(schedule, req); err != nil {
+		return nil, err
+	}
+
+	return s.repo.Create(ctx, schedule)
+}
+
+func (s *Service) Update(ctx context.Context, appID int64, scheduleID int64, req domain.ScheduleRequest) (*domain.Schedule, error) {
+	schedule, err := s.repo.GetByID(ctx, appID, scheduleID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.apply(schedule, req); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Update(ctx, schedule); err != nil {
+		return nil, err
+	}
+
+	return schedule, nil
+}
+
+func (s *Service) Delete(ctx context.Context, appID int64, scheduleID int64) error {
+	return s.repo.Delete(ctx, appID, scheduleID)
+}
+
+func (s *Service) RunDue(ctx context.Context) (int, error) {
+	now := time.Now().In(s.loc)
+
+	due, err := s.repo.ListDue(ctx, now, domain.ScheduleDueLimit)
+	if err != nil {
+		return 0, err
+	}
+
+	var (
+		ran  int
+		errs []error
+	)
+
+	for _, schedule := range due {
+		// Runs missed while the control plane was down collapse into this one.
+		// An expression that no longer parses stops the schedule.
+		var nextRunAt *time.Time
+		parsed, parseErr := parseCron(schedule.CronExpression)
+		if parseErr == nil {
+			next := parsed.Next(now)
+			nextRunAt = &next
+		}
+
+		claimed, err := s.repo.MarkRun(ctx, schedule, now, nextRunAt)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("schedule %d: %w", schedule.ID, err))
+			continue
+		}
+		if !claimed {
+			continue
+		}
+
+		traceID := uuid.New()
+		runErr := parseErr
+		if runErr == nil {
+			runErr = s.trigger(domain.WithTraceID(ctx, traceID), schedule)
+		}
+		if runErr != nil {
+			errs = append(errs, fmt.Errorf("schedule %d: %w", schedule.ID, runErr))
+		}
+
+		if err := s.repo.RecordResult(ctx, schedule.ID, traceID, runErr); err != nil {
+			errs = append(errs, fmt.Errorf("schedule %d: %w", schedule.ID, err))
+		}
+
+		ran++
+	}
+
+	return ran, errors.Join(errs...)
+}
+
+func (s *Service) trigger(ctx context.Context, schedule *domain.Schedule) error {
+	appID := schedule.ApplicationID
+
+	switch schedule.Action {
+	case domain.ScheduleActionDeploy:
+		if schedule.CreatedBy == nil {
+			return fmt.Errorf("schedule owner no longer exists, recreate the schedule to deploy")
+		}
+		_, err := s.appSvc.Deploy(ctx, appID, schedule.CreatedBy, domain.ApplicationDeployRequest{})
+		return err
+	case domain.ScheduleActionStart:
+		return s.appSvc.Start(ctx, appID)
+	case domain.ScheduleActionStop:
+		return s.appSvc.Stop(ctx, appID)
+	case domain.ScheduleActionRestart:
+		return s.appSvc.Restart(ctx, appID)
+	case domain.ScheduleActionCommand:
+		_, err := s.appSvc.RunCommand(ctx, appID, domain.ApplicationCommandRequest{
+			Service: schedule.Service,
+			Command: schedule.Command,
+		})
+		return err
+	default:
+		return fmt.Errorf("unknown schedule action: %s", schedule.Action)
+	}
+}
+
+// apply copies req onto schedule and works out its next run.
+func (s *Service) apply(schedule *domain.Schedule, req domain.ScheduleRequest) error {
+	parsed, err := parseCron(req.CronExpression)
+	if err != nil {
+		return err
+	}
+
+	schedule.Name = req.Name
+	schedule.CronExpression = strings.TrimSpace(req.CronExpression)
+	schedule.Action = req.Action
+	schedule.Service = ""
+	schedule.Command = ""
+	schedule.Enabled = req.Enabled == nil || *req.Enabled
+
+	if req.Action == domain.ScheduleActionCommand {
+		schedule.Service = req.Service
+		schedule.Command = req.Command
+	}
+
+	schedule.NextRunAt = nil
+	if schedule.Enabled {
+		next := parsed.Next(time.Now().In(s.loc))
+		schedule.NextRunAt = &next
+	}
+
+	return nil
+}
+
+// parseCron only accepts calendar expressions, intervals are reserved for
+// the built-in workers.
+func parseCron(expr string) (cron.Schedule, error) {
+	if strings.HasPrefix(strings.TrimSpace(expr), "@every") {
+		return nil, fmt.Errorf("%w: use a cron expression instead of an interval", domain.ErrInvalidSchedule)
+	}
+
+	parsed, err := cron.Parse(expr)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrInvalidSchedule, err)
+	}
+
+	return parsed, nil
+}
@@ -0,0 +1,149 @@
+// Package webhook
+package webhook
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"horizonx/internal/domain"
+	"horizonx/internal/gitwebhook"
+	"horizonx/internal/logger"
+	"horizonx/internal/secret"
+)
+
+// secretBytes is the entropy of a generated webhook secret, hex encoded it
+// is accepted by every provider.
+const secretBytes = 32
+
+type Service struct {
+	repo   domain.ApplicationRepository
+	appSvc domain.ApplicationService
+	box    *secret.Box
+	log    logger.Logger
+}
+
+// NewService takes a nil box when no secret key is configured, webhooks can
+// then neither be enabled nor verified.
+func NewService(
+	repo domain.ApplicationRepository,
+	appSvc domain.ApplicationService,
+	box *secret.Box,
+	log logger.Logger,
+) domain.GitWebhookService {
+	return &Service{
+		repo:   repo,
+		appSvc: appSvc,
+		box:    box,
+		log:    log,
+	}
+}
+
+func (s *Service) Enable(ctx context.Context, appID int64) (*domain.GitWebhookSecret, error) {
+	if s.box == nil {
+		return nil, domain.ErrSecretsDisabled
+	}
+
+	app, err := s.appSvc.GetByID(ctx, appID)
+	if err != nil {
+		return nil, err
+	}
+	if app.Source == domain.AppSourceImage {
+		return nil, fmt.Errorf("%w: an image application has no git repository", domain.ErrInvalidApplicationSource)
+	}
+
+	raw := make([]byte, secretBytes)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+	plaintext := hex.EncodeToString(raw)
+
+	encrypted, err := s.box.Seal([]byte(plaintext), secretAAD(appID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt webhook secret: %w", err)
+	}
+
+	if err := s.repo.SetWebhookSecret(ctx, appID, encrypted); err != nil {
+		return nil, err
+	}
+
+	return &domain.GitWebhookSecret{
+		Path:   domain.GitWebhookPath(appID),
+		Secret: plaintext,
+	}, nil
+}
+
+func (s *Service) Disable(ctx context.Context, appID int64) error {
+	return s.repo.SetWebhookSecret(ctx, appID, nil)
+}
+
+func (s *Service) Receive(ctx context.Context, appID int64, hook domain.GitWebhook) (*domain.GitWebhookResult, error) {
+	encrypted, err := s.repo.GetWebhookSecret(ctx, appID)
+	if err != nil {
+		return nil, err
+	}
+	if encrypted == nil {
+		return nil, domain.ErrWebhookDisabled
+	}
+	if s.box == nil {
+		return nil, domain.ErrSecretsDisabled
+	}
+
+	plaintext, err := s.box.Open(encrypted, secretAAD(appID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt webhook secret of application %d: %w", appID, err)
+	}
+
+	if err := gitwebhook.Verify(hook, string(plaintext)); err != nil {
+		s.log.Warn("webhook: rejected delivery", "app_id", appID, "provider", hook.Provider, "delivery_id", hook.DeliveryID)
+		return nil, err
+	}
+
+	push, err := gitwebhook.ParsePush(hook)
+	if err != nil {
+		if errors.Is(err, domain.ErrWebhookEventIgnored) {
+			return &domain.GitWebhookResult{Ignored: err.Error()}, nil
+		}
+		return nil, err
+	}
+
+	app, err := s.appSvc.GetByID(ctx, appID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case app.Source == domain.AppSourceImage:
+		return &domain.GitWebhookResult{Ignored: "application does not deploy from git"}, nil
+	case push.Branch == "":
+		return &domain.GitWebhookResult{Ignored: "not a branch push"}, nil
+	case push.Branch != app.Branch:
+		return &domain.GitWebhookResult{
+			Ignored: fmt.Sprintf("branch %s does not match %s", push.Branch, app.Branch),
+		}, nil
+	case push.CommitHash == "":
+		return &domain.GitWebhookResult{Ignored: "branch was deleted"}, nil
+	}
+
+	deployment, err := s.appSvc.Deploy(ctx, appID, nil, domain.ApplicationDeployRequest{Push: push})
+	if err != nil {
+		return nil, err
+	}
+
+	s.log.Info("webhook: deploying push",
+		"app_id", appID,
+		"provider", push.Provider,
+		"commit", push.CommitHash,
+		"pusher", push.Pusher,
+		"deployment_id", deployment.ID,
+	)
+
+	return &domain.GitWebhookResult{Deployment: deployment}, nil
+}
+
+// secretAAD binds a sealed webhook secret to its application.
+func secretAAD(appID int64) []byte {
+	return secret.AAD("applications", "webhook_secret", appID)
+}
@@ -3,6 +3,7 @@ package config
 
 import (
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
@@ -21,10 +22,31 @@ type Config struct {
 	JWTSecret      string
 	JWTExpiry      time.Duration
 
+	// SecretKey or the content of SecretKeyFile encrypts credentials stored
+	// by the control plane.
+	SecretKey     string
+	SecretKeyFile string
+	// SecretKeyPrevious or SecretKeyPreviousFile still decrypts what the
+	// key replaced by a rotation sealed, until everything is re-encrypted.
+	SecretKeyPrevious     string
+	SecretKeyPreviousFile string
+
 	AgentTargetAPIURL   string
 	AgentTargetWsURL    string
 	AgentServerAPIToken string
 	AgentServerID       uuid.UUID
+	AgentMaxJobs        int
+	AgentStateDir       string
+
+	WorkerSchedules map[string]WorkerSchedule
+}
+
+// WorkerSchedule overrides how a built-in worker is scheduled, keyed by the
+// worker name. Zero fields keep the worker's default.
+type WorkerSchedule struct {
+	Spec          string
+	Jitter        time.Duration
+	SkipIfRunning *bool
 }
 
 func Load() *Config {
@@ -67,6 +89,12 @@ func Load() *Config {
 		}
 	}
 
+	// Secret Key
+	secretKey := os.Getenv("SECRET_KEY")
+	secretKeyFile := os.Getenv("SECRET_KEY_FILE")
+	secretKeyPrevious := os.Getenv("SECRET_KEY_PREVIOUS")
+	secretKeyPreviousFile := os.Getenv("SECRET_KEY_PREVIOUS_FILE")
+
 	// AGENT Target URL
 	agentTargetAPIURL := getEnv("HORIZONX_API_URL", "http://localhost:3000")
 	agentTargetWsURL := getEnv("HORIZONX_WS_URL", "ws://localhost:3000/ws/agent")
@@ -80,6 +108,20 @@ func Load() *Config {
 		}
 	}
 
+	// AGENT Concurrent Jobs
+	agentMaxJobs := 4
+	if raw := os.Getenv("HORIZONX_MAX_CONCURRENT_JOBS"); raw != "" {
+		if n, err := strconv.Atoi(raw); err == nil && n > 0 {
+			agentMaxJobs = n
+		}
+	}
+
+	// AGENT State Directory
+	agentStateDir := getEnv("HORIZONX_STATE_DIR", "/var/horizonx/state")
+
+	// Worker Schedules
+	workerSchedules := loadWorkerSchedules()
+
 	return &Config{
 		LogLevel:  logLevel,
 		LogFormat: logFormat,
@@ -91,13 +133,64 @@ func Load() *Config {
 		JWTSecret:      jwtSecret,
 		JWTExpiry:      jwtExpiry,
 
+		SecretKey:             secretKey,
+		SecretKeyFile:         secretKeyFile,
+		SecretKeyPrevious:     secretKeyPrevious,
+		SecretKeyPreviousFile: secretKeyPreviousFile,
+
 		AgentTargetAPIURL:   agentTargetAPIURL,
 		AgentTargetWsURL:    agentTargetWsURL,
 		AgentServerAPIToken: agentServerAPIToken,
 		AgentServerID:       agentServerID,
+		AgentMaxJobs:        agentMaxJobs,
+		AgentStateDir:       agentStateDir,
+
+		WorkerSchedules: workerSchedules,
 	}
 }
 
+// loadWorkerSchedules reads WORKER_<NAME>_SCHEDULE, WORKER_<NAME>_JITTER and
+// WORKER_<NAME>_SKIP_IF_RUNNING, e.g. WORKER_METRICS_CLEANUP_SCHEDULE="0 3 * * *".
+func loadWorkerSchedules() map[string]WorkerSchedule {
+	schedules := make(map[string]WorkerSchedule)
+
+	for _, kv := range os.Environ() {
+		key, value, _ := strings.Cut(kv, "=")
+
+		rest, ok := strings.CutPrefix(key, "WORKER_")
+		if !ok || value == "" {
+			continue
+		}
+
+		for _, suffix := range []string{"_SCHEDULE", "_JITTER", "_SKIP_IF_RUNNING"} {
+			name, ok := strings.CutSuffix(rest, suffix)
+			if !ok {
+				continue
+			}
+			name = strings.ToLower(name)
+
+			schedule := schedules[name]
+			switch suffix {
+			case "_SCHEDULE":
+				schedule.Spec = value
+			case "_JITTER":
+				if jitter, err := time.ParseDuration(value); err == nil && jitter >= 0 {
+					schedule.Jitter = jitter
+				}
+			case "_SKIP_IF_RUNNING":
+				if skip, err := strconv.ParseBool(value); err == nil {
+					schedule.SkipIfRunning = &skip
+				}
+			}
+			schedules[name] = schedule
+
+			break
+		}
+	}
+
+	return schedules
+}
+
 func getEnv(key, fallback string) string {
 	if v := os.Getenv(key); v != "" {
 		return v
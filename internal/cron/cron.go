@@ -0,0 +1,282 @@
+// Package cron
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule returns the next activation time strictly after t, in t's location.
+type Schedule interface {
+	Next(t time.Time) time.Time
+}
+
+// Parse accepts a standard 5-field cron expression
+// ("minute hour day-of-month month day-of-week"), one of the @yearly,
+// @monthly, @weekly, @daily and @hourly shorthands, or "@every <duration>"
+// for a fixed interval of any length, measured from the previous activation
+// instead of aligned to the clock.
+func Parse(spec string) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+
+	if rest, ok := strings.CutPrefix(spec, "@every "); ok {
+		dur, err := time.ParseDuration(strings.TrimSpace(rest))
+		if err != nil {
+			return nil, fmt.Errorf("invalid schedule %q: %w", spec, err)
+		}
+		if dur <= 0 {
+			return nil, fmt.Errorf("invalid schedule %q: interval must be positive", spec)
+		}
+		return Every(dur), nil
+	}
+
+	switch spec {
+	case "@yearly", "@annually":
+		spec = "0 0 1 1 *"
+	case "@monthly":
+		spec = "0 0 1 * *"
+	case "@weekly":
+		spec = "0 0 * * 0"
+	case "@daily", "@midnight":
+		spec = "0 0 * * *"
+	case "@hourly":
+		spec = "0 * * * *"
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid schedule %q: expected 5 fields, got %d", spec, len(fields))
+	}
+
+	var (
+		s   cronSchedule
+		err error
+	)
+
+	if s.minute, err = parseCronField(fields[0], cronMinute); err != nil {
+		return nil, fmt.Errorf("invalid schedule %q: minute: %w", spec, err)
+	}
+	if s.hour, err = parseCronField(fields[1], cronHour); err != nil {
+		return nil, fmt.Errorf("invalid schedule %q: hour: %w", spec, err)
+	}
+	if s.dom, err = parseCronField(fields[2], cronDayOfMonth); err != nil {
+		return nil, fmt.Errorf("invalid schedule %q: day of month: %w", spec, err)
+	}
+	if s.month, err = parseCronField(fields[3], cronMonth); err != nil {
+		return nil, fmt.Errorf("invalid schedule %q: month: %w", spec, err)
+	}
+	if s.dow, err = parseCronField(fields[4], cronDayOfWeek); err != nil {
+		return nil, fmt.Errorf("invalid schedule %q: day of week: %w", spec, err)
+	}
+
+	// Sunday may be written as 7
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+
+	s.hourAny = fields[1] == "*"
+	s.domAny = fields[2] == "*"
+	s.dowAny = fields[4] == "*"
+
+	if s.Next(time.Now()).IsZero() {
+		return nil, fmt.Errorf("invalid schedule %q: never matches", spec)
+	}
+
+	return &s, nil
+}
+
+// Every runs at a fixed interval, measured from the previous activation.
+type Every time.Duration
+
+func (e Every) Next(t time.Time) time.Time {
+	return t.Add(time.Duration(e))
+}
+
+type cronBounds struct {
+	min, max int
+	names    map[string]int
+}
+
+var (
+	cronMinute     = cronBounds{min: 0, max: 59}
+	cronHour       = cronBounds{min: 0, max: 23}
+	cronDayOfMonth = cronBounds{min: 1, max: 31}
+	cronMonth      = cronBounds{min: 1, max: 12, names: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	cronDayOfWeek = cronBounds{min: 0, max: 7, names: map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+// cronSchedule keeps every field as a bitset of allowed values.
+type cronSchedule struct {
+	minute, hour, dom, month, dow uint64
+
+	// When both day fields are restricted a day matches if either does,
+	// as in classic cron.
+	domAny, dowAny bool
+
+	// Schedules pinned to hours run once across DST changes, those running
+	// every hour follow absolute time.
+	hourAny bool
+}
+
+// Next walks forward field by field, from month down to minute. It gives up
+// after five years, which only happens for impossible dates like "0 0 30 2 *".
+//
+// Like classic cron, a schedule pinned to hours that the clocks skip in
+// spring runs right after the gap, and one pinned to the hour repeated in
+// autumn runs only in its first pass.
+func (s *cronSchedule) Next(t time.Time) time.Time {
+	loc := t.Location()
+
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.Year() + 5
+
+wrap:
+	if t.Year() > limit {
+		return time.Time{}
+	}
+
+	for s.month&(1<<uint(t.Month())) == 0 {
+		t = midnight(t.Year(), t.Month()+1, 1, loc)
+		if t.Month() == time.January {
+			goto wrap
+		}
+	}
+
+	for !s.dayMatches(t) {
+		t = midnight(t.Year(), t.Month(), t.Day()+1, loc)
+		if t.Day() == 1 {
+			goto wrap
+		}
+	}
+
+	for s.hour&(1<<uint(t.Hour())) == 0 {
+		// Step in absolute time, a wall clock hour may not exist on DST days
+		prev := t.Hour()
+		t = t.Add(time.Hour - time.Duration(t.Minute())*time.Minute)
+		if t.Hour() == 0 {
+			goto wrap
+		}
+
+		skipped := uint64(1)<<uint(t.Hour()) - uint64(1)<<uint(prev+1)
+		if t.Hour() > prev+1 && s.hour&skipped != 0 {
+			return t
+		}
+	}
+
+	for s.minute&(1<<uint(t.Minute())) == 0 {
+		t = t.Add(time.Minute)
+		if t.Minute() == 0 {
+			goto wrap
+		}
+	}
+
+	if !s.hourAny && t.Add(-time.Hour).Hour() == t.Hour() {
+		t = t.Add(time.Hour - time.Duration(t.Minute())*time.Minute)
+		goto wrap
+	}
+
+	return t
+}
+
+// midnight returns the start of the given day. When midnight falls into a DST
+// gap time.Date may return the evening before, so step forward into the day.
+func midnight(year int, month time.Month, day int, loc *time.Location) time.Time {
+	t := time.Date(year, month, day, 0, 0, 0, 0, loc)
+	noon := time.Date(year, month, day, 12, 0, 0, 0, loc)
+
+	for t.Day() != noon.Day() {
+		t = t.Add(time.Hour)
+	}
+
+	return t
+}
+
+func (s *cronSchedule) dayMatches(t time.Time) bool {
+	domMatch := s.dom&(1<<uint(t.Day())) != 0
+	dowMatch := s.dow&(1<<uint(t.Weekday())) != 0
+
+	if s.domAny || s.dowAny {
+		return domMatch && dowMatch
+	}
+
+	return domMatch || dowMatch
+}
+
+// parseCronField supports "*", single values, ranges "a-b", steps "*/n" or
+// "a-b/n", and comma separated lists of those.
+func parseCronField(field string, bounds cronBounds) (uint64, error) {
+	var bits uint64
+
+	for part := range strings.SplitSeq(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepPart)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid step %q", stepPart)
+			}
+			step = n
+		}
+
+		var start, end int
+		switch {
+		case rangePart == "*":
+			start, end = bounds.min, bounds.max
+		case strings.Contains(rangePart, "-"):
+			lo, hi, _ := strings.Cut(rangePart, "-")
+
+			var err error
+			if start, err = bounds.value(lo); err != nil {
+				return 0, err
+			}
+			if end, err = bounds.value(hi); err != nil {
+				return 0, err
+			}
+			if start > end {
+				return 0, fmt.Errorf("invalid range %q", rangePart)
+			}
+		default:
+			var err error
+			if start, err = bounds.value(rangePart); err != nil {
+				return 0, err
+			}
+
+			end = start
+			// "5/15" means every 15 starting at 5
+			if hasStep {
+				end = bounds.max
+			}
+		}
+
+		for v := start; v <= end; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+
+	return bits, nil
+}
+
+func (b cronBounds) value(raw string) (int, error) {
+	if v, ok := b.names[strings.ToLower(raw)]; ok {
+		return v, nil
+	}
+
+	v, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q", raw)
+	}
+
+	if v < b.min || v > b.max {
+		return 0, fmt.Errorf("value %d out of range %d-%d", v, b.min, b.max)
+	}
+
+	return v, nil
+}
@@ -0,0 +1,339 @@
+package cron
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+func TestParseErrors(t *testing.T) {
+	tests := []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"@fortnightly",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * 32 * *",
+		"* * * 0 *",
+		"* * * 13 *",
+		"* * * * 8",
+		"-1 * * * *",
+		"*/0 * * * *",
+		"*/-5 * * * *",
+		"*/x * * * *",
+		"5-1 * * * *",
+		"1-x * * * *",
+		"1,,2 * * * *",
+		"* * * foo *",
+		"* * * * someday",
+		"0 0 30 2 *",
+		"0 0 31 4,6,9,11 *",
+		"@every",
+		"@every 0s",
+		"@every -1m",
+		"@every soon",
+	}
+
+	for _, spec := range tests {
+		t.Run(spec, func(t *testing.T) {
+			if _, err := Parse(spec); err == nil {
+				t.Errorf("Parse(%q) succeeded, want an error", spec)
+			}
+		})
+	}
+}
+
+func bits(values ...int) uint64 {
+	var b uint64
+	for _, v := range values {
+		b |= 1 << uint(v)
+	}
+	return b
+}
+
+func span(from, to int) []int {
+	var values []int
+	for v := from; v <= to; v++ {
+		values = append(values, v)
+	}
+	return values
+}
+
+func TestParseCronField(t *testing.T) {
+	tests := []struct {
+		field  string
+		bounds cronBounds
+		want   uint64
+	}{
+		{"*", cronMinute, bits(span(0, 59)...)},
+		{"7", cronMinute, bits(7)},
+		{"*/15", cronMinute, bits(0, 15, 30, 45)},
+		{"5/15", cronMinute, bits(5, 20, 35, 50)},
+		{"10-20", cronMinute, bits(span(10, 20)...)},
+		{"10-20/5", cronMinute, bits(10, 15, 20)},
+		{"10-21/5", cronMinute, bits(10, 15, 20)},
+		{"1,3,5-7", cronMinute, bits(1, 3, 5, 6, 7)},
+		{"0-10/5,30", cronMinute, bits(0, 5, 10, 30)},
+		{"*/7", cronHour, bits(0, 7, 14, 21)},
+		{"*/10", cronDayOfMonth, bits(1, 11, 21, 31)},
+		{"jan,jul", cronMonth, bits(1, 7)},
+		{"MAR-may", cronMonth, bits(3, 4, 5)},
+		{"*/3", cronMonth, bits(1, 4, 7, 10)},
+		{"mon-fri", cronDayOfWeek, bits(1, 2, 3, 4, 5)},
+		{"sat,sun", cronDayOfWeek, bits(0, 6)},
+		{"*/2", cronDayOfWeek, bits(0, 2, 4, 6)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.field, func(t *testing.T) {
+			got, err := parseCronField(tt.field, tt.bounds)
+			if err != nil {
+				t.Fatalf("parseCronField(%q): %v", tt.field, err)
+			}
+			if got != tt.want {
+				t.Errorf("parseCronField(%q) = %b, want %b", tt.field, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSundayAsSeven(t *testing.T) {
+	for _, spec := range []string{"0 0 * * 7", "0 0 * * 0", "0 0 * * sun", "@weekly"} {
+		s, err := Parse(spec)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", spec, err)
+		}
+
+		// 2026-03-04 is a Wednesday
+		got := s.Next(time.Date(2026, 3, 4, 12, 0, 0, 0, time.UTC))
+		want := time.Date(2026, 3, 8, 0, 0, 0, 0, time.UTC)
+		if !got.Equal(want) {
+			t.Errorf("%q: Next = %s, want %s", spec, got, want)
+		}
+	}
+}
+
+func TestNext(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		spec string
+		loc  *time.Location
+		from string
+		want []string
+	}{
+		{
+			name: "every minute is strictly after from",
+			spec: "* * * * *",
+			from: "2026-03-04T10:15:00Z",
+			want: []string{"2026-03-04T10:16:00Z", "2026-03-04T10:17:00Z"},
+		},
+		{
+			name: "seconds are truncated",
+			spec: "* * * * *",
+			from: "2026-03-04T10:15:59Z",
+			want: []string{"2026-03-04T10:16:00Z"},
+		},
+		{
+			name: "steps and ranges",
+			spec: "*/20 9-10 * * mon-fri",
+			from: "2026-03-06T10:30:00Z",
+			want: []string{
+				"2026-03-06T10:40:00Z",
+				"2026-03-09T09:00:00Z",
+				"2026-03-09T09:20:00Z",
+			},
+		},
+		{
+			name: "hourly",
+			spec: "@hourly",
+			from: "2026-12-31T23:00:00Z",
+			want: []string{"2027-01-01T00:00:00Z", "2027-01-01T01:00:00Z"},
+		},
+		{
+			name: "day of month or day of week when both are set",
+			spec: "0 0 13 * fri",
+			from: "2026-02-01T00:00:00Z",
+			want: []string{
+				"2026-02-06T00:00:00Z",
+				"2026-02-13T00:00:00Z",
+				"2026-02-20T00:00:00Z",
+				"2026-02-27T00:00:00Z",
+				"2026-03-06T00:00:00Z",
+				"2026-03-13T00:00:00Z",
+				"2026-03-20T00:00:00Z",
+			},
+		},
+		{
+			name: "day of month alone",
+			spec: "0 0 13 * *",
+			from: "2026-02-01T00:00:00Z",
+			want: []string{"2026-02-13T00:00:00Z", "2026-03-13T00:00:00Z"},
+		},
+		{
+			name: "day of week alone",
+			spec: "0 0 * * fri",
+			from: "2026-02-13T00:00:00Z",
+			want: []string{"2026-02-20T00:00:00Z", "2026-02-27T00:00:00Z"},
+		},
+		{
+			name: "month end skips short months",
+			spec: "0 0 31 * *",
+			from: "2026-01-31T00:00:00Z",
+			want: []string{
+				"2026-03-31T00:00:00Z",
+				"2026-05-31T00:00:00Z",
+				"2026-07-31T00:00:00Z",
+				"2026-08-31T00:00:00Z",
+				"2026-10-31T00:00:00Z",
+			},
+		},
+		{
+			name: "last day of february",
+			spec: "0 0 28,29 2 *",
+			from: "2027-02-28T00:00:00Z",
+			want: []string{"2028-02-28T00:00:00Z", "2028-02-29T00:00:00Z", "2029-02-28T00:00:00Z"},
+		},
+		{
+			name: "leap day",
+			spec: "30 6 29 2 *",
+			from: "2026-01-01T00:00:00Z",
+			want: []string{"2028-02-29T06:30:00Z", "2032-02-29T06:30:00Z"},
+		},
+		{
+			name: "leap day across a century that is not a leap year",
+			spec: "0 0 29 2 *",
+			from: "2099-03-01T00:00:00Z",
+			want: []string{"2104-02-29T00:00:00Z"},
+		},
+		{
+			name: "wall clock time kept across spring forward",
+			spec: "0 9 * * *",
+			loc:  berlin,
+			from: "2026-03-28T10:00:00+01:00",
+			want: []string{"2026-03-29T09:00:00+02:00", "2026-03-30T09:00:00+02:00"},
+		},
+		{
+			name: "hour skipped by spring forward runs after the gap",
+			spec: "30 2 * * *",
+			loc:  berlin,
+			from: "2026-03-28T03:00:00+01:00",
+			want: []string{"2026-03-29T03:00:00+02:00", "2026-03-30T02:30:00+02:00"},
+		},
+		{
+			name: "hourly jobs follow absolute time in spring",
+			spec: "30 * * * *",
+			loc:  berlin,
+			from: "2026-03-29T00:45:00+01:00",
+			want: []string{
+				"2026-03-29T01:30:00+01:00",
+				"2026-03-29T03:30:00+02:00",
+				"2026-03-29T04:30:00+02:00",
+			},
+		},
+		{
+			name: "repeated hour runs once in autumn",
+			spec: "30 2 * * *",
+			loc:  berlin,
+			from: "2026-10-24T12:00:00+02:00",
+			want: []string{"2026-10-25T02:30:00+02:00", "2026-10-26T02:30:00+01:00"},
+		},
+		{
+			name: "repeated hour from its second pass",
+			spec: "30 2 * * *",
+			loc:  berlin,
+			from: "2026-10-25T02:10:00+01:00",
+			want: []string{"2026-10-26T02:30:00+01:00"},
+		},
+		{
+			name: "hourly jobs follow absolute time in autumn",
+			spec: "30 * * * *",
+			loc:  berlin,
+			from: "2026-10-25T01:45:00+02:00",
+			want: []string{
+				"2026-10-25T02:30:00+02:00",
+				"2026-10-25T02:30:00+01:00",
+				"2026-10-25T03:30:00+01:00",
+			},
+		},
+		{
+			name: "steps within a pinned hour in autumn",
+			spec: "*/20 1 * * *",
+			loc:  newYork,
+			from: "2026-11-01T00:50:00-04:00",
+			want: []string{
+				"2026-11-01T01:00:00-04:00",
+				"2026-11-01T01:20:00-04:00",
+				"2026-11-01T01:40:00-04:00",
+				"2026-11-02T01:00:00-05:00",
+			},
+		},
+		{
+			name: "midnight across spring forward",
+			spec: "@daily",
+			loc:  newYork,
+			from: "2026-03-07T12:00:00-05:00",
+			want: []string{"2026-03-08T00:00:00-05:00", "2026-03-09T00:00:00-04:00"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loc := tt.loc
+			if loc == nil {
+				loc = time.UTC
+			}
+
+			s, err := Parse(tt.spec)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.spec, err)
+			}
+
+			from := mustTime(t, tt.from).In(loc)
+			for _, w := range tt.want {
+				want := mustTime(t, w)
+
+				got := s.Next(from)
+				if !got.Equal(want) {
+					t.Fatalf("Next(%s) = %s, want %s", from, got, want.In(loc))
+				}
+				if got.Location() != loc {
+					t.Errorf("Next(%s) is in %s, want %s", from, got.Location(), loc)
+				}
+				from = got
+			}
+		})
+	}
+}
+
+func TestEvery(t *testing.T) {
+	s, err := Parse("@every 10s")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	from := time.Date(2026, 3, 4, 10, 15, 3, 0, time.UTC)
+	if got, want := s.Next(from), from.Add(10*time.Second); !got.Equal(want) {
+		t.Errorf("Next = %s, want %s", got, want)
+	}
+}
+
+func mustTime(t *testing.T, value string) time.Time {
+	t.Helper()
+
+	v, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
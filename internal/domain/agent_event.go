@@ -0,0 +1,32 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AgentEventIDHeader carries the ID of an agent outbox entry so replays of
+// the same entry can be ignored.
+const AgentEventIDHeader = "X-Event-ID"
+
+// AgentEventRetention is how long processed agent event IDs are remembered.
+// Agents replaying an outbox older than this may be processed twice.
+const AgentEventRetention = 7 * 24 * time.Hour
+
+type AgentEventRepository interface {
+	Exists(ctx context.Context, eventID uuid.UUID) (bool, error)
+	// Record stores the event ID, recording it twice is not an error.
+	Record(ctx context.Context, serverID uuid.UUID, eventID uuid.UUID) error
+	DeleteBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+type AgentEventService interface {
+	// Processed reports whether the event was already handled and the agent
+	// is replaying it.
+	Processed(ctx context.Context, eventID uuid.UUID) (bool, error)
+	// Record marks the event handled, only once it succeeded.
+	Record(ctx context.Context, serverID uuid.UUID, eventID uuid.UUID) error
+	Prune(ctx context.Context) (int64, error)
+}
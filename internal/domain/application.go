@@ -3,6 +3,11 @@ package domain
 import (
 	"context"
 	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -11,6 +16,27 @@ import (
 var (
 	ErrApplicationNotFound  = errors.New("application not found")
 	ErrInvalidDockerCompose = errors.New("invalid docker compose configuration")
+	ErrComposeProjectTaken  = errors.New("compose project name is already used on this server")
+
+	ErrInvalidApplicationSource = errors.New("setting does not apply to the application source")
+
+	ErrEnvVarNotFound      = errors.New("env var not found")
+	ErrEnvVarNotRevealable = errors.New("env var is not a preview value and is never shown")
+)
+
+// ApplicationSource is where a deploy takes the application from.
+//
+//   - git clones the repository and builds the compose services
+//   - image pulls prebuilt images. The compose file is stored with the
+//     application and a deploy may name the image tag or digest, compose
+//     files pick it up as ${HORIZONX_IMAGE_TAG} or ${HORIZONX_IMAGE_REF},
+//     the latter with its ":" or "@" separator. Deploy strategies do not
+//     apply, a deploy pulls and recreates the changed containers.
+type ApplicationSource string
+
+const (
+	AppSourceGit   ApplicationSource = "git"
+	AppSourceImage ApplicationSource = "image"
 )
 
 type ApplicationStatus string
@@ -26,45 +52,388 @@ const (
 	AppStatusUnknown    ApplicationStatus = "unknown"
 )
 
+// DeployStrategy decides how a deploy replaces the running containers.
+//
+//   - recreate stops the application, then builds and starts it again
+//   - build_then_swap builds the images while the old containers keep
+//     serving, then recreates only the changed containers
+//   - blue_green starts a second compose project next to the live one, waits
+//     for it to be healthy and then removes the old project. Services must
+//     not publish fixed host ports or set container_name, both projects run
+//     side by side for a while. Named volumes must set an explicit name or
+//     be external, otherwise each project would get its own empty volume.
+type DeployStrategy string
+
+const (
+	DeployRecreate      DeployStrategy = "recreate"
+	DeployBuildThenSwap DeployStrategy = "build_then_swap"
+	DeployBlueGreen     DeployStrategy = "blue_green"
+)
+
 type Application struct {
 	ID               int64             `json:"id"`
 	ServerID         uuid.UUID         `json:"server_id"`
 	Name             string            `json:"name"`
+	Source           ApplicationSource `json:"source"`
 	RepoURL          string            `json:"repo_url,omitempty"`
 	Branch           string            `json:"branch"`
+	GitCredentialID  *int64            `json:"git_credential_id,omitempty"`
 	Status           ApplicationStatus `json:"status"`
 	LastDeploymentAt *time.Time        `json:"last_deployment_at,omitempty"`
+	JobTimeouts      JobTimeouts       `json:"job_timeouts"`
+	DeployStrategy   DeployStrategy    `json:"deploy_strategy"`
 	CreatedAt        time.Time         `json:"created_at"`
 	UpdatedAt        time.Time         `json:"updated_at"`
 
+	// AutoRollback redeploys the last successful commit when a deploy fails
+	// or, with RollbackWindowMinutes set, when the application turns failed
+	// within that many minutes after a deploy.
+	AutoRollback          bool `json:"auto_rollback"`
+	RollbackWindowMinutes int  `json:"rollback_window_minutes"`
+
+	// WebhookEnabled is set once a git webhook secret was generated, pushes
+	// to Branch then deploy the application.
+	WebhookEnabled bool `json:"webhook_enabled"`
+
+	// GitPollIntervalSeconds, when set, has the server agent check the
+	// branch head that often and deploy new commits once they stayed the
+	// head for GitPollDebounceSeconds. For remotes that cannot send webhooks.
+	GitPollIntervalSeconds int `json:"git_poll_interval_seconds"`
+	GitPollDebounceSeconds int `json:"git_poll_debounce_seconds"`
+
+	// ComposePath is the compose file relative to the repository root, the
+	// standard file names at the root are looked up when empty. The
+	// override files are layered on top of it in order. Changes take
+	// effect with the next deploy.
+	ComposePath        string   `json:"compose_path"`
+	ComposeOverrides   []string `json:"compose_overrides"`
+	ComposeProfiles    []string `json:"compose_profiles"`
+	ComposeProjectName string   `json:"compose_project_name"`
+
+	// ComposeFile and RegistryCredentialID belong to image applications,
+	// the agent writes the file as compose.yaml before each deploy. The
+	// file is only loaded for a single application.
+	ComposeFile          string `json:"compose_file,omitempty"`
+	RegistryCredentialID *int64 `json:"registry_credential_id,omitempty"`
+
+	// Services is the container breakdown of the last health check, only
+	// loaded for a single application.
+	Services        []ServiceHealth `json:"services,omitempty"`
+	HealthCheckedAt *time.Time      `json:"health_checked_at,omitempty"`
+
 	EnvVars *[]EnvironmentVariable `json:"env_vars,omitempty"`
 }
 
+// ComposeProjectName is the docker compose project of an application, the
+// agent checks every app out into a directory of that name.
+func ComposeProjectName(appID int64) string {
+	return fmt.Sprintf("app-%d", appID)
+}
+
+// ComposeConfig tells the agent which compose files of the checkout make up
+// an application. Paths are relative to the repository root and the
+// project directory is the one of Path, as with docker compose -f.
+type ComposeConfig struct {
+	Path        string   `json:"path,omitempty"`
+	Overrides   []string `json:"overrides,omitempty"`
+	Profiles    []string `json:"profiles,omitempty"`
+	ProjectName string   `json:"project_name,omitempty"`
+}
+
+// ComposeConfig returns the compose settings sent to the agent on deploy.
+func (a *Application) ComposeConfig() ComposeConfig {
+	return ComposeConfig{
+		Path:        a.ComposePath,
+		Overrides:   a.ComposeOverrides,
+		Profiles:    a.ComposeProfiles,
+		ProjectName: a.ComposeProjectName,
+	}
+}
+
+// Project is the compose project of appID under this configuration, blue
+// and green slots are suffixed to it.
+func (c ComposeConfig) Project(appID int64) string {
+	if c.ProjectName != "" {
+		return c.ProjectName
+	}
+
+	return ComposeProjectName(appID)
+}
+
+var (
+	composeProjectPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,62}$`)
+	composeProfilePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]{0,63}$`)
+)
+
+// ValidComposeProject reports whether name is a valid compose project name.
+// Names starting with app- are reserved for the default project names, the
+// agent tells applications apart by them.
+func ValidComposeProject(name string) bool {
+	return composeProjectPattern.MatchString(name) && !strings.HasPrefix(name, "app-")
+}
+
+// ValidComposeProfile reports whether name is a valid compose profile.
+func ValidComposeProfile(name string) bool {
+	return composeProfilePattern.MatchString(name)
+}
+
+// ValidComposeFile reports whether path is a relative path that stays
+// inside the repository checkout.
+func ValidComposeFile(path string) bool {
+	return path != "" && !strings.Contains(path, "\\") && filepath.IsLocal(path)
+}
+
+var (
+	imageTagPattern    = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.-]{0,127}$`)
+	imageDigestPattern = regexp.MustCompile(`^sha256:[a-f0-9]{64}$`)
+)
+
+// ValidImageRef reports whether ref is an image tag or a sha256 digest.
+func ValidImageRef(ref string) bool {
+	return imageTagPattern.MatchString(ref) || imageDigestPattern.MatchString(ref)
+}
+
+// ImageRefSuffix returns ref as it follows an image name, ":tag" or
+// "@sha256:...".
+func ImageRefSuffix(ref string) string {
+	if imageDigestPattern.MatchString(ref) {
+		return "@" + ref
+	}
+
+	return ":" + ref
+}
+
+// ParseComposeProject returns the application owning a compose project. Any
+// suffix after the application id, such as a deploy slot, is ignored.
+func ParseComposeProject(project string) (int64, bool) {
+	rest, ok := strings.CutPrefix(project, "app-")
+	if !ok {
+		return 0, false
+	}
+
+	if i := strings.IndexByte(rest, '-'); i >= 0 {
+		rest = rest[:i]
+	}
+
+	appID, err := strconv.ParseInt(rest, 10, 64)
+	if err != nil || appID <= 0 {
+		return 0, false
+	}
+
+	return appID, true
+}
+
+// ParseComposeWorkingDir returns the application whose checkout holds dir,
+// the working directory compose records on containers. It finds the
+// applications with a custom project name.
+func ParseComposeWorkingDir(dir string) (int64, bool) {
+	for elem := range strings.SplitSeq(filepath.ToSlash(dir), "/") {
+		rest, ok := strings.CutPrefix(elem, "app-")
+		if !ok {
+			continue
+		}
+
+		if appID, err := strconv.ParseInt(rest, 10, 64); err == nil && appID > 0 {
+			return appID, true
+		}
+	}
+
+	return 0, false
+}
+
+// JobTimeouts overrides the default timeout of a job type, in seconds.
+type JobTimeouts map[JobType]int
+
+// JobTimeout returns the timeout for jobs of the given type run for this
+// application, falling back to the job type default.
+func (a *Application) JobTimeout(t JobType) time.Duration {
+	if seconds, ok := a.JobTimeouts[t]; ok && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+
+	return t.DefaultTimeout()
+}
+
 type ApplicationListOptions struct {
 	ListOptions
 	ServerID *uuid.UUID `json:"server_id"`
 }
 
 type ApplicationCreateRequest struct {
-	ServerID uuid.UUID `json:"server_id" validate:"required"`
-	Name     string    `json:"name" validate:"required,min=3,max=100"`
-	RepoURL  string    `json:"repo_url" validate:"required"`
-	Branch   string    `json:"branch" validate:"required"`
+	ServerID uuid.UUID         `json:"server_id" validate:"required"`
+	Name     string            `json:"name" validate:"required,min=3,max=100"`
+	Source   ApplicationSource `json:"source" validate:"omitempty,oneof=git image"`
+	RepoURL  string            `json:"repo_url" validate:"required_unless=Source image"`
+	Branch   string            `json:"branch" validate:"required_unless=Source image"`
+
+	ComposeFile          string `json:"compose_file" validate:"required_if=Source image,max=262144"`
+	RegistryCredentialID *int64 `json:"registry_credential_id" validate:"omitempty,min=1"`
+
+	GitCredentialID *int64 `json:"git_credential_id" validate:"omitempty,min=1"`
+
+	JobTimeouts    JobTimeouts    `json:"job_timeouts" validate:"omitempty,dive,keys,oneof=app_deploy app_start app_stop app_restart app_command,endkeys,min=1,max=86400"`
+	DeployStrategy DeployStrategy `json:"deploy_strategy" validate:"omitempty,oneof=recreate build_then_swap blue_green"`
+
+	AutoRollback          bool `json:"auto_rollback"`
+	RollbackWindowMinutes int  `json:"rollback_window_minutes" validate:"min=0,max=1440"`
+
+	GitPollIntervalSeconds int `json:"git_poll_interval_seconds" validate:"eq=0|min=30,max=86400"`
+	GitPollDebounceSeconds int `json:"git_poll_debounce_seconds" validate:"min=0,max=3600"`
+
+	ComposePath        string   `json:"compose_path" validate:"omitempty,max=255,compose_file"`
+	ComposeOverrides   []string `json:"compose_overrides" validate:"omitempty,max=10,dive,max=255,compose_file"`
+	ComposeProfiles    []string `json:"compose_profiles" validate:"omitempty,max=10,dive,compose_profile"`
+	ComposeProjectName string   `json:"compose_project_name" validate:"omitempty,compose_project"`
 
 	EnvVars []EnvironmentVariableRequest `json:"env_vars" validate:"omitempty,dive"`
 }
 
+// ApplicationUpdateRequest cannot change the source, repository and branch
+// are required for git applications and a compose file for image ones.
 type ApplicationUpdateRequest struct {
-	Name    string `json:"name" validate:"required,min=3,max=100"`
-	RepoURL string `json:"repo_url" validate:"required"`
-	Branch  string `json:"branch" validate:"required"`
+	Name string `json:"name" validate:"required,min=3,max=100"`
+
+	// Left out, the source settings are kept.
+	RepoURL     *string `json:"repo_url"`
+	Branch      *string `json:"branch"`
+	ComposeFile *string `json:"compose_file" validate:"omitempty,max=262144"`
+
+	// Left out, the credentials stay attached. 0 detaches one.
+	GitCredentialID      *int64 `json:"git_credential_id" validate:"omitempty,min=0"`
+	RegistryCredentialID *int64 `json:"registry_credential_id" validate:"omitempty,min=0"`
+
+	// Left out, the timeouts are kept. An empty object clears them.
+	JobTimeouts    *JobTimeouts   `json:"job_timeouts" validate:"omitempty,dive,keys,oneof=app_deploy app_start app_stop app_restart app_command,endkeys,min=1,max=86400"`
+	DeployStrategy DeployStrategy `json:"deploy_strategy" validate:"omitempty,oneof=recreate build_then_swap blue_green"`
+
+	// Left out, the rollback policy keeps its current values.
+	AutoRollback          *bool `json:"auto_rollback"`
+	RollbackWindowMinutes *int  `json:"rollback_window_minutes" validate:"omitempty,min=0,max=1440"`
+
+	// Left out, polling keeps its current settings. An interval of 0 turns
+	// it off.
+	GitPollIntervalSeconds *int `json:"git_poll_interval_seconds" validate:"omitempty,eq=0|min=30,max=86400"`
+	GitPollDebounceSeconds *int `json:"git_poll_debounce_seconds" validate:"omitempty,min=0,max=3600"`
+
+	// Left out, the compose settings are kept. An empty value resets one to
+	// the default. A renamed project is taken down on the next deploy, its
+	// named volumes are kept but the new project starts with empty ones.
+	ComposePath        *string   `json:"compose_path" validate:"omitempty,max=255,compose_file|eq="`
+	ComposeOverrides   *[]string `json:"compose_overrides" validate:"omitempty,max=10,dive,max=255,compose_file"`
+	ComposeProfiles    *[]string `json:"compose_profiles" validate:"omitempty,max=10,dive,compose_profile"`
+	ComposeProjectName *string   `json:"compose_project_name" validate:"omitempty,compose_project|eq="`
 
 	EnvVars []EnvironmentVariableRequest `json:"env_vars" validate:"omitempty,dive"`
 }
 
+// ApplicationDeployRequest pins a deploy to a ref, a full commit SHA or a
+// tag, or to the commit of an earlier deployment. Without either the head
+// of the application branch is deployed. Image applications take an image
+// tag or digest instead of a ref, without one the compose file decides.
+type ApplicationDeployRequest struct {
+	Ref          string `json:"ref" validate:"omitempty,max=255,startsnotwith=-,startsnotwith=+,excludesall= ~^:?*[\\,excludes=..,excluded_with=DeploymentID"`
+	Image        string `json:"image" validate:"omitempty,image_ref,excluded_with=DeploymentID Ref"`
+	DeploymentID *int64 `json:"deployment_id" validate:"omitempty,min=1"`
+
+	// Push is set by git webhooks and the poller, the pushed commit is
+	// deployed and its pusher recorded. It cannot be sent to the API.
+	Push *GitPush `json:"-"`
+}
+
+type ApplicationCommandRequest struct {
+	Service string `json:"service" validate:"required,max=100"`
+	Command string `json:"command" validate:"required,max=4096"`
+}
+
 type ApplicationHealth struct {
 	ApplicationID int64             `json:"application_id"`
 	Status        ApplicationStatus `json:"status"`
+	Services      []ServiceHealth   `json:"services"`
+}
+
+// ServiceHealth is the state of one compose container. Replicas of a service
+// are reported separately. Uptime is measured when the check ran.
+type ServiceHealth struct {
+	Service       string        `json:"service"`
+	Container     string        `json:"container"`
+	State         string        `json:"state"`
+	Health        string        `json:"health,omitempty"`
+	ExitCode      int           `json:"exit_code"`
+	Ports         []ServicePort `json:"ports"`
+	RestartCount  int           `json:"restart_count"`
+	StartedAt     *time.Time    `json:"started_at,omitempty"`
+	UptimeSeconds int64         `json:"uptime_seconds"`
+}
+
+type ServicePort struct {
+	HostIP        string `json:"host_ip,omitempty"`
+	PublishedPort int    `json:"published_port,omitempty"`
+	TargetPort    int    `json:"target_port"`
+	Protocol      string `json:"protocol"`
+}
+
+// Status maps the container state to an application status.
+func (s ServiceHealth) Status() ApplicationStatus {
+	switch s.State {
+	case "running":
+		switch s.Health {
+		case "unhealthy":
+			return AppStatusFailed
+		case "starting":
+			return AppStatusStarting
+		default:
+			return AppStatusRunning
+		}
+	case "restarting":
+		return AppStatusRestarting
+	case "exited":
+		if s.ExitCode == 0 {
+			return AppStatusStopped
+		}
+		return AppStatusFailed
+	case "dead":
+		return AppStatusFailed
+	default:
+		return AppStatusUnknown
+	}
+}
+
+// AggregateHealth works out the application status from its containers.
+// The first rule that matches wins:
+//
+//  1. failed if any container is unhealthy, dead or exited with a non-zero code
+//  2. restarting if any container is restarting
+//  3. starting if any container is still waiting for its health check
+//  4. running if any container is running; containers that exited with 0
+//     are taken as finished one-shot tasks such as migrations
+//  5. stopped if every container exited with 0
+//  6. unknown otherwise, including no containers at all or paused ones
+func AggregateHealth(services []ServiceHealth) ApplicationStatus {
+	if len(services) == 0 {
+		return AppStatusUnknown
+	}
+
+	counts := make(map[ApplicationStatus]int)
+	for _, s := range services {
+		counts[s.Status()]++
+	}
+
+	for _, status := range []ApplicationStatus{
+		AppStatusFailed,
+		AppStatusRestarting,
+		AppStatusStarting,
+		AppStatusRunning,
+	} {
+		if counts[status] > 0 {
+			return status
+		}
+	}
+
+	if counts[AppStatusStopped] == len(services) {
+		return AppStatusStopped
+	}
+
+	return AppStatusUnknown
 }
 
 type EnvironmentVariable struct {
@@ -75,6 +444,23 @@ type EnvironmentVariable struct {
 	IsPreview     bool      `json:"is_preview"`
 	CreatedAt     time.Time `json:"created_at"`
 	UpdatedAt     time.Time `json:"updated_at"`
+
+	// Encrypted values are only decrypted for deploys and reveals.
+	Encrypted bool `json:"encrypted"`
+}
+
+// EnvValueMask replaces env var values in responses.
+const EnvValueMask = "********"
+
+// Mask hides the value. Only preview values can be revealed afterwards,
+// others are write-only.
+func (e *EnvironmentVariable) Mask() {
+	e.Value = EnvValueMask
+}
+
+// IsMasked reports whether the value is the mask sent back by a client.
+func (e *EnvironmentVariable) IsMasked() bool {
+	return e.Value == EnvValueMask
 }
 
 type EnvironmentVariableRequest struct {
@@ -95,9 +481,18 @@ type ApplicationRepository interface {
 
 	SyncEnvVars(ctx context.Context, appID int64, envVars []EnvironmentVariable) error
 	ListEnvVars(ctx context.Context, appID int64) ([]EnvironmentVariable, error)
+	// ListEnvVarValues decrypts the env vars of appID for a deploy.
+	ListEnvVarValues(ctx context.Context, appID int64) (map[string]string, error)
+	// GetEnvVar returns an env var with its value decrypted.
+	GetEnvVar(ctx context.Context, appID int64, key string) (*EnvironmentVariable, error)
 	CreateEnvVar(ctx context.Context, env *EnvironmentVariable) error
 	UpdateEnvVar(ctx context.Context, env *EnvironmentVariable) error
 	DeleteEnvVar(ctx context.Context, appID int64, key string) error
+
+	// GetWebhookSecret returns the encrypted webhook secret, nil when the
+	// webhook is disabled. SetWebhookSecret disables it with nil.
+	GetWebhookSecret(ctx context.Context, appID int64) ([]byte, error)
+	SetWebhookSecret(ctx context.Context, appID int64, encrypted []byte) error
 }
 
 type ApplicationService interface {
@@ -110,13 +505,19 @@ type ApplicationService interface {
 	UpdateHealth(ctx context.Context, serverID uuid.UUID, reports []ApplicationHealth) error
 	Delete(ctx context.Context, appID int64) error
 
-	Deploy(ctx context.Context, appID int64, deployedBy int64) (*Deployment, error)
+	// Deploy takes a nil deployedBy for deploys no user triggered.
+	Deploy(ctx context.Context, appID int64, deployedBy *int64, req ApplicationDeployRequest) (*Deployment, error)
+	Rollback(ctx context.Context, deploymentID int64, reason RollbackReason) (*Deployment, error)
 	Start(ctx context.Context, appID int64) error
 	Stop(ctx context.Context, appID int64) error
 	Restart(ctx context.Context, appID int64) error
+	RunCommand(ctx context.Context, appID int64, req ApplicationCommandRequest) (*Job, error)
 
 	ListEnvVars(ctx context.Context, appID int64) ([]EnvironmentVariable, error)
 	AddEnvVar(ctx context.Context, appID int64, req EnvironmentVariableRequest) error
 	UpdateEnvVar(ctx context.Context, appID int64, key string, req EnvironmentVariableRequest) error
 	DeleteEnvVar(ctx context.Context, appID int64, key string) error
+	// RevealEnvVar returns a preview env var in plain text and records who
+	// asked for it.
+	RevealEnvVar(ctx context.Context, appID int64, key string, revealedBy int64) (*EnvironmentVariable, error)
 }
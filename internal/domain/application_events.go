@@ -11,3 +11,27 @@ type EventApplicationStatusChanged struct {
 	ApplicationID int64             `json:"application_id"`
 	Status        ApplicationStatus `json:"status"`
 }
+
+type EventApplicationHealthReported struct {
+	ApplicationID int64             `json:"application_id"`
+	ServerID      uuid.UUID         `json:"server_id"`
+	Status        ApplicationStatus `json:"status"`
+}
+
+type EventContainerLogsRequested struct {
+	ServerID uuid.UUID            `json:"server_id"`
+	Request  ContainerLogsRequest `json:"request"`
+}
+
+type EventContainerLogsStopped struct {
+	ServerID  uuid.UUID `json:"server_id"`
+	RequestID uuid.UUID `json:"request_id"`
+}
+
+type EventContainerLogsReceived struct {
+	ApplicationID int64              `json:"application_id"`
+	Channel       string             `json:"channel"`
+	Lines         []ContainerLogLine `json:"lines"`
+	Done          bool               `json:"done"`
+	Error         string             `json:"error,omitempty"`
+}
@@ -0,0 +1,95 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrAgentOffline         = errors.New("agent is not connected")
+	ErrContainerLogsTimeout = errors.New("timed out waiting for container logs")
+	ErrContainerLogsFailed  = errors.New("failed to read container logs")
+)
+
+const (
+	ContainerLogsDefaultTail = 100
+	// ContainerLogsMaxTail caps how many lines a single request may ask for.
+	ContainerLogsMaxTail = 1000
+	// ContainerLogsTimeout bounds how long a request waits for the agent.
+	ContainerLogsTimeout = 30 * time.Second
+	// ContainerLogsSubscribeTimeout stops a follow stream nobody subscribed to.
+	ContainerLogsSubscribeTimeout = 30 * time.Second
+)
+
+// ContainerLogOptions mirrors the docker compose logs flags. Since takes a
+// timestamp or a relative duration such as "15m".
+type ContainerLogOptions struct {
+	Tail    int    `json:"tail,omitempty" validate:"min=0,max=1000"`
+	Since   string `json:"since,omitempty" validate:"omitempty,max=64,startsnotwith=-"`
+	Service string `json:"service,omitempty" validate:"omitempty,max=100,startsnotwith=-"`
+	Follow  bool   `json:"follow,omitempty"`
+}
+
+type ContainerLogLine struct {
+	Stream LogStream `json:"stream"`
+	Line   string    `json:"line"`
+}
+
+// ContainerLogs is the answer to a container logs request. Channel is set in
+// follow mode and names the user websocket channel carrying new lines.
+type ContainerLogs struct {
+	ApplicationID int64              `json:"application_id"`
+	Lines         []ContainerLogLine `json:"lines"`
+	Channel       string             `json:"channel,omitempty"`
+}
+
+// ContainerLogsChannel is the user websocket channel of a follow stream.
+// Viewers of the same application and service share one agent stream.
+func ContainerLogsChannel(appID int64, service string) string {
+	if service == "" {
+		return fmt.Sprintf("application:%d:container-logs", appID)
+	}
+
+	return fmt.Sprintf("application:%d:container-logs:%s", appID, service)
+}
+
+// ContainerLogsRequest asks the agent for container output. With Follow set
+// the agent keeps streaming chunks under RequestID until told to stop.
+type ContainerLogsRequest struct {
+	RequestID     uuid.UUID           `json:"request_id"`
+	ApplicationID int64               `json:"application_id"`
+	Options       ContainerLogOptions `json:"options"`
+}
+
+type ContainerLogsStop struct {
+	RequestID uuid.UUID `json:"request_id"`
+}
+
+// ContainerLogsChunk carries lines from the agent. Done marks the last chunk
+// of a request, Error is set when the command failed.
+type ContainerLogsChunk struct {
+	RequestID uuid.UUID          `json:"request_id"`
+	Lines     []ContainerLogLine `json:"lines"`
+	Done      bool               `json:"done"`
+	Error     string             `json:"error,omitempty"`
+}
+
+type ContainerLogService interface {
+	Get(ctx context.Context, appID int64, opts ContainerLogOptions) (*ContainerLogs, error)
+
+	// HandleChunk takes a chunk sent by the agent of serverID.
+	HandleChunk(serverID uuid.UUID, chunk ContainerLogsChunk)
+	// ChannelEmpty stops the follow stream behind channel, if any.
+	ChannelEmpty(channel string)
+	// DropServerStreams forgets the follow streams of a disconnected agent.
+	DropServerStreams(serverID uuid.UUID)
+}
+
+// WsPresence reports how many clients listen on a user websocket channel.
+type WsPresence interface {
+	Subscribers(channel string) int
+}
@@ -6,7 +6,15 @@ import (
 	"time"
 )
 
-var ErrDeploymentNotFound = errors.New("deployment not found")
+var (
+	ErrDeploymentNotFound = errors.New("deployment not found")
+	ErrNoRollbackTarget   = errors.New("no successful deployment to roll back to")
+	ErrRollbackOfRollback = errors.New("a rollback deployment is not rolled back again")
+	ErrDeploymentNoCommit = errors.New("deployment has no recorded commit")
+	ErrDeploymentNoImage  = errors.New("deployment has no recorded image tag or digest")
+
+	ErrDeploymentInProgress = errors.New("a deployment of this application is queued or running")
+)
 
 type DeploymentStatus string
 
@@ -15,12 +23,26 @@ const (
 	DeploymentDeploying DeploymentStatus = "deploying"
 	DeploymentSuccess   DeploymentStatus = "success"
 	DeploymentFailed    DeploymentStatus = "failed"
+	DeploymentCancelled DeploymentStatus = "cancelled"
+
+	// DeploymentSuperseded is a deployment that never ran because a newer
+	// one was requested while it was queued.
+	DeploymentSuperseded DeploymentStatus = "superseded"
+)
+
+// RollbackReason tells why a deployment was rolled back.
+type RollbackReason string
+
+const (
+	RollbackDeployFailed RollbackReason = "deploy_failed"
+	RollbackUnhealthy    RollbackReason = "unhealthy"
 )
 
 type Deployment struct {
 	ID            int64            `json:"id"`
 	ApplicationID int64            `json:"application_id"`
 	Branch        string           `json:"branch"`
+	Ref           *string          `json:"ref,omitempty"`
 	CommitHash    *string          `json:"commit_hash,omitempty"`
 	CommitMessage *string          `json:"commit_message,omitempty"`
 	Status        DeploymentStatus `json:"status"`
@@ -29,6 +51,20 @@ type Deployment struct {
 	FinishedAt    *time.Time       `json:"finished_at,omitempty"`
 	DeployedBy    *int64           `json:"deployed_by,omitempty"`
 
+	// PushedBy is the git user whose push triggered the deployment through
+	// a webhook.
+	PushedBy *string `json:"pushed_by,omitempty"`
+
+	// Image is the tag or digest an image application was deployed with.
+	Image *string `json:"image,omitempty"`
+
+	// RollbackOf is the deployment this one replaced by redeploying an
+	// earlier commit.
+	RollbackOf *int64 `json:"rollback_of,omitempty"`
+
+	// SupersededBy is the deployment that replaced this one in the queue.
+	SupersededBy *int64 `json:"superseded_by,omitempty"`
+
 	Deployer *User `json:"deployer,omitempty"`
 	Logs     []Log `json:"logs,omitempty"`
 }
@@ -41,9 +77,19 @@ type DeploymentListOptions struct {
 }
 
 type DeploymentCreateRequest struct {
-	ApplicationID int64  `json:"application_id"`
-	Branch        string `json:"branch"`
-	DeployedBy    *int64 `json:"deployed_by,omitempty"`
+	ApplicationID int64   `json:"application_id"`
+	Branch        string  `json:"branch"`
+	Ref           *string `json:"ref,omitempty"`
+	DeployedBy    *int64  `json:"deployed_by,omitempty"`
+	RollbackOf    *int64  `json:"rollback_of,omitempty"`
+
+	// Known up front when a push triggered the deploy, otherwise the agent
+	// reports the commit after checking it out.
+	CommitHash    *string `json:"commit_hash,omitempty"`
+	CommitMessage *string `json:"commit_message,omitempty"`
+	PushedBy      *string `json:"pushed_by,omitempty"`
+
+	Image *string `json:"image,omitempty"`
 }
 
 type DeploymentCommitInfoRequest = struct {
@@ -59,6 +105,7 @@ type DeploymentLogsRequest struct {
 type DeploymentRepository interface {
 	List(ctx context.Context, opts DeploymentListOptions) ([]*Deployment, int64, error)
 	GetByID(ctx context.Context, deploymentID int64) (*Deployment, error)
+	GetLastSuccessful(ctx context.Context, appID int64, beforeID int64) (*Deployment, error)
 	Create(ctx context.Context, deployment *Deployment) (*Deployment, error)
 	Start(ctx context.Context, deploymentID int64) (*Deployment, error)
 	Finish(ctx context.Context, deploymentID int64) (*Deployment, error)
@@ -69,6 +116,9 @@ type DeploymentRepository interface {
 type DeploymentService interface {
 	List(ctx context.Context, opts DeploymentListOptions) (*ListResult[*Deployment], error)
 	GetByID(ctx context.Context, deploymentID int64) (*Deployment, error)
+	// GetLastSuccessful returns the newest successful deployment of appID
+	// created before beforeID that recorded its commit or image.
+	GetLastSuccessful(ctx context.Context, appID int64, beforeID int64) (*Deployment, error)
 	Create(ctx context.Context, req DeploymentCreateRequest) (*Deployment, error)
 	Start(ctx context.Context, deploymentID int64) error
 	Finish(ctx context.Context, deploymentID int64) error
@@ -5,7 +5,8 @@ import "time"
 type EventDeploymentCreated struct {
 	DeploymentID  int64     `json:"deployment_id"`
 	ApplicationID int64     `json:"application_id"`
-	DeployedBy    int64     `json:"deployed_by"`
+	DeployedBy    *int64    `json:"deployed_by,omitempty"`
+	RollbackOf    *int64    `json:"rollback_of,omitempty"`
 	TriggeredAt   time.Time `json:"triggered_at"`
 }
 
@@ -34,3 +35,14 @@ type EventDeploymentCommitInfoReceived struct {
 	CommitHash    string `json:"commit_hash"`
 	CommitMessage string `json:"commit_message"`
 }
+
+// EventDeploymentRolledBack carries the commit of a git application or the
+// image of an image application that is deployed again.
+type EventDeploymentRolledBack struct {
+	DeploymentID  int64          `json:"deployment_id"`
+	ApplicationID int64          `json:"application_id"`
+	RollbackID    int64          `json:"rollback_id"`
+	CommitHash    string         `json:"commit_hash,omitempty"`
+	Image         string         `json:"image,omitempty"`
+	Reason        RollbackReason `json:"reason"`
+}
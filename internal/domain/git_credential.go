@@ -0,0 +1,86 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	ErrGitCredentialNotFound = errors.New("git credential not found")
+	ErrGitCredentialInUse    = errors.New("git credential is used by an application")
+	ErrGitCredentialExists   = errors.New("a git credential with this name already exists")
+	ErrInvalidGitCredential  = errors.New("invalid git credential")
+	ErrSecretsDisabled       = errors.New("no secret key is configured, set SECRET_KEY or SECRET_KEY_FILE")
+)
+
+type GitCredentialType string
+
+const (
+	GitCredentialSSHKey     GitCredentialType = "ssh_key"
+	GitCredentialHTTPSToken GitCredentialType = "https_token"
+)
+
+// GitCredential lets agents clone private repositories. The secret, an SSH
+// private key or an HTTPS token, is stored encrypted and never returned.
+// PublicKey is derived from an SSH key so it can be added as a deploy key.
+type GitCredential struct {
+	ID         int64             `json:"id"`
+	Name       string            `json:"name"`
+	Type       GitCredentialType `json:"type"`
+	Username   string            `json:"username,omitempty"`
+	PublicKey  string            `json:"public_key,omitempty"`
+	KnownHosts string            `json:"known_hosts,omitempty"`
+	CreatedBy  *int64            `json:"created_by,omitempty"`
+	CreatedAt  time.Time         `json:"created_at"`
+	UpdatedAt  time.Time         `json:"updated_at"`
+
+	EncryptedSecret []byte `json:"-"`
+}
+
+type GitCredentialCreateRequest struct {
+	Name       string            `json:"name" validate:"required,min=3,max=100"`
+	Type       GitCredentialType `json:"type" validate:"required,oneof=ssh_key https_token"`
+	Username   string            `json:"username" validate:"required_if=Type https_token,max=255"`
+	Secret     string            `json:"secret" validate:"required,max=16384"`
+	KnownHosts string            `json:"known_hosts" validate:"required_if=Type ssh_key,max=16384"`
+}
+
+// GitCredentialUpdateRequest keeps the stored secret when Secret is empty.
+type GitCredentialUpdateRequest struct {
+	Name       string `json:"name" validate:"required,min=3,max=100"`
+	Username   string `json:"username" validate:"max=255"`
+	Secret     string `json:"secret" validate:"omitempty,max=16384"`
+	KnownHosts string `json:"known_hosts" validate:"omitempty,max=16384"`
+}
+
+// GitAuth is a decrypted credential, it only travels inside deploy job
+// payloads.
+type GitAuth struct {
+	Type       GitCredentialType `json:"type"`
+	Username   string            `json:"username,omitempty"`
+	Secret     string            `json:"secret"`
+	KnownHosts string            `json:"known_hosts,omitempty"`
+}
+
+type GitCredentialRepository interface {
+	List(ctx context.Context) ([]*GitCredential, error)
+	GetByID(ctx context.Context, credentialID int64) (*GitCredential, error)
+	// NextID reserves the id of a credential about to be created, its
+	// secret is sealed for that id before the row exists.
+	NextID(ctx context.Context) (int64, error)
+	Create(ctx context.Context, c *GitCredential) (*GitCredential, error)
+	Update(ctx context.Context, c *GitCredential) error
+	Delete(ctx context.Context, credentialID int64) error
+}
+
+type GitCredentialService interface {
+	List(ctx context.Context) ([]*GitCredential, error)
+	GetByID(ctx context.Context, credentialID int64) (*GitCredential, error)
+	Create(ctx context.Context, req GitCredentialCreateRequest, createdBy int64) (*GitCredential, error)
+	Update(ctx context.Context, credentialID int64, req GitCredentialUpdateRequest) (*GitCredential, error)
+	Delete(ctx context.Context, credentialID int64) error
+
+	// Resolve decrypts a credential for a deploy job.
+	Resolve(ctx context.Context, credentialID int64) (*GitAuth, error)
+}
@@ -0,0 +1,67 @@
+package domain
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// GitPollDueLimit caps the number of applications polled per worker tick.
+const GitPollDueLimit = 200
+
+// GitRemoteTimeout bounds a single git ls-remote run on the agent.
+const GitRemoteTimeout = 30 * time.Second
+
+// GitPollState is what the poller remembers of an application remote.
+// RemoteHash is the last head seen and RemoteChangedAt when it first showed
+// up, a deploy waits until it has stayed the head for the debounce.
+// TriggeredHash is the last head a deploy was started for, so a failed
+// deploy is not retried on every poll.
+type GitPollState struct {
+	ApplicationID   int64
+	RemoteHash      string
+	RemoteChangedAt *time.Time
+	TriggeredHash   string
+	PolledAt        *time.Time
+}
+
+// GitRemoteHead is the answer of an agent for one poll target. Error is set
+// instead of CommitHash when the remote could not be read.
+type GitRemoteHead struct {
+	ApplicationID int64  `json:"application_id"`
+	CommitHash    string `json:"commit_hash,omitempty"`
+	Error         string `json:"error,omitempty"`
+}
+
+// SameCommit reports whether two commit hashes name the same commit. Hashes
+// stored before full SHAs were kept have 8 characters, one of those matches
+// the full SHA it starts.
+func SameCommit(a, b string) bool {
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	if a == "" {
+		return b == ""
+	}
+
+	return strings.HasPrefix(b, a)
+}
+
+type GitPollRepository interface {
+	// ClaimDue returns the applications with polling enabled whose interval
+	// elapsed and marks them polled at now.
+	ClaimDue(ctx context.Context, now time.Time, limit int) ([]int64, error)
+	Get(ctx context.Context, appID int64) (*GitPollState, error)
+	Save(ctx context.Context, state *GitPollState) error
+}
+
+type GitPollService interface {
+	// PollDue sends a poll job to the server of every due application and
+	// returns how many applications were polled.
+	PollDue(ctx context.Context) (int, error)
+	// HandleHeads compares the heads reported by a server with the deployed
+	// commits and deploys the ones that moved.
+	HandleHeads(ctx context.Context, serverID uuid.UUID, heads []GitRemoteHead) error
+}
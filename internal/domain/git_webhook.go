@@ -0,0 +1,74 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrWebhookDisabled         = errors.New("webhook is not enabled for this application")
+	ErrInvalidWebhookSignature = errors.New("invalid webhook signature")
+	ErrUnknownWebhookProvider  = errors.New("unknown webhook provider")
+	ErrInvalidWebhookPayload   = errors.New("invalid webhook payload")
+	ErrWebhookEventIgnored     = errors.New("webhook event ignored")
+)
+
+type GitProvider string
+
+const (
+	GitProviderGitHub GitProvider = "github"
+	GitProviderGitLab GitProvider = "gitlab"
+	GitProviderGitea  GitProvider = "gitea"
+)
+
+// GitWebhook is a delivery as received, before its signature is checked.
+// Signature holds whatever the provider sends to prove the secret: an HMAC
+// of the body for GitHub and Gitea, the secret itself for GitLab.
+type GitWebhook struct {
+	Provider   GitProvider
+	Event      string
+	DeliveryID string
+	Signature  string
+	Body       []byte
+}
+
+// GitPush is the part of a push payload a deploy needs. CommitHash is empty
+// when the push deleted the branch, Branch is empty for tag pushes.
+type GitPush struct {
+	Provider      GitProvider `json:"provider"`
+	Branch        string      `json:"branch"`
+	CommitHash    string      `json:"commit_hash"`
+	CommitMessage string      `json:"commit_message"`
+	Pusher        string      `json:"pusher"`
+}
+
+// GitWebhookSecret is returned once when a webhook is enabled or its secret
+// rotated, it cannot be read back afterwards. Path is relative to the API
+// address the provider reaches.
+type GitWebhookSecret struct {
+	Path   string `json:"path"`
+	Secret string `json:"secret"`
+}
+
+func GitWebhookPath(appID int64) string {
+	return fmt.Sprintf("/webhooks/git/%d", appID)
+}
+
+// GitWebhookResult tells the provider what a delivery did. Ignored explains
+// why a valid delivery did not deploy.
+type GitWebhookResult struct {
+	Deployment *Deployment `json:"deployment,omitempty"`
+	Ignored    string      `json:"ignored,omitempty"`
+}
+
+type GitWebhookService interface {
+	// Enable creates a new secret for the application webhook, replacing
+	// the previous one.
+	Enable(ctx context.Context, appID int64) (*GitWebhookSecret, error)
+	Disable(ctx context.Context, appID int64) error
+
+	// Receive verifies a delivery and deploys the pushed commit when it
+	// landed on the application branch.
+	Receive(ctx context.Context, appID int64, hook GitWebhook) (*GitWebhookResult, error)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"slices"
 	"time"
 
 	"github.com/google/uuid"
@@ -12,6 +13,7 @@ import (
 var (
 	ErrJobNotFound     = errors.New("job not found")
 	ErrInvalidJobState = errors.New("invalid job state")
+	ErrJobTimeout      = errors.New("job timed out")
 )
 
 type (
@@ -24,18 +26,48 @@ const (
 	JobTypeAppStart       JobType = "app_start"
 	JobTypeAppStop        JobType = "app_stop"
 	JobTypeAppRestart     JobType = "app_restart"
+	JobTypeAppCommand     JobType = "app_command"
 	JobTypeAppHealthCheck JobType = "app_health_check"
+	JobTypeAppGitPoll     JobType = "app_git_poll"
 	JobTypeMetricsCollect JobType = "metrics_collect"
 )
 
 const (
-	JobQueued  JobStatus = "queued"
-	JobRunning JobStatus = "running"
-	JobSuccess JobStatus = "success"
-	JobFailed  JobStatus = "failed"
-	JobExpired JobStatus = "expired"
+	JobQueued    JobStatus = "queued"
+	JobRunning   JobStatus = "running"
+	JobSuccess   JobStatus = "success"
+	JobFailed    JobStatus = "failed"
+	JobExpired   JobStatus = "expired"
+	JobCancelled JobStatus = "cancelled"
 )
 
+const (
+	// JobLeaseDuration is how long a claimed job stays owned by an agent
+	// without a heartbeat before the reaper takes it back.
+	JobLeaseDuration = 2 * time.Minute
+	// JobHeartbeatInterval is how often agents extend the lease of running jobs.
+	JobHeartbeatInterval = 30 * time.Second
+	// JobClaimLimit caps the number of jobs handed out per claim request.
+	JobClaimLimit = 30
+)
+
+// SerializedJobTypes change the containers of an application. An agent is
+// handed at most one of them per application at a time, in queue order.
+var SerializedJobTypes = []JobType{
+	JobTypeAppDeploy,
+	JobTypeAppStart,
+	JobTypeAppStop,
+	JobTypeAppRestart,
+}
+
+// LightJobTypes only read state. Agents run them in a lane of their own so
+// they never wait behind a long build.
+var LightJobTypes = []JobType{
+	JobTypeMetricsCollect,
+	JobTypeAppHealthCheck,
+	JobTypeAppGitPoll,
+}
+
 type Job struct {
 	ID            int64           `json:"id"`
 	TraceID       uuid.UUID       `json:"trace_id"`
@@ -50,6 +82,16 @@ type Job struct {
 	FinishedAt    *time.Time      `json:"finished_at"`
 	ExpiredAt     *time.Time      `json:"expired_at"`
 
+	LeaseExpiresAt *time.Time `json:"lease_expires_at"`
+	HeartbeatAt    *time.Time `json:"heartbeat_at"`
+
+	Attempt     int        `json:"attempt"`
+	MaxAttempts int        `json:"max_attempts"`
+	NextRunAt   *time.Time `json:"next_run_at"`
+	RetryOfID   *int64     `json:"retry_of_id"`
+
+	TimeoutSeconds int `json:"timeout_seconds"`
+
 	Logs []Log `json:"logs,omitempty"`
 }
 
@@ -63,28 +105,173 @@ type JobListOptions struct {
 	Statuses      []string   `json:"statuses,omitempty"`
 }
 
+// JobClaimRequest carries the free slots of the agent, Limit in its main
+// pool and LightLimit in its lane for LightJobTypes. Without it up to
+// JobClaimLimit jobs of each are handed out.
+type JobClaimRequest struct {
+	Limit      int `json:"limit" validate:"min=0,max=30"`
+	LightLimit int `json:"light_limit" validate:"min=0,max=30"`
+}
+
 type JobFinishRequest struct {
 	Status JobStatus `json:"status"`
 }
 
 type JobRepository interface {
 	List(ctx context.Context, opts JobListOptions) ([]*Job, int64, error)
-	GetPending(ctx context.Context, serverID uuid.UUID) ([]*Job, error)
 	GetByID(ctx context.Context, jobID int64) (*Job, error)
 	Create(ctx context.Context, j *Job) (*Job, error)
 	Delete(ctx context.Context, jobID int64) error
-	Retry(ctx context.Context, jobID int64, j *Job) (*Job, error)
-	MarkRunning(ctx context.Context, jobID int64) (*Job, error)
-	MarkFinished(ctx context.Context, jobID int64, status JobStatus) (*Job, error)
+	ListAttempts(ctx context.Context, rootID int64) ([]*Job, error)
+	DeleteFinishedBefore(ctx context.Context, jobType JobType, before time.Time) (int64, error)
+	// MarkFinished only finishes a job running on serverID. changed is false
+	// when the job was already finished, e.g. a repeated finish.
+	MarkFinished(ctx context.Context, jobID int64, serverID uuid.UUID, status JobStatus) (job *Job, changed bool, err error)
+	MarkCancelled(ctx context.Context, jobID int64) (*Job, error)
+	// CreateUnlessDeploying creates a job of an application unless a deploy
+	// of it is queued or running, ErrDeploymentInProgress then.
+	CreateUnlessDeploying(ctx context.Context, j *Job) (*Job, error)
+	// CreateDeploy creates the deploy job j and, in the same transaction,
+	// cancels the deploy jobs still queued for its application and marks
+	// their deployments superseded by j's. The cancelled jobs are returned.
+	CreateDeploy(ctx context.Context, j *Job) (*Job, []*Job, error)
+	// Claim leases up to limit queued jobs and up to lightLimit queued
+	// LightJobTypes to serverID.
+	Claim(ctx context.Context, serverID uuid.UUID, limit int, lightLimit int, lease time.Duration) ([]*Job, error)
+	Heartbeat(ctx context.Context, jobID int64, serverID uuid.UUID, lease time.Duration) (*Job, error)
+	ListExpiredLeases(ctx context.Context) ([]*Job, error)
+	ReleaseLease(ctx context.Context, jobID int64, requeue bool) (*Job, error)
 }
 
 type JobService interface {
 	List(ctx context.Context, opts JobListOptions) (*ListResult[*Job], error)
-	GetPending(ctx context.Context, serverID uuid.UUID) ([]*Job, error)
 	GetByID(ctx context.Context, jobID int64) (*Job, error)
 	Create(ctx context.Context, j *Job) (*Job, error)
 	Delete(ctx context.Context, jobID int64) error
-	Retry(ctx context.Context, jobID int64, j *Job) (*Job, error)
-	Start(ctx context.Context, jobID int64) (*Job, error)
-	Finish(ctx context.Context, jobID int64, status JobStatus) (*Job, error)
+	ListAttempts(ctx context.Context, jobID int64) ([]*Job, error)
+	Prune(ctx context.Context, jobType JobType, before time.Time) (int64, error)
+	Finish(ctx context.Context, jobID int64, serverID uuid.UUID, status JobStatus) (*Job, error)
+	Cancel(ctx context.Context, jobID int64, cancelledBy int64) (*Job, error)
+	// CreateUnlessDeploying creates a lifecycle job of an application,
+	// ErrDeploymentInProgress while a deploy of it is queued or running.
+	CreateUnlessDeploying(ctx context.Context, j *Job) (*Job, error)
+	// CreateDeploy creates the deploy job j and cancels the deploy jobs of
+	// its application that are still queued, j replaces them.
+	CreateDeploy(ctx context.Context, j *Job) (*Job, []*Job, error)
+	// Claim leases up to limit queued jobs and up to lightLimit queued
+	// LightJobTypes to serverID, each capped at JobClaimLimit.
+	Claim(ctx context.Context, serverID uuid.UUID, limit int, lightLimit int) ([]*Job, error)
+	Heartbeat(ctx context.Context, jobID int64, serverID uuid.UUID) (*Job, error)
+	ReapExpiredLeases(ctx context.Context) (int, error)
+}
+
+// JobSecretResolver fills in the secrets a stored job payload only
+// references, for the agent that claimed the job.
+type JobSecretResolver interface {
+	Resolve(ctx context.Context, job *Job) error
+}
+
+// IsLight reports whether the job type is one of LightJobTypes.
+func (t JobType) IsLight() bool {
+	return slices.Contains(LightJobTypes, t)
+}
+
+// RequeueOnLeaseExpiry reports whether a job of this type can safely be
+// handed to an agent again when its previous owner stopped heartbeating.
+func (t JobType) RequeueOnLeaseExpiry() bool {
+	switch t {
+	case JobTypeMetricsCollect, JobTypeAppHealthCheck, JobTypeAppGitPoll:
+		return true
+	default:
+		return false
+	}
+}
+
+// JobRetryPolicy describes how often a failed job is attempted again and how
+// long to wait between attempts.
+type JobRetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+// Backoff returns the delay before the attempt following the given one,
+// doubling BaseDelay for every attempt already made and capping at MaxDelay.
+func (p JobRetryPolicy) Backoff(attempt int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if delay >= p.MaxDelay {
+			return p.MaxDelay
+		}
+	}
+
+	return min(delay, p.MaxDelay)
+}
+
+// RetryPolicy returns the retry policy for the job type. Metrics and health
+// check jobs are never retried since the scheduler enqueues a fresh one on
+// its next tick anyway.
+func (t JobType) RetryPolicy() JobRetryPolicy {
+	switch t {
+	case JobTypeAppDeploy:
+		return JobRetryPolicy{MaxAttempts: 3, BaseDelay: 30 * time.Second, MaxDelay: 5 * time.Minute}
+	case JobTypeAppStart, JobTypeAppStop, JobTypeAppRestart:
+		return JobRetryPolicy{MaxAttempts: 3, BaseDelay: 10 * time.Second, MaxDelay: 2 * time.Minute}
+	default:
+		return JobRetryPolicy{MaxAttempts: 1}
+	}
+}
+
+// CanRetry reports whether a finished attempt with the given status should be
+// followed by another one.
+func (j *Job) CanRetry(status JobStatus) bool {
+	if status != JobFailed && status != JobExpired {
+		return false
+	}
+
+	return j.Attempt < j.MaxAttempts
+}
+
+// DefaultTimeout is how long the agent lets a job of this type run before
+// killing it, unless the application overrides it.
+func (t JobType) DefaultTimeout() time.Duration {
+	switch t {
+	case JobTypeAppDeploy:
+		return 30 * time.Minute
+	case JobTypeAppStart, JobTypeAppStop, JobTypeAppRestart:
+		return 5 * time.Minute
+	case JobTypeAppCommand:
+		return 15 * time.Minute
+	case JobTypeAppGitPoll:
+		return 5 * time.Minute
+	default:
+		return time.Minute
+	}
+}
+
+// Timeout returns the deadline the agent enforces for the job.
+func (j *Job) Timeout() time.Duration {
+	if j.TimeoutSeconds > 0 {
+		return time.Duration(j.TimeoutSeconds) * time.Second
+	}
+
+	return j.Type.DefaultTimeout()
+}
+
+type traceIDContextKey struct{}
+
+// WithTraceID makes jobs created with the returned context share traceID, so
+// a caller can link the jobs it enqueues back to its own records.
+func WithTraceID(ctx context.Context, traceID uuid.UUID) context.Context {
+	return context.WithValue(ctx, traceIDContextKey{}, traceID)
+}
+
+// TraceIDFromContext returns the trace ID set by WithTraceID, or a new one.
+func TraceIDFromContext(ctx context.Context) uuid.UUID {
+	if traceID, ok := ctx.Value(traceIDContextKey{}).(uuid.UUID); ok {
+		return traceID
+	}
+
+	return uuid.New()
 }
@@ -15,6 +15,13 @@ type EventJobCreated struct {
 	Type          JobType   `json:"type"`
 }
 
+type EventJobQueued struct {
+	JobID    int64     `json:"job_id"`
+	TraceID  uuid.UUID `json:"trace_id"`
+	ServerID uuid.UUID `json:"server_id"`
+	Type     JobType   `json:"type"`
+}
+
 type EventJobStarted struct {
 	JobID         int64     `json:"job_id"`
 	TraceID       uuid.UUID `json:"trace_id"`
@@ -32,6 +39,18 @@ type EventJobFinished struct {
 	DeploymentID  *int64    `json:"deployment_id"`
 	Type          JobType   `json:"type"`
 	Status        JobStatus `json:"status"`
+	Attempt       int       `json:"attempt"`
+	WillRetry     bool      `json:"will_retry"`
+}
+
+type EventJobCancelled struct {
+	JobID         int64     `json:"job_id"`
+	TraceID       uuid.UUID `json:"trace_id"`
+	ServerID      uuid.UUID `json:"server_id"`
+	ApplicationID *int64    `json:"application_id"`
+	DeploymentID  *int64    `json:"deployment_id"`
+	Type          JobType   `json:"type"`
+	CancelledBy   int64     `json:"cancelled_by"`
 }
 
 type EventJobStatusChanged struct {
@@ -1,13 +1,100 @@
 package domain
 
-import "github.com/google/uuid"
+import (
+	"encoding/json"
 
+	"github.com/google/uuid"
+)
+
+// DeployAppPayload is stored with references only: credential IDs and env
+// var keys. GitAuth, EnvVars and RegistryAuth are filled in when an agent
+// claims the job and never written to the jobs table.
 type DeployAppPayload struct {
 	ApplicationID int64             `json:"application_id"`
 	DeploymentID  int64             `json:"deployment_id"`
 	RepoURL       string            `json:"repo_url"`
 	Branch        string            `json:"branch"`
+	Ref           string            `json:"ref,omitempty"`
+	GitAuth       *GitAuth          `json:"git_auth,omitempty"`
 	EnvVars       map[string]string `json:"env_vars,omitempty"`
+	Strategy      DeployStrategy    `json:"strategy,omitempty"`
+	Compose       ComposeConfig     `json:"compose"`
+
+	GitCredentialID *int64   `json:"git_credential_id,omitempty"`
+	EnvKeys         []string `json:"env_keys,omitempty"`
+
+	// Image applications only, the agent writes ComposeFile instead of
+	// cloning and pulls the images with RegistryAuth.
+	Source       ApplicationSource `json:"source,omitempty"`
+	ComposeFile  string            `json:"compose_file,omitempty"`
+	Image        string            `json:"image,omitempty"`
+	RegistryAuth *RegistryAuth     `json:"registry_auth,omitempty"`
+
+	RegistryCredentialID *int64 `json:"registry_credential_id,omitempty"`
+}
+
+// RedactSecrets drops the credentials of deploy and git poll payloads and
+// masks the env var values of deploys before the job is shown to users, only
+// agents get to see them.
+func (j *Job) RedactSecrets() {
+	if len(j.Payload) == 0 {
+		return
+	}
+
+	var (
+		redacted []byte
+		err      error
+	)
+
+	switch j.Type {
+	case JobTypeAppDeploy:
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(j.Payload, &fields); err != nil {
+			return
+		}
+
+		_, hasGit := fields["git_auth"]
+		_, hasRegistry := fields["registry_auth"]
+		_, hasEnv := fields["env_vars"]
+		if !hasGit && !hasRegistry && !hasEnv {
+			return
+		}
+		delete(fields, "git_auth")
+		delete(fields, "registry_auth")
+
+		if hasEnv {
+			var env map[string]string
+			if json.Unmarshal(fields["env_vars"], &env) != nil {
+				delete(fields, "env_vars")
+			} else {
+				for key := range env {
+					env[key] = EnvValueMask
+				}
+				fields["env_vars"], _ = json.Marshal(env)
+			}
+		}
+
+		redacted, err = json.Marshal(fields)
+
+	case JobTypeAppGitPoll:
+		var payload AppGitPollPayload
+		if err := json.Unmarshal(j.Payload, &payload); err != nil {
+			return
+		}
+
+		for i := range payload.Targets {
+			payload.Targets[i].GitAuth = nil
+		}
+
+		redacted, err = json.Marshal(payload)
+
+	default:
+		return
+	}
+
+	if err == nil {
+		j.Payload = redacted
+	}
 }
 
 type StartAppPayload struct {
@@ -22,7 +109,28 @@ type RestartAppPayload struct {
 	ApplicationID int64 `json:"application_id"`
 }
 
+type RunCommandAppPayload struct {
+	ApplicationID int64  `json:"application_id"`
+	Service       string `json:"service"`
+	Command       string `json:"command"`
+}
+
 type AppHealthCheckPayload struct {
 	ServerID        uuid.UUID `json:"server_id"`
 	ApplicationsIDs []int64   `json:"application_ids"`
 }
+
+// AppGitPollPayload asks an agent for the head commit of each target branch.
+type AppGitPollPayload struct {
+	Targets []GitPollTarget `json:"targets"`
+}
+
+// GitPollTarget is stored with GitCredentialID only, GitAuth is filled in
+// when an agent claims the job.
+type GitPollTarget struct {
+	ApplicationID   int64    `json:"application_id"`
+	RepoURL         string   `json:"repo_url"`
+	Branch          string   `json:"branch"`
+	GitAuth         *GitAuth `json:"git_auth,omitempty"`
+	GitCredentialID *int64   `json:"git_credential_id,omitempty"`
+}
@@ -36,16 +36,30 @@ const (
 	ActionAppStart       LogAction = "app_start"
 	ActionAppStop        LogAction = "app_stop"
 	ActionAppRestart     LogAction = "app_restart"
+	ActionAppCommand     LogAction = "app_command"
 	ActionAppHealthCheck LogAction = "app_health_check"
+	ActionAppGitPoll     LogAction = "app_git_poll"
+	ActionAppEnvReveal   LogAction = "app_env_reveal"
 )
 
 const (
 	StepGitClone          LogStep = "git_clone"
+	StepGitLsRemote       LogStep = "git_ls_remote"
 	StepBuildPrepare      LogStep = "build_prepare"
+	StepManifestRead      LogStep = "manifest_read"
+	StepPreDeploy         LogStep = "pre_deploy"
+	StepPostDeploy        LogStep = "post_deploy"
+	StepHealthCheckURL    LogStep = "health_check_url"
 	StepDockerBuild       LogStep = "docker_build"
+	StepDockerLogin       LogStep = "docker_login"
+	StepDockerPull        LogStep = "docker_pull"
+	StepDockerSwap        LogStep = "docker_swap"
+	StepDockerHealthWait  LogStep = "docker_health_wait"
+	StepDockerTeardown    LogStep = "docker_teardown"
 	StepDockerStart       LogStep = "docker_start"
 	StepDockerStop        LogStep = "docker_stop"
 	StepDockerRestart     LogStep = "docker_restart"
+	StepDockerExec        LogStep = "docker_exec"
 	StepDockerHealthCheck LogStep = "docker_health_check"
 )
 
@@ -111,12 +125,17 @@ type LogEmitRequest struct {
 	Context *LogContext `json:"context"`
 }
 
+// LogBatchLimit caps the number of log lines accepted in one batch request.
+const LogBatchLimit = 1000
+
 type LogRepository interface {
 	List(ctx context.Context, opts LogListOptions) ([]*Log, int64, error)
 	Create(ctx context.Context, l *Log) (*Log, error)
+	CreateBatch(ctx context.Context, logs []*Log) error
 }
 
 type LogService interface {
 	List(ctx context.Context, opts LogListOptions) (*ListResult[*Log], error)
 	Create(ctx context.Context, l *Log) (*Log, error)
+	CreateBatch(ctx context.Context, logs []*Log) error
 }
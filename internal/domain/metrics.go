@@ -28,6 +28,8 @@ type Metrics struct {
 	Network       NetworkMetric `json:"network"`
 	UptimeSeconds float64       `json:"uptime_seconds"`
 	RecordedAt    time.Time     `json:"recorded_at"`
+
+	Containers []ContainerMetric `json:"containers"`
 }
 
 type Signal struct {
@@ -102,6 +104,44 @@ type NetworkMetric struct {
 	TXSpeedMBs Signal `json:"tx_speed_mbs"`
 }
 
+// ContainerMetric is the resource usage of one container of a HorizonX
+// managed compose project. CPUPercent follows docker stats and is relative to
+// a single core. MemoryLimitBytes is 0 when the container has no limit, on
+// hosts without cgroup v2 docker reports the host memory instead.
+type ContainerMetric struct {
+	ApplicationID int64  `json:"application_id"`
+	Service       string `json:"service"`
+	Container     string `json:"container"`
+
+	CPUPercent       float64 `json:"cpu_percent"`
+	MemoryBytes      uint64  `json:"memory_bytes"`
+	MemoryLimitBytes uint64  `json:"memory_limit_bytes"`
+
+	RXBytes    uint64  `json:"rx_bytes"`
+	TXBytes    uint64  `json:"tx_bytes"`
+	RXSpeedMBs float64 `json:"rx_speed_mbs"`
+	TXSpeedMBs float64 `json:"tx_speed_mbs"`
+
+	BlockReadBytes  uint64  `json:"block_read_bytes"`
+	BlockWriteBytes uint64  `json:"block_write_bytes"`
+	BlockReadMBps   float64 `json:"block_read_mbps"`
+	BlockWriteMBps  float64 `json:"block_write_mbps"`
+}
+
+// ApplicationMetrics groups the container metrics of one application from a
+// single agent sample.
+type ApplicationMetrics struct {
+	ApplicationID int64             `json:"application_id"`
+	ServerID      uuid.UUID         `json:"server_id"`
+	Containers    []ContainerMetric `json:"containers"`
+	RecordedAt    time.Time         `json:"recorded_at"`
+}
+
+type MemoryUsageSample struct {
+	UsedBytes uint64    `json:"used_bytes"`
+	At        time.Time `json:"at"`
+}
+
 type CPUUsageSample struct {
 	UsagePercent float64   `json:"usage_percent"`
 	At           time.Time `json:"at"`
@@ -118,6 +158,12 @@ type MetricsService interface {
 	Latest(serverID uuid.UUID) (*Metrics, error)
 	CPUUsageHistory(serverID uuid.UUID) ([]CPUUsageSample, error)
 	NetSpeedHistory(serverID uuid.UUID) ([]NetworkSpeedSample, error)
+
+	AppLatest(appID int64) (*ApplicationMetrics, error)
+	AppCPUUsageHistory(appID int64) ([]CPUUsageSample, error)
+	AppMemoryUsageHistory(appID int64) ([]MemoryUsageSample, error)
+	AppNetSpeedHistory(appID int64) ([]NetworkSpeedSample, error)
+
 	Cleanup(ctx context.Context, serverID uuid.UUID, cutoff time.Time) error
 }
 
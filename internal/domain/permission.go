@@ -18,4 +18,7 @@ const (
 
 	PermAppRead  PermissionConst = "app_read"
 	PermAppWrite PermissionConst = "app_write"
+
+	// PermAppSecretsRead reveals env var values in plain text.
+	PermAppSecretsRead PermissionConst = "app_secrets_read"
 )
@@ -0,0 +1,75 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	ErrRegistryCredentialNotFound = errors.New("registry credential not found")
+	ErrRegistryCredentialInUse    = errors.New("registry credential is used by an application")
+	ErrRegistryCredentialExists   = errors.New("a registry credential with this name already exists")
+)
+
+// RegistryCredential lets agents pull private images of image applications.
+// The password or token is stored encrypted and never returned.
+type RegistryCredential struct {
+	ID        int64     `json:"id"`
+	Name      string    `json:"name"`
+	Registry  string    `json:"registry"`
+	Username  string    `json:"username"`
+	CreatedBy *int64    `json:"created_by,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	EncryptedPassword []byte `json:"-"`
+}
+
+// RegistryCredentialCreateRequest takes the registry host as docker login
+// does, such as ghcr.io or registry.example.com:5000.
+type RegistryCredentialCreateRequest struct {
+	Name     string `json:"name" validate:"required,min=3,max=100"`
+	Registry string `json:"registry" validate:"required,max=255,hostname_port|hostname"`
+	Username string `json:"username" validate:"required,max=255"`
+	Password string `json:"password" validate:"required,max=16384"`
+}
+
+// RegistryCredentialUpdateRequest keeps the stored password when Password is
+// empty.
+type RegistryCredentialUpdateRequest struct {
+	Name     string `json:"name" validate:"required,min=3,max=100"`
+	Registry string `json:"registry" validate:"required,max=255,hostname_port|hostname"`
+	Username string `json:"username" validate:"required,max=255"`
+	Password string `json:"password" validate:"omitempty,max=16384"`
+}
+
+// RegistryAuth is a decrypted credential, it only travels inside deploy job
+// payloads.
+type RegistryAuth struct {
+	Registry string `json:"registry"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+type RegistryCredentialRepository interface {
+	List(ctx context.Context) ([]*RegistryCredential, error)
+	GetByID(ctx context.Context, credentialID int64) (*RegistryCredential, error)
+	// NextID reserves the id of a credential about to be created, its
+	// secret is sealed for that id before the row exists.
+	NextID(ctx context.Context) (int64, error)
+	Create(ctx context.Context, c *RegistryCredential) (*RegistryCredential, error)
+	Update(ctx context.Context, c *RegistryCredential) error
+	Delete(ctx context.Context, credentialID int64) error
+}
+
+type RegistryCredentialService interface {
+	List(ctx context.Context) ([]*RegistryCredential, error)
+	GetByID(ctx context.Context, credentialID int64) (*RegistryCredential, error)
+	Create(ctx context.Context, req RegistryCredentialCreateRequest, createdBy int64) (*RegistryCredential, error)
+	Update(ctx context.Context, credentialID int64, req RegistryCredentialUpdateRequest) (*RegistryCredential, error)
+	Delete(ctx context.Context, credentialID int64) error
+
+	// Resolve decrypts a credential for a deploy job.
+	Resolve(ctx context.Context, credentialID int64) (*RegistryAuth, error)
+}
@@ -0,0 +1,85 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrScheduleNotFound = errors.New("schedule not found")
+	ErrInvalidSchedule  = errors.New("invalid schedule")
+)
+
+type ScheduleAction string
+
+const (
+	ScheduleActionDeploy  ScheduleAction = "deploy"
+	ScheduleActionStart   ScheduleAction = "start"
+	ScheduleActionStop    ScheduleAction = "stop"
+	ScheduleActionRestart ScheduleAction = "restart"
+	ScheduleActionCommand ScheduleAction = "command"
+)
+
+// ScheduleDueLimit caps the number of schedules triggered per worker tick.
+const ScheduleDueLimit = 100
+
+// Schedule runs an action against an application whenever its cron
+// expression matches, evaluated in the control plane time zone.
+type Schedule struct {
+	ID             int64          `json:"id"`
+	ApplicationID  int64          `json:"application_id"`
+	Name           string         `json:"name"`
+	CronExpression string         `json:"cron_expression"`
+	Action         ScheduleAction `json:"action"`
+	Service        string         `json:"service,omitempty"`
+	Command        string         `json:"command,omitempty"`
+	Enabled        bool           `json:"enabled"`
+	CreatedBy      *int64         `json:"created_by,omitempty"`
+
+	LastRunAt   *time.Time `json:"last_run_at"`
+	LastTraceID *uuid.UUID `json:"last_trace_id"`
+	LastError   *string    `json:"last_error"`
+	NextRunAt   *time.Time `json:"next_run_at"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type ScheduleRequest struct {
+	Name           string         `json:"name" validate:"required,min=3,max=100"`
+	CronExpression string         `json:"cron_expression" validate:"required,max=100"`
+	Action         ScheduleAction `json:"action" validate:"required,oneof=deploy start stop restart command"`
+	Service        string         `json:"service" validate:"required_if=Action command,max=100"`
+	Command        string         `json:"command" validate:"required_if=Action command,max=4096"`
+	Enabled        *bool          `json:"enabled"`
+}
+
+type ScheduleRepository interface {
+	List(ctx context.Context, appID int64) ([]*Schedule, error)
+	GetByID(ctx context.Context, appID int64, scheduleID int64) (*Schedule, error)
+	Create(ctx context.Context, s *Schedule) (*Schedule, error)
+	Update(ctx context.Context, s *Schedule) error
+	Delete(ctx context.Context, appID int64, scheduleID int64) error
+
+	// ListDue returns enabled schedules of live applications whose next run
+	// is at or before now.
+	ListDue(ctx context.Context, now time.Time, limit int) ([]*Schedule, error)
+	// MarkRun moves a due schedule to its next run. It reports false when
+	// another control plane already took this run.
+	MarkRun(ctx context.Context, s *Schedule, runAt time.Time, nextRunAt *time.Time) (bool, error)
+	RecordResult(ctx context.Context, scheduleID int64, traceID uuid.UUID, runErr error) error
+}
+
+type ScheduleService interface {
+	List(ctx context.Context, appID int64) ([]*Schedule, error)
+	GetByID(ctx context.Context, appID int64, scheduleID int64) (*Schedule, error)
+	Create(ctx context.Context, appID int64, req ScheduleRequest, createdBy int64) (*Schedule, error)
+	Update(ctx context.Context, appID int64, scheduleID int64, req ScheduleRequest) (*Schedule, error)
+	Delete(ctx context.Context, appID int64, scheduleID int64) error
+
+	// RunDue triggers every due schedule and returns how many were run.
+	RunDue(ctx context.Context) (int, error)
+}
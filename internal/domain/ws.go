@@ -21,6 +21,7 @@ type WsServerEvent struct {
 
 type WsServerMessage struct {
 	TargetServerID uuid.UUID       `json:"target_server_id"`
+	Event          string          `json:"event"`
 	Payload        json.RawMessage `json:"payload"`
 }
 
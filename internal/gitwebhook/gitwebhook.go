@@ -0,0 +1,158 @@
+// Package gitwebhook reads push deliveries of GitHub, GitLab and Gitea. It
+// does no I/O so recorded deliveries can be replayed against it.
+package gitwebhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"horizonx/internal/domain"
+)
+
+// FromHeader identifies the provider of a delivery from its headers. Gitea
+// also sends the GitHub headers, so it is checked first.
+func FromHeader(h http.Header, body []byte) (domain.GitWebhook, error) {
+	hook := domain.GitWebhook{Body: body}
+
+	switch {
+	case h.Get("X-Gitea-Event") != "":
+		hook.Provider = domain.GitProviderGitea
+		hook.Event = h.Get("X-Gitea-Event")
+		hook.DeliveryID = h.Get("X-Gitea-Delivery")
+		hook.Signature = h.Get("X-Gitea-Signature")
+
+	case h.Get("X-GitHub-Event") != "":
+		hook.Provider = domain.GitProviderGitHub
+		hook.Event = h.Get("X-GitHub-Event")
+		hook.DeliveryID = h.Get("X-GitHub-Delivery")
+		hook.Signature = h.Get("X-Hub-Signature-256")
+
+	case h.Get("X-Gitlab-Event") != "":
+		hook.Provider = domain.GitProviderGitLab
+		hook.Event = h.Get("X-Gitlab-Event")
+		hook.DeliveryID = h.Get("X-Gitlab-Event-UUID")
+		hook.Signature = h.Get("X-Gitlab-Token")
+
+	default:
+		return hook, domain.ErrUnknownWebhookProvider
+	}
+
+	return hook, nil
+}
+
+// Verify checks the delivery against the webhook secret.
+//
+//   - GitHub signs the body with HMAC-SHA256, hex encoded after "sha256="
+//   - Gitea signs the body with HMAC-SHA256, hex encoded
+//   - GitLab sends the secret itself as the token
+func Verify(hook domain.GitWebhook, secret string) error {
+	if secret == "" || hook.Signature == "" {
+		return domain.ErrInvalidWebhookSignature
+	}
+
+	var ok bool
+	switch hook.Provider {
+	case domain.GitProviderGitHub:
+		sig, found := strings.CutPrefix(hook.Signature, "sha256=")
+		ok = found && validHMAC(sig, secret, hook.Body)
+	case domain.GitProviderGitea:
+		ok = validHMAC(hook.Signature, secret, hook.Body)
+	case domain.GitProviderGitLab:
+		ok = subtle.ConstantTimeCompare([]byte(hook.Signature), []byte(secret)) == 1
+	default:
+		return domain.ErrUnknownWebhookProvider
+	}
+
+	if !ok {
+		return domain.ErrInvalidWebhookSignature
+	}
+
+	return nil
+}
+
+// Sign returns the signature GitHub or Gitea would send for body, for
+// replaying recorded deliveries.
+func Sign(provider domain.GitProvider, secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	sig := hex.EncodeToString(mac.Sum(nil))
+
+	switch provider {
+	case domain.GitProviderGitHub:
+		return "sha256=" + sig
+	case domain.GitProviderGitLab:
+		return secret
+	default:
+		return sig
+	}
+}
+
+func validHMAC(sig, secret string, body []byte) bool {
+	got, err := hex.DecodeString(strings.TrimSpace(sig))
+	if err != nil {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+
+	return hmac.Equal(got, mac.Sum(nil))
+}
+
+// ParsePush reads a push delivery. Other events, such as the ping sent when
+// a webhook is created, return domain.ErrWebhookEventIgnored.
+func ParsePush(hook domain.GitWebhook) (*domain.GitPush, error) {
+	var (
+		push *domain.GitPush
+		err  error
+	)
+
+	switch hook.Provider {
+	case domain.GitProviderGitHub:
+		if hook.Event != "push" {
+			return nil, fmt.Errorf("%w: %s", domain.ErrWebhookEventIgnored, hook.Event)
+		}
+		push, err = parseGitHub(hook.Body)
+	case domain.GitProviderGitea:
+		if hook.Event != "push" {
+			return nil, fmt.Errorf("%w: %s", domain.ErrWebhookEventIgnored, hook.Event)
+		}
+		push, err = parseGitea(hook.Body)
+	case domain.GitProviderGitLab:
+		if hook.Event != "Push Hook" {
+			return nil, fmt.Errorf("%w: %s", domain.ErrWebhookEventIgnored, hook.Event)
+		}
+		push, err = parseGitLab(hook.Body)
+	default:
+		return nil, domain.ErrUnknownWebhookProvider
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrInvalidWebhookPayload, err)
+	}
+
+	push.Provider = hook.Provider
+	return push, nil
+}
+
+// branchFromRef returns the branch of a pushed ref, empty for tags.
+func branchFromRef(ref string) string {
+	branch, _ := strings.CutPrefix(ref, "refs/heads/")
+	if branch == ref {
+		return ""
+	}
+	return branch
+}
+
+// commitFromAfter returns the pushed commit, empty when the push deleted
+// the ref and the providers send a SHA of zeros.
+func commitFromAfter(after string) string {
+	if strings.Trim(after, "0") == "" {
+		return ""
+	}
+	return after
+}
@@ -0,0 +1,313 @@
+package gitwebhook
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"horizonx/internal/domain"
+)
+
+func readFixture(t *testing.T, provider domain.GitProvider, name string) []byte {
+	t.Helper()
+
+	body, err := os.ReadFile(filepath.Join("testdata", string(provider), name+".json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return body
+}
+
+func header(kv ...string) http.Header {
+	h := http.Header{}
+	for i := 0; i < len(kv); i += 2 {
+		h.Set(kv[i], kv[i+1])
+	}
+	return h
+}
+
+func TestFromHeader(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  http.Header
+		want    domain.GitWebhook
+		wantErr error
+	}{
+		{
+			name: "github",
+			header: header(
+				"X-GitHub-Event", "push",
+				"X-GitHub-Delivery", "72d3162e-cc78-11e3-81ab-4c9367dc0958",
+				"X-Hub-Signature", "sha1=7d38cdd689735b008b3c702edd92eea23791c5f6",
+				"X-Hub-Signature-256", "sha256=d57c68ca6f92289e6987922ff26938930f6e66a2d161ef06abdf1859230aa23c",
+			),
+			want: domain.GitWebhook{
+				Provider:   domain.GitProviderGitHub,
+				Event:      "push",
+				DeliveryID: "72d3162e-cc78-11e3-81ab-4c9367dc0958",
+				Signature:  "sha256=d57c68ca6f92289e6987922ff26938930f6e66a2d161ef06abdf1859230aa23c",
+			},
+		},
+		{
+			name: "gitlab",
+			header: header(
+				"X-Gitlab-Event", "Push Hook",
+				"X-Gitlab-Event-UUID", "13792a34-cac6-4fda-95a8-c58e00a3954e",
+				"X-Gitlab-Token", "s3cret",
+			),
+			want: domain.GitWebhook{
+				Provider:   domain.GitProviderGitLab,
+				Event:      "Push Hook",
+				DeliveryID: "13792a34-cac6-4fda-95a8-c58e00a3954e",
+				Signature:  "s3cret",
+			},
+		},
+		{
+			name: "gitea also sending github headers",
+			header: header(
+				"X-Gitea-Event", "push",
+				"X-Gitea-Delivery", "f6266f16-1bf3-46a5-9ea4-602e06ead473",
+				"X-Gitea-Signature", "2bf1f0a8b6f0c6e8f0fb2f9d4e6a0b1c",
+				"X-GitHub-Event", "push",
+				"X-GitHub-Delivery", "f6266f16-1bf3-46a5-9ea4-602e06ead473",
+				"X-Hub-Signature-256", "sha256=2bf1f0a8b6f0c6e8f0fb2f9d4e6a0b1c",
+			),
+			want: domain.GitWebhook{
+				Provider:   domain.GitProviderGitea,
+				Event:      "push",
+				DeliveryID: "f6266f16-1bf3-46a5-9ea4-602e06ead473",
+				Signature:  "2bf1f0a8b6f0c6e8f0fb2f9d4e6a0b1c",
+			},
+		},
+		{
+			name:    "unknown provider",
+			header:  header("X-Event-Key", "repo:push"),
+			wantErr: domain.ErrUnknownWebhookProvider,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := []byte(`{}`)
+			got, err := FromHeader(tt.header, body)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+
+			if got.Provider != tt.want.Provider || got.Event != tt.want.Event ||
+				got.DeliveryID != tt.want.DeliveryID || got.Signature != tt.want.Signature {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+			if string(got.Body) != string(body) {
+				t.Errorf("body = %q, want %q", got.Body, body)
+			}
+		})
+	}
+}
+
+func TestVerify(t *testing.T) {
+	const secret = "It's a Secret to Everybody"
+	body := []byte("Hello, World!")
+
+	// the example from the GitHub documentation on validating deliveries
+	const githubSig = "757107ea0eb2509fc211221cce984b8a37570b6d7586c22c46f4379c8b043e17"
+
+	tests := []struct {
+		name      string
+		provider  domain.GitProvider
+		signature string
+		secret    string
+		wantErr   error
+	}{
+		{"github", domain.GitProviderGitHub, "sha256=" + githubSig, secret, nil},
+		{"github signed by Sign", domain.GitProviderGitHub, Sign(domain.GitProviderGitHub, secret, body), secret, nil},
+		{"github wrong secret", domain.GitProviderGitHub, "sha256=" + githubSig, "another secret", domain.ErrInvalidWebhookSignature},
+		{"github tampered signature", domain.GitProviderGitHub, "sha256=" + githubSig[:63] + "8", secret, domain.ErrInvalidWebhookSignature},
+		{"github missing sha256 prefix", domain.GitProviderGitHub, githubSig, secret, domain.ErrInvalidWebhookSignature},
+		{"github sha1 prefix", domain.GitProviderGitHub, "sha1=" + githubSig, secret, domain.ErrInvalidWebhookSignature},
+		{"github not hex", domain.GitProviderGitHub, "sha256=not-a-signature", secret, domain.ErrInvalidWebhookSignature},
+		{"gitea", domain.GitProviderGitea, githubSig, secret, nil},
+		{"gitea signed by Sign", domain.GitProviderGitea, Sign(domain.GitProviderGitea, secret, body), secret, nil},
+		{"gitea with sha256 prefix", domain.GitProviderGitea, "sha256=" + githubSig, secret, domain.ErrInvalidWebhookSignature},
+		{"gitea wrong secret", domain.GitProviderGitea, githubSig, "another secret", domain.ErrInvalidWebhookSignature},
+		{"gitlab token", domain.GitProviderGitLab, secret, secret, nil},
+		{"gitlab wrong token", domain.GitProviderGitLab, "It's a secret to everybody", secret, domain.ErrInvalidWebhookSignature},
+		{"gitlab token is not an hmac", domain.GitProviderGitLab, Sign(domain.GitProviderGitea, secret, body), secret, domain.ErrInvalidWebhookSignature},
+		{"missing signature", domain.GitProviderGitHub, "", secret, domain.ErrInvalidWebhookSignature},
+		{"missing secret", domain.GitProviderGitLab, "", "", domain.ErrInvalidWebhookSignature},
+		{"unknown provider", domain.GitProvider("bitbucket"), githubSig, secret, domain.ErrUnknownWebhookProvider},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Verify(domain.GitWebhook{
+				Provider:  tt.provider,
+				Signature: tt.signature,
+				Body:      body,
+			}, tt.secret)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParsePush(t *testing.T) {
+	tests := []struct {
+		provider domain.GitProvider
+		event    string
+		fixture  string
+		want     *domain.GitPush
+		wantErr  error
+	}{
+		{
+			provider: domain.GitProviderGitHub,
+			event:    "push",
+			fixture:  "push",
+			want: &domain.GitPush{
+				Branch:        "main",
+				CommitHash:    "0d1a26e67d8f5eaf1f6ba5c57fc3c7d91ac0fd1c",
+				CommitMessage: "Fix login redirect\n\nThe callback lost the next parameter.",
+				Pusher:        "octocat",
+			},
+		},
+		{
+			provider: domain.GitProviderGitHub,
+			event:    "ping",
+			fixture:  "ping",
+			wantErr:  domain.ErrWebhookEventIgnored,
+		},
+		{
+			provider: domain.GitProviderGitHub,
+			event:    "push",
+			fixture:  "tag",
+			want: &domain.GitPush{
+				CommitHash:    "0d1a26e67d8f5eaf1f6ba5c57fc3c7d91ac0fd1c",
+				CommitMessage: "Fix login redirect\n\nThe callback lost the next parameter.",
+				Pusher:        "octocat",
+			},
+		},
+		{
+			provider: domain.GitProviderGitHub,
+			event:    "push",
+			fixture:  "branch_delete",
+			want: &domain.GitPush{
+				Branch: "feature/login",
+				Pusher: "octocat",
+			},
+		},
+		{
+			provider: domain.GitProviderGitLab,
+			event:    "Push Hook",
+			fixture:  "push",
+			want: &domain.GitPush{
+				Branch:        "main",
+				CommitHash:    "da1560886d4f094c3e6c9ef40349f7d38b5d27d7",
+				CommitMessage: "fixed readme",
+				Pusher:        "jsmith",
+			},
+		},
+		{
+			// GitLab has no ping, tag pushes come as their own event
+			provider: domain.GitProviderGitLab,
+			event:    "Tag Push Hook",
+			fixture:  "tag",
+			wantErr:  domain.ErrWebhookEventIgnored,
+		},
+		{
+			provider: domain.GitProviderGitLab,
+			event:    "Push Hook",
+			fixture:  "branch_delete",
+			want: &domain.GitPush{
+				Branch: "feature/login",
+				Pusher: "jsmith",
+			},
+		},
+		{
+			provider: domain.GitProviderGitea,
+			event:    "push",
+			fixture:  "push",
+			want: &domain.GitPush{
+				Branch:        "main",
+				CommitHash:    "bffeb74224043ba2feb48d137756c8a9331c449a",
+				CommitMessage: "Add health endpoint",
+				Pusher:        "ada",
+			},
+		},
+		{
+			provider: domain.GitProviderGitea,
+			event:    "push",
+			fixture:  "tag",
+			want: &domain.GitPush{
+				CommitHash:    "bffeb74224043ba2feb48d137756c8a9331c449a",
+				CommitMessage: "Add health endpoint",
+				Pusher:        "ada",
+			},
+		},
+		{
+			provider: domain.GitProviderGitea,
+			event:    "push",
+			fixture:  "branch_delete",
+			want: &domain.GitPush{
+				Branch: "feature/login",
+				Pusher: "ada",
+			},
+		},
+		{
+			// Gitea has no ping either, a deleted branch is also announced
+			// as a delete event
+			provider: domain.GitProviderGitea,
+			event:    "delete",
+			fixture:  "delete",
+			wantErr:  domain.ErrWebhookEventIgnored,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.provider)+"/"+tt.fixture, func(t *testing.T) {
+			got, err := ParsePush(domain.GitWebhook{
+				Provider: tt.provider,
+				Event:    tt.event,
+				Body:     readFixture(t, tt.provider, tt.fixture),
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+
+			tt.want.Provider = tt.provider
+			if *got != *tt.want {
+				t.Errorf("got %+v, want %+v", *got, *tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePushInvalidPayload(t *testing.T) {
+	for _, provider := range []domain.GitProvider{
+		domain.GitProviderGitHub,
+		domain.GitProviderGitLab,
+		domain.GitProviderGitea,
+	} {
+		event := "push"
+		if provider == domain.GitProviderGitLab {
+			event = "Push Hook"
+		}
+
+		_, err := ParsePush(domain.GitWebhook{
+			Provider: provider,
+			Event:    event,
+			Body:     []byte(`{"ref": 42`),
+		})
+		if !errors.Is(err, domain.ErrInvalidWebhookPayload) {
+			t.Errorf("%s: err = %v, want ErrInvalidWebhookPayload", provider, err)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package gitwebhook
+
+import (
+	"encoding/json"
+	"strings"
+
+	"horizonx/internal/domain"
+)
+
+type commit struct {
+	ID      string `json:"id"`
+	Message string `json:"message"`
+}
+
+type githubPush struct {
+	Ref        string  `json:"ref"`
+	After      string  `json:"after"`
+	Deleted    bool    `json:"deleted"`
+	HeadCommit *commit `json:"head_commit"`
+	Pusher     struct {
+		Name string `json:"name"`
+	} `json:"pusher"`
+	Sender struct {
+		Login string `json:"login"`
+	} `json:"sender"`
+}
+
+type giteaPush struct {
+	Ref        string   `json:"ref"`
+	After      string   `json:"after"`
+	HeadCommit *commit  `json:"head_commit"`
+	Commits    []commit `json:"commits"`
+	Pusher     struct {
+		Login    string `json:"login"`
+		Username string `json:"username"`
+	} `json:"pusher"`
+}
+
+type gitlabPush struct {
+	Ref          string   `json:"ref"`
+	After        string   `json:"after"`
+	CheckoutSHA  *string  `json:"checkout_sha"`
+	UserUsername string   `json:"user_username"`
+	UserName     string   `json:"user_name"`
+	Commits      []commit `json:"commits"`
+}
+
+func parseGitHub(body []byte) (*domain.GitPush, error) {
+	var p githubPush
+	if err := json.Unmarshal(body, &p); err != nil {
+		return nil, err
+	}
+
+	push := &domain.GitPush{
+		Branch:     branchFromRef(p.Ref),
+		CommitHash: commitFromAfter(p.After),
+		Pusher:     firstNonEmpty(p.Pusher.Name, p.Sender.Login),
+	}
+	if p.Deleted {
+		push.CommitHash = ""
+	}
+	if p.HeadCommit != nil {
+		push.CommitMessage = strings.TrimSpace(p.HeadCommit.Message)
+	}
+
+	return push, nil
+}
+
+func parseGitea(body []byte) (*domain.GitPush, error) {
+	var p giteaPush
+	if err := json.Unmarshal(body, &p); err != nil {
+		return nil, err
+	}
+
+	push := &domain.GitPush{
+		Branch:     branchFromRef(p.Ref),
+		CommitHash: commitFromAfter(p.After),
+		Pusher:     firstNonEmpty(p.Pusher.Username, p.Pusher.Login),
+	}
+
+	if p.HeadCommit != nil {
+		push.CommitMessage = strings.TrimSpace(p.HeadCommit.Message)
+	} else {
+		push.CommitMessage = messageOf(p.Commits, push.CommitHash)
+	}
+
+	return push, nil
+}
+
+func parseGitLab(body []byte) (*domain.GitPush, error) {
+	var p gitlabPush
+	if err := json.Unmarshal(body, &p); err != nil {
+		return nil, err
+	}
+
+	push := &domain.GitPush{
+		Branch:     branchFromRef(p.Ref),
+		CommitHash: commitFromAfter(p.After),
+		Pusher:     firstNonEmpty(p.UserUsername, p.UserName),
+	}
+	if p.CheckoutSHA == nil {
+		push.CommitHash = ""
+	}
+	push.CommitMessage = messageOf(p.Commits, push.CommitHash)
+
+	return push, nil
+}
+
+// messageOf finds the message of the pushed commit in the commit list.
+func messageOf(commits []commit, hash string) string {
+	if hash == "" {
+		return ""
+	}
+
+	for _, c := range commits {
+		if c.ID == hash {
+			return strings.TrimSpace(c.Message)
+		}
+	}
+
+	return ""
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
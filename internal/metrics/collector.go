@@ -73,6 +73,10 @@ type Collector struct {
 	netTxEMA map[string]*EMA
 
 	iface string
+
+	containers    []trackedContainer
+	containersAt  time.Time
+	lastContainer map[string]ContainerState
 }
 
 func NewCollector(cfg *config.Config, log logger.Logger) *Collector {
@@ -89,6 +93,8 @@ func NewCollector(cfg *config.Config, log logger.Logger) *Collector {
 		lastDiskIO: make(map[string]DiskIOState),
 		lastNet:    make(map[string]NetState),
 
+		lastContainer: make(map[string]ContainerState),
+
 		cpuUsageEMA:   NewEMA(15 * time.Second),
 		cpuFreqEMA:    NewEMA(20 * time.Second),
 		cpuPowerEMA:   NewEMA(20 * time.Second),
@@ -152,6 +158,7 @@ func (c *Collector) collect() {
 	metrics.Disk = c.getDiskMetrics()
 	metrics.Network = c.getNetworkMetric()
 	metrics.UptimeSeconds = c.reader.Uptime()
+	metrics.Containers = c.getContainerMetrics()
 	metrics.RecordedAt = time.Now().UTC()
 
 	c.ApplyEMA(&metrics)
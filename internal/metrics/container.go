@@ -0,0 +1,181 @@
+package metrics
+
+import (
+	"time"
+
+	"horizonx/internal/domain"
+	"horizonx/internal/system"
+)
+
+// containerRefreshInterval bounds how often docker is asked for the list of
+// containers, the counters themselves are read on every sample.
+const containerRefreshInterval = 30 * time.Second
+
+type trackedContainer struct {
+	system.ContainerInfo
+
+	ApplicationID int64
+	CgroupDir     string
+}
+
+type ContainerState struct {
+	CPUUsageUsec uint64
+	RxBytes      uint64
+	TxBytes      uint64
+	ReadBytes    uint64
+	WriteBytes   uint64
+	Time         time.Time
+}
+
+func (c *Collector) getContainerMetrics() []domain.ContainerMetric {
+	now := time.Now()
+
+	if now.Sub(c.containersAt) >= containerRefreshInterval {
+		c.refreshContainers()
+		c.containersAt = now
+	}
+
+	if len(c.containers) == 0 {
+		return []domain.ContainerMetric{}
+	}
+
+	// docker stats is only needed when some container has no cgroup v2
+	// directory to read from.
+	var dockerStats map[string]system.ContainerStats
+	for _, ct := range c.containers {
+		if ct.CgroupDir == "" {
+			dockerStats = c.reader.DockerStats()
+			break
+		}
+	}
+
+	result := make([]domain.ContainerMetric, 0, len(c.containers))
+	seen := make(map[string]bool, len(c.containers))
+
+	for _, ct := range c.containers {
+		var (
+			stats system.ContainerStats
+			ok    bool
+		)
+
+		if ct.CgroupDir != "" {
+			stats, ok = c.reader.ContainerCgroupStats(ct.CgroupDir)
+			if pid := c.reader.CgroupPid(ct.CgroupDir); ok && pid > 0 {
+				net := c.reader.ContainerNetBytes(pid)
+				stats.RxBytes, stats.TxBytes = net.RxBytes, net.TxBytes
+			}
+		} else {
+			stats, ok = dockerStats[ct.ID]
+		}
+
+		if !ok {
+			continue
+		}
+		seen[ct.ID] = true
+
+		result = append(result, c.calculateContainerMetric(ct, stats, now))
+	}
+
+	for id := range c.lastContainer {
+		if !seen[id] {
+			delete(c.lastContainer, id)
+		}
+	}
+
+	return result
+}
+
+// refreshContainers keeps the containers of HorizonX applications. Cgroup
+// paths are looked up once per container.
+func (c *Collector) refreshContainers() {
+	known := make(map[string]trackedContainer, len(c.containers))
+	for _, ct := range c.containers {
+		known[ct.ID] = ct
+	}
+
+	containers := make([]trackedContainer, 0, len(known))
+
+	for _, info := range c.reader.ComposeContainers() {
+		appID, ok := domain.ParseComposeProject(info.Project)
+		if !ok {
+			appID, ok = domain.ParseComposeWorkingDir(info.WorkingDir)
+		}
+		if !ok {
+			continue
+		}
+
+		if ct, ok := known[info.ID]; ok {
+			containers = append(containers, ct)
+			continue
+		}
+
+		containers = append(containers, trackedContainer{
+			ContainerInfo: info,
+			ApplicationID: appID,
+			CgroupDir:     c.reader.ContainerCgroupDir(info.ID),
+		})
+	}
+
+	c.containers = containers
+}
+
+func (c *Collector) calculateContainerMetric(ct trackedContainer, stats system.ContainerStats, now time.Time) domain.ContainerMetric {
+	m := domain.ContainerMetric{
+		ApplicationID: ct.ApplicationID,
+		Service:       ct.Service,
+		Container:     ct.Name,
+
+		MemoryBytes:      stats.MemoryBytes,
+		MemoryLimitBytes: stats.MemoryLimitBytes,
+
+		RXBytes:         stats.RxBytes,
+		TXBytes:         stats.TxBytes,
+		BlockReadBytes:  stats.ReadBytes,
+		BlockWriteBytes: stats.WriteBytes,
+	}
+
+	if stats.HasPercent {
+		m.CPUPercent = stats.CPUPercent
+	}
+
+	last, ok := c.lastContainer[ct.ID]
+
+	c.lastContainer[ct.ID] = ContainerState{
+		CPUUsageUsec: stats.CPUUsageUsec,
+		RxBytes:      stats.RxBytes,
+		TxBytes:      stats.TxBytes,
+		ReadBytes:    stats.ReadBytes,
+		WriteBytes:   stats.WriteBytes,
+		Time:         now,
+	}
+
+	if !ok {
+		return m
+	}
+
+	dt := now.Sub(last.Time).Seconds()
+	if dt <= 0 {
+		return m
+	}
+
+	if !stats.HasPercent {
+		m.CPUPercent = counterRate(stats.CPUUsageUsec, last.CPUUsageUsec, dt) / 1e6 * 100
+	}
+
+	m.RXSpeedMBs = counterRate(stats.RxBytes, last.RxBytes, dt) / 1024 / 1024
+	m.TXSpeedMBs = counterRate(stats.TxBytes, last.TxBytes, dt) / 1024 / 1024
+	m.BlockReadMBps = counterRate(stats.ReadBytes, last.ReadBytes, dt) / 1024 / 1024
+	m.BlockWriteMBps = counterRate(stats.WriteBytes, last.WriteBytes, dt) / 1024 / 1024
+
+	return m
+}
+
+// counterRate returns the per second increase of a counter, 0 when it was
+// reset in between.
+func counterRate(curr, last uint64, dt float64) float64 {
+	if curr < last {
+		return 0
+	}
+
+	return float64(curr-last) / dt
+}
@@ -0,0 +1,184 @@
+// Package secret
+package secret
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// KeySize is the length of an AES-256 key.
+const KeySize = 32
+
+var (
+	ErrInvalidKey  = errors.New("secret key must be 32 bytes, base64 or hex encoded")
+	ErrCiphertext  = errors.New("ciphertext is malformed or was sealed with another key")
+	ErrKeyConflict = errors.New("set either SECRET_KEY or SECRET_KEY_FILE, not both")
+	ErrNoKey       = errors.New("SECRET_KEY_PREVIOUS is set without SECRET_KEY")
+)
+
+// Box seals values with AES-256-GCM. The random nonce is stored in front of
+// the ciphertext, the additional data given to Seal must be given again to
+// Open.
+type Box struct {
+	aead cipher.AEAD
+
+	// previous keys still open values sealed before a key rotation, Seal
+	// never uses them.
+	previous []cipher.AEAD
+}
+
+func NewBox(key []byte, previous ...[]byte) (*Box, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	b := &Box{aead: aead}
+	for _, k := range previous {
+		prev, err := newAEAD(k)
+		if err != nil {
+			return nil, err
+		}
+		b.previous = append(b.previous, prev)
+	}
+
+	return b, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) != KeySize {
+		return nil, ErrInvalidKey
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// AAD names the row a value is sealed for. Sealed with it, a value copied
+// to another row or column no longer opens.
+func AAD(table, column string, id any) []byte {
+	return fmt.Appendf(nil, "%s.%s:%v", table, column, id)
+}
+
+func (b *Box) Seal(plaintext, aad []byte) ([]byte, error) {
+	nonce := make([]byte, b.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	return b.aead.Seal(nonce, nonce, plaintext, aad), nil
+}
+
+// Open tries the current key first, then the previous ones.
+func (b *Box) Open(ciphertext, aad []byte) ([]byte, error) {
+	if plaintext, err := open(b.aead, ciphertext, aad); err == nil {
+		return plaintext, nil
+	}
+
+	for _, aead := range b.previous {
+		if plaintext, err := open(aead, ciphertext, aad); err == nil {
+			return plaintext, nil
+		}
+	}
+
+	return nil, ErrCiphertext
+}
+
+func open(aead cipher.AEAD, ciphertext, aad []byte) ([]byte, error) {
+	size := aead.NonceSize()
+	if len(ciphertext) < size+aead.Overhead() {
+		return nil, ErrCiphertext
+	}
+
+	plaintext, err := aead.Open(nil, ciphertext[:size], ciphertext[size:], aad)
+	if err != nil {
+		return nil, ErrCiphertext
+	}
+
+	return plaintext, nil
+}
+
+// Reseal opens a value sealed with any known key and seals it again with
+// the current one, for the same additional data.
+func (b *Box) Reseal(ciphertext, aad []byte) ([]byte, error) {
+	plaintext, err := b.Open(ciphertext, aad)
+	if err != nil {
+		return nil, err
+	}
+
+	return b.Seal(plaintext, aad)
+}
+
+// LoadKey decodes the key given inline or read from file. Both empty means
+// no key is configured and returns nil.
+func LoadKey(raw, file string) ([]byte, error) {
+	if raw != "" && file != "" {
+		return nil, ErrKeyConflict
+	}
+
+	if file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read secret key file: %w", err)
+		}
+		raw = string(data)
+	}
+
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, nil
+	}
+
+	return DecodeKey(raw)
+}
+
+// LoadBox builds the box of the configured key, the previous key is only
+// set while a rotation is under way. No key configured returns nil.
+func LoadBox(raw, file, previousRaw, previousFile string) (*Box, error) {
+	key, err := LoadKey(raw, file)
+	if err != nil {
+		return nil, err
+	}
+
+	previous, err := LoadKey(previousRaw, previousFile)
+	if err != nil {
+		return nil, fmt.Errorf("previous key: %w", err)
+	}
+
+	if key == nil {
+		if previous != nil {
+			return nil, ErrNoKey
+		}
+		return nil, nil
+	}
+
+	if previous == nil {
+		return NewBox(key)
+	}
+
+	return NewBox(key, previous)
+}
+
+// DecodeKey accepts a 32 byte key as standard base64 or hex.
+func DecodeKey(raw string) ([]byte, error) {
+	if key, err := base64.StdEncoding.DecodeString(raw); err == nil && len(key) == KeySize {
+		return key, nil
+	}
+
+	if key, err := hex.DecodeString(raw); err == nil && len(key) == KeySize {
+		return key, nil
+	}
+
+	return nil, ErrInvalidKey
+}
@@ -0,0 +1,255 @@
+package secret
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var testAAD = AAD("git_credentials", "encrypted_secret", 1)
+
+func testKey(t *testing.T, fill byte) []byte {
+	t.Helper()
+	return bytes.Repeat([]byte{fill}, KeySize)
+}
+
+func newBox(t *testing.T, key []byte, previous ...[]byte) *Box {
+	t.Helper()
+
+	b, err := NewBox(key, previous...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestSealOpen(t *testing.T) {
+	b := newBox(t, testKey(t, 1))
+	plaintext := []byte("postgres://user:s3cret@db/app")
+
+	sealed, err := b.Seal(plaintext, testAAD)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(sealed, plaintext) {
+		t.Fatal("sealed value contains the plaintext")
+	}
+
+	got, err := b.Open(sealed, testAAD)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if !bytes.Equal(got, plaintext) {
+		t.Errorf("Open = %q, want %q", got, plaintext)
+	}
+
+	again, err := b.Seal(plaintext, testAAD)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(sealed, again) {
+		t.Error("sealing twice gave the same ciphertext, the nonce is not random")
+	}
+}
+
+func TestOpenRejects(t *testing.T) {
+	b := newBox(t, testKey(t, 1))
+	sealed, err := b.Seal([]byte("value"), testAAD)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tampered := bytes.Clone(sealed)
+	tampered[len(tampered)-1] ^= 1
+
+	tests := map[string]struct {
+		box        *Box
+		ciphertext []byte
+		aad        []byte
+	}{
+		"tampered":     {b, tampered, nil},
+		"truncated":    {b, sealed[:b.aead.NonceSize()], nil},
+		"empty":        {b, nil, nil},
+		"other key":    {newBox(t, testKey(t, 2)), sealed, nil},
+		"other row":    {b, sealed, AAD("git_credentials", "encrypted_secret", 2)},
+		"other column": {b, sealed, AAD("registry_credentials", "encrypted_password", 1)},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			aad := testAAD
+			if tt.aad != nil {
+				aad = tt.aad
+			}
+			if _, err := tt.box.Open(tt.ciphertext, aad); !errors.Is(err, ErrCiphertext) {
+				t.Errorf("Open: err = %v, want ErrCiphertext", err)
+			}
+		})
+	}
+}
+
+func TestOpenWithPreviousKey(t *testing.T) {
+	oldKey, newKey := testKey(t, 1), testKey(t, 2)
+	old := newBox(t, oldKey)
+	rotated := newBox(t, newKey, oldKey)
+
+	sealedOld, err := old.Seal([]byte("before rotation"), testAAD)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := rotated.Open(sealedOld, testAAD)
+	if err != nil || string(got) != "before rotation" {
+		t.Fatalf("Open of a value sealed with the previous key = %q, %v", got, err)
+	}
+
+	// Seal only ever uses the current key.
+	sealedNew, err := rotated.Seal([]byte("after rotation"), testAAD)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := newBox(t, newKey).Open(sealedNew, testAAD); err != nil {
+		t.Errorf("current key alone cannot open a new value: %v", err)
+	}
+	if _, err := old.Open(sealedNew, testAAD); !errors.Is(err, ErrCiphertext) {
+		t.Errorf("previous key opened a new value: err = %v", err)
+	}
+}
+
+func TestReseal(t *testing.T) {
+	oldKey, newKey := testKey(t, 1), testKey(t, 2)
+	old := newBox(t, oldKey)
+	rotated := newBox(t, newKey, oldKey)
+	current := newBox(t, newKey)
+
+	sealed, err := old.Seal([]byte("rotate me"), testAAD)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resealed, err := rotated.Reseal(sealed, testAAD)
+	if err != nil {
+		t.Fatalf("Reseal: %v", err)
+	}
+	got, err := current.Open(resealed, testAAD)
+	if err != nil || string(got) != "rotate me" {
+		t.Fatalf("current key opens the resealed value as %q, %v", got, err)
+	}
+	if _, err := old.Open(resealed, testAAD); !errors.Is(err, ErrCiphertext) {
+		t.Errorf("previous key still opens the resealed value: err = %v", err)
+	}
+
+	// Without the previous key there is nothing to reseal from.
+	if _, err := current.Reseal(sealed, testAAD); !errors.Is(err, ErrCiphertext) {
+		t.Errorf("Reseal without the previous key: err = %v, want ErrCiphertext", err)
+	}
+}
+
+func TestEnvelopeRewrap(t *testing.T) {
+	oldKey, newKey := testKey(t, 1), testKey(t, 2)
+	old := newBox(t, oldKey)
+
+	env, err := old.SealEnvelope([]byte("env value"), testAAD)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := old.OpenEnvelope(env, testAAD)
+	if err != nil || string(got) != "env value" {
+		t.Fatalf("OpenEnvelope = %q, %v", got, err)
+	}
+
+	// Rotating the master key rewraps the data key, the ciphertext stays.
+	wrapped, err := newBox(t, newKey, oldKey).Reseal(env.DataKey, testAAD)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rewrapped := &Envelope{Ciphertext: env.Ciphertext, DataKey: wrapped}
+
+	got, err = newBox(t, newKey).OpenEnvelope(rewrapped, testAAD)
+	if err != nil || string(got) != "env value" {
+		t.Fatalf("OpenEnvelope after rewrap = %q, %v", got, err)
+	}
+	if _, err := old.OpenEnvelope(rewrapped, testAAD); !errors.Is(err, ErrCiphertext) {
+		t.Errorf("previous key opens the rewrapped envelope: err = %v", err)
+	}
+}
+
+func TestNewBoxKeySize(t *testing.T) {
+	if _, err := NewBox(make([]byte, 16)); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("16 byte key: err = %v, want ErrInvalidKey", err)
+	}
+	if _, err := NewBox(make([]byte, KeySize), make([]byte, 31)); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("31 byte previous key: err = %v, want ErrInvalidKey", err)
+	}
+}
+
+func TestDecodeKey(t *testing.T) {
+	key := testKey(t, 7)
+
+	for name, raw := range map[string]string{
+		"base64": base64.StdEncoding.EncodeToString(key),
+		"hex":    hex.EncodeToString(key),
+	} {
+		got, err := DecodeKey(raw)
+		if err != nil || !bytes.Equal(got, key) {
+			t.Errorf("%s: DecodeKey = %x, %v", name, got, err)
+		}
+	}
+
+	for _, raw := range []string{
+		"",
+		"not a key",
+		base64.StdEncoding.EncodeToString(key[:16]),
+		hex.EncodeToString(key[:31]),
+	} {
+		if _, err := DecodeKey(raw); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("DecodeKey(%q): err = %v, want ErrInvalidKey", raw, err)
+		}
+	}
+}
+
+func TestLoadBox(t *testing.T) {
+	oldKey, newKey := testKey(t, 1), testKey(t, 2)
+	oldRaw := base64.StdEncoding.EncodeToString(oldKey)
+	newRaw := hex.EncodeToString(newKey)
+
+	file := filepath.Join(t.TempDir(), "secret_key")
+	if err := os.WriteFile(file, []byte(newRaw+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := LoadBox("", "", "", "")
+	if err != nil || b != nil {
+		t.Errorf("no key: LoadBox = %v, %v, want nil", b, err)
+	}
+
+	if _, err := LoadBox(newRaw, file, "", ""); !errors.Is(err, ErrKeyConflict) {
+		t.Errorf("key and key file: err = %v, want ErrKeyConflict", err)
+	}
+	if _, err := LoadBox("", "", oldRaw, ""); !errors.Is(err, ErrNoKey) {
+		t.Errorf("previous key alone: err = %v, want ErrNoKey", err)
+	}
+	if _, err := LoadBox("", "", "", filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("missing previous key file: LoadBox succeeded")
+	}
+
+	sealed, err := newBox(t, oldKey).Seal([]byte("value"), testAAD)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rotated, err := LoadBox("", file, oldRaw, "")
+	if err != nil {
+		t.Fatalf("LoadBox: %v", err)
+	}
+	resealed, err := rotated.Reseal(sealed, testAAD)
+	if err != nil {
+		t.Fatalf("Reseal: %v", err)
+	}
+	if _, err := newBox(t, newKey).Open(resealed, testAAD); err != nil {
+		t.Errorf("key from file cannot open the resealed value: %v", err)
+	}
+}
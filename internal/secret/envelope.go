@@ -0,0 +1,53 @@
+package secret
+
+import (
+	"crypto/rand"
+	"fmt"
+)
+
+// Envelope is a value sealed with a data key of its own. The data key is
+// sealed with the master key of a Box, rotating the master key only
+// rewraps data keys and leaves the ciphertext alone. Both are sealed for
+// the same additional data.
+type Envelope struct {
+	Ciphertext []byte
+	DataKey    []byte
+}
+
+func (b *Box) SealEnvelope(plaintext, aad []byte) (*Envelope, error) {
+	dataKey := make([]byte, KeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, fmt.Errorf("failed to generate data key: %w", err)
+	}
+
+	inner, err := NewBox(dataKey)
+	if err != nil {
+		return nil, err
+	}
+
+	ciphertext, err := inner.Seal(plaintext, aad)
+	if err != nil {
+		return nil, err
+	}
+
+	wrapped, err := b.Seal(dataKey, aad)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Envelope{Ciphertext: ciphertext, DataKey: wrapped}, nil
+}
+
+func (b *Box) OpenEnvelope(e *Envelope, aad []byte) ([]byte, error) {
+	dataKey, err := b.Open(e.DataKey, aad)
+	if err != nil {
+		return nil, err
+	}
+
+	inner, err := NewBox(dataKey)
+	if err != nil {
+		return nil, ErrCiphertext
+	}
+
+	return inner.Open(e.Ciphertext, aad)
+}
@@ -0,0 +1,262 @@
+package system
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+type ContainerInfo struct {
+	ID         string
+	Name       string
+	Project    string
+	Service    string
+	WorkingDir string
+}
+
+// ContainerStats holds cumulative counters. When they come from docker stats
+// the CPU usage is already a percentage and CPUUsageUsec stays 0.
+type ContainerStats struct {
+	CPUUsageUsec uint64
+	CPUPercent   float64
+	HasPercent   bool
+
+	MemoryBytes      uint64
+	MemoryLimitBytes uint64
+
+	ReadBytes  uint64
+	WriteBytes uint64
+
+	RxBytes uint64
+	TxBytes uint64
+}
+
+// ComposeContainers lists the running containers that belong to a compose
+// project.
+func (r *SystemReader) ComposeContainers() []ContainerInfo {
+	out, err := exec.Command(
+		"docker", "ps", "--no-trunc",
+		"--filter", "label=com.docker.compose.project",
+		"--format", `{{.ID}}\t{{.Names}}\t{{.Label "com.docker.compose.project"}}\t{{.Label "com.docker.compose.service"}}\t{{.Label "com.docker.compose.project.working_dir"}}`,
+	).Output()
+	if err != nil {
+		r.log.Debug("failed to list compose containers", "error", err.Error())
+		return nil
+	}
+
+	var containers []ContainerInfo
+	for line := range strings.SplitSeq(strings.TrimSpace(string(out)), "\n") {
+		fields := strings.Split(line, "\t")
+		if len(fields) < 5 {
+			continue
+		}
+
+		containers = append(containers, ContainerInfo{
+			ID:         fields[0],
+			Name:       fields[1],
+			Project:    fields[2],
+			Service:    fields[3],
+			WorkingDir: fields[4],
+		})
+	}
+
+	return containers
+}
+
+// CgroupPid returns a process of the cgroup, its network namespace is the
+// one of the container.
+func (r *SystemReader) CgroupPid(dir string) int {
+	data, err := os.ReadFile(filepath.Join(dir, "cgroup.procs"))
+	if err != nil {
+		return 0
+	}
+
+	first, _, _ := strings.Cut(string(data), "\n")
+	pid, _ := strconv.Atoi(strings.TrimSpace(first))
+	return pid
+}
+
+// ContainerCgroupDir finds the cgroup v2 directory of a container for both
+// the systemd and the cgroupfs docker drivers.
+func (r *SystemReader) ContainerCgroupDir(id string) string {
+	candidates := []string{
+		"/sys/fs/cgroup/system.slice/docker-" + id + ".scope",
+		"/sys/fs/cgroup/docker/" + id,
+	}
+
+	for _, dir := range candidates {
+		if _, err := os.Stat(filepath.Join(dir, "cpu.stat")); err == nil {
+			return dir
+		}
+	}
+
+	return ""
+}
+
+// ContainerCgroupStats reads the counters of a cgroup v2 directory.
+func (r *SystemReader) ContainerCgroupStats(dir string) (ContainerStats, bool) {
+	var stats ContainerStats
+
+	cpu, ok := readKeyedFile(filepath.Join(dir, "cpu.stat"))
+	if !ok {
+		return stats, false
+	}
+	stats.CPUUsageUsec = cpu["usage_usec"]
+
+	stats.MemoryBytes = readUintFile(filepath.Join(dir, "memory.current"))
+	stats.MemoryLimitBytes = readUintFile(filepath.Join(dir, "memory.max"))
+
+	if data, err := os.ReadFile(filepath.Join(dir, "io.stat")); err == nil {
+		for line := range strings.SplitSeq(string(data), "\n") {
+			for _, field := range strings.Fields(line) {
+				key, value, ok := strings.Cut(field, "=")
+				if !ok {
+					continue
+				}
+
+				n, _ := strconv.ParseUint(value, 10, 64)
+				switch key {
+				case "rbytes":
+					stats.ReadBytes += n
+				case "wbytes":
+					stats.WriteBytes += n
+				}
+			}
+		}
+	}
+
+	return stats, true
+}
+
+// ContainerNetBytes sums the traffic of every interface but loopback in the
+// network namespace of pid.
+func (r *SystemReader) ContainerNetBytes(pid int) NetStats {
+	data, err := os.ReadFile("/proc/" + strconv.Itoa(pid) + "/net/dev")
+	if err != nil {
+		r.log.Debug("failed to read container net dev", "pid", pid, "error", err.Error())
+		return NetStats{}
+	}
+
+	var stats NetStats
+	for line := range strings.SplitSeq(string(data), "\n") {
+		iface, counters, ok := strings.Cut(strings.TrimSpace(line), ":")
+		if !ok || iface == "lo" {
+			continue
+		}
+
+		fields := strings.Fields(counters)
+		if len(fields) < 9 {
+			continue
+		}
+
+		rx, _ := strconv.ParseUint(fields[0], 10, 64)
+		tx, _ := strconv.ParseUint(fields[8], 10, 64)
+		stats.RxBytes += rx
+		stats.TxBytes += tx
+	}
+
+	return stats
+}
+
+// DockerStats is the fallback for hosts without cgroup v2, keyed by full
+// container id. It is slow, docker samples the CPU for about a second.
+func (r *SystemReader) DockerStats() map[string]ContainerStats {
+	out, err := exec.Command("docker", "stats", "--no-stream", "--no-trunc", "--format", "{{json .}}").Output()
+	if err != nil {
+		r.log.Debug("failed to run docker stats", "error", err.Error())
+		return nil
+	}
+
+	var row struct {
+		ID       string `json:"ID"`
+		CPUPerc  string `json:"CPUPerc"`
+		MemUsage string `json:"MemUsage"`
+		NetIO    string `json:"NetIO"`
+		BlockIO  string `json:"BlockIO"`
+	}
+
+	result := make(map[string]ContainerStats)
+	for line := range strings.SplitSeq(strings.TrimSpace(string(out)), "\n") {
+		if err := json.Unmarshal([]byte(line), &row); err != nil {
+			continue
+		}
+
+		stats := ContainerStats{HasPercent: true}
+		stats.CPUPercent, _ = strconv.ParseFloat(strings.TrimSuffix(row.CPUPerc, "%"), 64)
+		stats.MemoryBytes, stats.MemoryLimitBytes = parseSizePair(row.MemUsage)
+		stats.RxBytes, stats.TxBytes = parseSizePair(row.NetIO)
+		stats.ReadBytes, stats.WriteBytes = parseSizePair(row.BlockIO)
+
+		result[row.ID] = stats
+	}
+
+	return result
+}
+
+func readKeyedFile(path string) (map[string]uint64, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+
+	values := make(map[string]uint64)
+	for line := range strings.SplitSeq(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+
+		n, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		values[fields[0]] = n
+	}
+
+	return values, true
+}
+
+// readUintFile reads a single number, "max" and missing files read as 0.
+func readUintFile(path string) uint64 {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0
+	}
+
+	n, _ := strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+	return n
+}
+
+// parseSizePair parses docker stats columns such as "12.5MiB / 1.9GiB".
+func parseSizePair(s string) (uint64, uint64) {
+	left, right, _ := strings.Cut(s, "/")
+	return parseSize(left), parseSize(right)
+}
+
+func parseSize(s string) uint64 {
+	s = strings.TrimSpace(s)
+
+	units := []struct {
+		suffix string
+		factor float64
+	}{
+		{"KiB", 1 << 10}, {"MiB", 1 << 20}, {"GiB", 1 << 30}, {"TiB", 1 << 40},
+		{"kB", 1e3}, {"KB", 1e3}, {"MB", 1e6}, {"GB", 1e9}, {"TB", 1e12},
+		{"B", 1},
+	}
+
+	for _, u := range units {
+		if num, ok := strings.CutSuffix(s, u.suffix); ok {
+			n, err := strconv.ParseFloat(strings.TrimSpace(num), 64)
+			if err != nil {
+				return 0
+			}
+			return uint64(n * u.factor)
+		}
+	}
+
+	return 0
+}
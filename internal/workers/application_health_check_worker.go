@@ -4,7 +4,6 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"time"
 
 	"horizonx/internal/domain"
 	"horizonx/internal/logger"
@@ -85,47 +84,28 @@ func (w *ApplicationHealthCheckWorker) Run(ctx context.Context) error {
 			return fmt.Errorf("failed to marshal job payload: %w", err)
 		}
 
-		// Create new job
-		if len(jobs.Data) == 0 {
-			data := &domain.Job{
-				TraceID:  uuid.New(),
-				ServerID: p.ServerID,
-				Type:     jobType,
-				Payload:  payload,
-			}
-
-			createdJob, err := w.job.Create(ctx, data)
-			if err != nil {
-				w.log.Error("failed to create job", "job_type", jobType, "server_id", p.ServerID.String())
-				continue
-			}
-
-			w.log.Debug("job created", "job_id", createdJob.ID, "job_type", jobType)
-			continue
-		}
-
-		// Retry existing job
+		// Every run gets its own job so past runs stay in the history
 		if len(jobs.Data) > 0 {
-			job := jobs.Data[0]
-
-			if job.Status == domain.JobQueued {
+			status := jobs.Data[0].Status
+			if status == domain.JobQueued || status == domain.JobRunning {
 				continue
 			}
+		}
 
-			queuedAt := time.Now().UTC()
-			data := &domain.Job{
-				Status:   domain.JobQueued,
-				QueuedAt: &queuedAt,
-			}
-
-			updatedJob, err := w.job.Retry(ctx, job.ID, data)
-			if err != nil {
-				w.log.Error("failed to retry job", "job_type", jobType, "server_id", p.ServerID.String())
-				continue
-			}
+		data := &domain.Job{
+			TraceID:  uuid.New(),
+			ServerID: p.ServerID,
+			Type:     jobType,
+			Payload:  payload,
+		}
 
-			w.log.Debug("job retried", "job_id", updatedJob.ID, "job_type", jobType)
+		createdJob, err := w.job.Create(ctx, data)
+		if err != nil {
+			w.log.Error("failed to create job", "job_type", jobType, "server_id", p.ServerID.String())
+			continue
 		}
+
+		w.log.Debug("job created", "job_id", createdJob.ID, "job_type", jobType)
 	}
 
 	return nil
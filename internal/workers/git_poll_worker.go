@@ -0,0 +1,40 @@
+package workers
+
+import (
+	"context"
+	"fmt"
+
+	"horizonx/internal/domain"
+	"horizonx/internal/logger"
+)
+
+// GitPollWorker asks agents for the branch heads of applications that poll
+// their remote. Each application keeps its own interval, the worker only
+// has to tick more often than the shortest one.
+type GitPollWorker struct {
+	gitPoll domain.GitPollService
+	log     logger.Logger
+}
+
+func NewGitPollWorker(gitPoll domain.GitPollService, log logger.Logger) Worker {
+	return &GitPollWorker{
+		gitPoll: gitPoll,
+		log:     log,
+	}
+}
+
+func (w *GitPollWorker) Name() string {
+	return "application_git_poll"
+}
+
+func (w *GitPollWorker) Run(ctx context.Context) error {
+	polled, err := w.gitPoll.PollDue(ctx)
+	if polled > 0 {
+		w.log.Debug("application remotes polled", "count", polled)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to poll git remotes: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,38 @@
+package workers
+
+import (
+	"context"
+	"fmt"
+
+	"horizonx/internal/domain"
+	"horizonx/internal/logger"
+)
+
+type JobLeaseReaperWorker struct {
+	job domain.JobService
+	log logger.Logger
+}
+
+func NewJobLeaseReaperWorker(job domain.JobService, log logger.Logger) Worker {
+	return &JobLeaseReaperWorker{
+		job: job,
+		log: log,
+	}
+}
+
+func (w *JobLeaseReaperWorker) Name() string {
+	return "job_lease_reaper"
+}
+
+func (w *JobLeaseReaperWorker) Run(ctx context.Context) error {
+	reaped, err := w.job.ReapExpiredLeases(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to reap expired job leases: %w", err)
+	}
+
+	if reaped > 0 {
+		w.log.Warn("reaped jobs with expired leases", "count", reaped)
+	}
+
+	return nil
+}
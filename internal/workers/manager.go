@@ -3,7 +3,6 @@ package workers
 
 import (
 	"context"
-	"time"
 
 	"horizonx/internal/domain"
 	"horizonx/internal/logger"
@@ -21,6 +20,9 @@ type ManagerServices struct {
 	Server      domain.ServerService
 	Metrics     domain.MetricsService
 	Application domain.ApplicationService
+	AgentEvent  domain.AgentEventService
+	Schedule    domain.ScheduleService
+	GitPoll     domain.GitPollService
 }
 
 type Worker interface {
@@ -37,24 +39,59 @@ func NewManager(log logger.Logger, scheduler *Scheduler, services *ManagerServic
 	}
 }
 
-func (m *Manager) Start(ctx context.Context) {
+// Start schedules the built-in workers. The defaults keep the intervals the
+// workers always ran at, "@every" is measured from startup like the tickers
+// it replaced. Wall clock schedules are opt-in through
+// config.Config.WorkerSchedules (WORKER_<NAME>_SCHEDULE).
+func (m *Manager) Start(ctx context.Context) error {
 	m.log.Info("worker: manager started")
 
-	m.scheduler.RunByDuration(ctx, 10*time.Second, &MetricsCollectWorker{
+	if err := m.scheduler.Run(ctx, ScheduleOptions{Spec: "@every 10s", SkipIfRunning: true}, &MetricsCollectWorker{
 		job:    m.services.Job,
 		server: m.services.Server,
 		log:    m.log,
-	})
+	}); err != nil {
+		return err
+	}
 
-	m.scheduler.RunDaily(ctx, DailySchedule{Hour: 2, Minute: 0}, &MetricsCleanupWorker{
+	if err := m.scheduler.Run(ctx, ScheduleOptions{Spec: "0 2 * * *", SkipIfRunning: true}, &MetricsCleanupWorker{
 		metrics: m.services.Metrics,
 		server:  m.services.Server,
+		job:     m.services.Job,
+		events:  m.services.AgentEvent,
 		log:     m.log,
-	})
+	}); err != nil {
+		return err
+	}
 
-	m.scheduler.RunByDuration(ctx, 5*time.Minute, &ApplicationHealthCheckWorker{
+	if err := m.scheduler.Run(ctx, ScheduleOptions{Spec: "@every 5m", SkipIfRunning: true}, &ApplicationHealthCheckWorker{
 		app: m.services.Application,
 		job: m.services.Job,
 		log: m.log,
-	})
+	}); err != nil {
+		return err
+	}
+
+	if err := m.scheduler.Run(ctx, ScheduleOptions{Spec: "@every " + domain.JobHeartbeatInterval.String(), SkipIfRunning: true}, &JobLeaseReaperWorker{
+		job: m.services.Job,
+		log: m.log,
+	}); err != nil {
+		return err
+	}
+
+	if err := m.scheduler.Run(ctx, ScheduleOptions{Spec: "* * * * *", SkipIfRunning: true}, &ScheduleWorker{
+		schedule: m.services.Schedule,
+		log:      m.log,
+	}); err != nil {
+		return err
+	}
+
+	if err := m.scheduler.Run(ctx, ScheduleOptions{Spec: "@every 15s", SkipIfRunning: true}, &GitPollWorker{
+		gitPoll: m.services.GitPoll,
+		log:     m.log,
+	}); err != nil {
+		return err
+	}
+
+	return nil
 }
@@ -12,13 +12,23 @@ import (
 type MetricsCleanupWorker struct {
 	metrics domain.MetricsService
 	server  domain.ServerService
+	job     domain.JobService
+	events  domain.AgentEventService
 	log     logger.Logger
 }
 
-func NewMetricsCleanupWorker(metrics domain.MetricsService, server domain.ServerService, log logger.Logger) Worker {
+func NewMetricsCleanupWorker(
+	metrics domain.MetricsService,
+	server domain.ServerService,
+	job domain.JobService,
+	events domain.AgentEventService,
+	log logger.Logger,
+) Worker {
 	return &MetricsCleanupWorker{
 		metrics: metrics,
 		server:  server,
+		job:     job,
+		events:  events,
 		log:     log,
 	}
 }
@@ -45,5 +55,16 @@ func (w *MetricsCleanupWorker) Run(ctx context.Context) error {
 		}
 	}
 
+	// Recurring jobs create a row per run, keep the same retention as metrics
+	for _, jobType := range []domain.JobType{domain.JobTypeMetricsCollect, domain.JobTypeAppHealthCheck, domain.JobTypeAppGitPoll} {
+		if _, err := w.job.Prune(ctx, jobType, cutoffTime); err != nil {
+			w.log.Error("failed to prune finished jobs", "job_type", jobType, "error", err.Error())
+		}
+	}
+
+	if _, err := w.events.Prune(ctx); err != nil {
+		w.log.Error("failed to prune agent events", "error", err.Error())
+	}
+
 	return nil
 }
@@ -3,7 +3,6 @@ package workers
 import (
 	"context"
 	"fmt"
-	"time"
 
 	"horizonx/internal/domain"
 	"horizonx/internal/logger"
@@ -58,46 +57,27 @@ func (w *MetricsCollectWorker) Run(ctx context.Context) error {
 			continue
 		}
 
-		// Create new job
-		if len(jobs.Data) == 0 {
-			data := &domain.Job{
-				TraceID:  uuid.New(),
-				ServerID: srv.ID,
-				Type:     domain.JobTypeMetricsCollect,
-			}
-
-			createdJob, err := w.job.Create(ctx, data)
-			if err != nil {
-				w.log.Error("failed to create job", "job_type", jobType, "server_id", srv.ID.String())
-				continue
-			}
-
-			w.log.Debug("job created", "job_id", createdJob.ID, "job_type", createdJob.Type)
-			continue
-		}
-
-		// Retry existing job
+		// Every run gets its own job so past runs stay in the history
 		if len(jobs.Data) > 0 {
-			job := jobs.Data[0]
-
-			if job.Status == domain.JobQueued {
+			status := jobs.Data[0].Status
+			if status == domain.JobQueued || status == domain.JobRunning {
 				continue
 			}
+		}
 
-			queuedAt := time.Now().UTC()
-			data := &domain.Job{
-				Status:   domain.JobQueued,
-				QueuedAt: &queuedAt,
-			}
-
-			updatedJob, err := w.job.Retry(ctx, job.ID, data)
-			if err != nil {
-				w.log.Error("failed to retry job", "job_type", jobType, "server_id", srv.ID.String())
-				continue
-			}
+		data := &domain.Job{
+			TraceID:  uuid.New(),
+			ServerID: srv.ID,
+			Type:     domain.JobTypeMetricsCollect,
+		}
 
-			w.log.Debug("job retried", "job_id", updatedJob.ID, "job_type", updatedJob.Type)
+		createdJob, err := w.job.Create(ctx, data)
+		if err != nil {
+			w.log.Error("failed to create job", "job_type", jobType, "server_id", srv.ID.String())
+			continue
 		}
+
+		w.log.Debug("job created", "job_id", createdJob.ID, "job_type", createdJob.Type)
 	}
 
 	return nil
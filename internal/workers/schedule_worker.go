@@ -0,0 +1,37 @@
+package workers
+
+import (
+	"context"
+	"fmt"
+
+	"horizonx/internal/domain"
+	"horizonx/internal/logger"
+)
+
+type ScheduleWorker struct {
+	schedule domain.ScheduleService
+	log      logger.Logger
+}
+
+func NewScheduleWorker(schedule domain.ScheduleService, log logger.Logger) Worker {
+	return &ScheduleWorker{
+		schedule: schedule,
+		log:      log,
+	}
+}
+
+func (w *ScheduleWorker) Name() string {
+	return "application_schedules"
+}
+
+func (w *ScheduleWorker) Run(ctx context.Context) error {
+	ran, err := w.schedule.RunDue(ctx)
+	if ran > 0 {
+		w.log.Info("application schedules triggered", "count", ran)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to run due schedules: %w", err)
+	}
+
+	return nil
+}
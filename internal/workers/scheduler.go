@@ -2,15 +2,31 @@ package workers
 
 import (
 	"context"
+	"fmt"
+	"math/rand/v2"
+	"sync/atomic"
 	"time"
 
 	"horizonx/internal/config"
+	"horizonx/internal/cron"
 	"horizonx/internal/logger"
 )
 
-type DailySchedule struct {
-	Hour   int
-	Minute int
+// ScheduleDisabled turns a built-in worker off when used as its schedule.
+const ScheduleDisabled = "off"
+
+// ScheduleOptions describes when a worker runs. Spec is parsed by
+// cron.Parse and evaluated in the configured time zone.
+type ScheduleOptions struct {
+	Spec string
+
+	// Jitter delays every run by a random duration in [0, Jitter) so workers
+	// on several control planes do not fire at the same instant.
+	Jitter time.Duration
+
+	// SkipIfRunning drops a run while the previous one is still in progress
+	// instead of starting an overlapping one.
+	SkipIfRunning bool
 }
 
 type Scheduler struct {
@@ -25,65 +41,90 @@ func NewScheduler(cfg *config.Config, log logger.Logger) *Scheduler {
 	}
 }
 
-func (s *Scheduler) RunByDuration(ctx context.Context, dur time.Duration, worker Worker) {
-	go func() {
-		ticker := time.NewTicker(dur)
-		defer ticker.Stop()
-
-		for {
-			select {
-			case <-ctx.Done():
-				return
-			case <-ticker.C:
-				start := time.Now()
-
-				err := worker.Run(ctx)
-				if err != nil {
-					s.log.Error("worker failed", "name", worker.Name(), "error", err)
-				}
-
-				s.log.Debug("worker finished", "name", worker.Name(), "time", time.Since(start))
-			}
+// Run schedules worker with opts, after applying any override configured for
+// the worker's name. An invalid schedule is reported before anything starts.
+func (s *Scheduler) Run(ctx context.Context, opts ScheduleOptions, worker Worker) error {
+	if override, ok := s.cfg.WorkerSchedules[worker.Name()]; ok {
+		if override.Spec != "" {
+			opts.Spec = override.Spec
+		}
+		if override.Jitter > 0 {
+			opts.Jitter = override.Jitter
 		}
-	}()
+		if override.SkipIfRunning != nil {
+			opts.SkipIfRunning = *override.SkipIfRunning
+		}
+	}
+
+	if opts.Spec == ScheduleDisabled {
+		s.log.Info("worker disabled", "name", worker.Name())
+		return nil
+	}
+
+	schedule, err := cron.Parse(opts.Spec)
+	if err != nil {
+		return fmt.Errorf("worker %s: %w", worker.Name(), err)
+	}
+
+	s.log.Debug("worker scheduled",
+		"name", worker.Name(),
+		"schedule", opts.Spec,
+		"jitter", opts.Jitter,
+		"skip_if_running", opts.SkipIfRunning,
+	)
+
+	go s.loop(ctx, schedule, opts, worker)
+
+	return nil
 }
 
-func (s *Scheduler) RunDaily(ctx context.Context, schedule DailySchedule, worker Worker) {
-	go func() {
-		for {
-			now := time.Now().In(s.cfg.TimeZone)
-
-			next := time.Date(
-				now.Year(),
-				now.Month(),
-				now.Day(),
-				schedule.Hour,
-				schedule.Minute,
-				0,
-				0,
-				s.cfg.TimeZone,
-			)
-			if !next.After(now) {
-				next = next.AddDate(0, 0, 1)
-			}
-
-			timer := time.NewTimer(time.Until(next))
-
-			select {
-			case <-ctx.Done():
-				timer.Stop()
-				s.log.Debug("daily worker canceled", "name", worker.Name())
-				return
-			case <-timer.C:
-				start := time.Now()
-
-				err := worker.Run(ctx)
-				if err != nil {
-					s.log.Error("worker failed", "name", worker.Name(), "error", err)
-				}
-
-				s.log.Debug("worker finished", "name", worker.Name(), "time", time.Since(start))
-			}
+func (s *Scheduler) loop(ctx context.Context, schedule cron.Schedule, opts ScheduleOptions, worker Worker) {
+	var running atomic.Int32
+
+	for {
+		now := time.Now().In(s.cfg.TimeZone)
+
+		next := schedule.Next(now)
+		if next.IsZero() {
+			s.log.Warn("worker schedule has no upcoming runs", "name", worker.Name(), "schedule", opts.Spec)
+			return
 		}
-	}()
+
+		delay := next.Sub(now)
+		if opts.Jitter > 0 {
+			delay += rand.N(opts.Jitter)
+		}
+
+		timer := time.NewTimer(delay)
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			s.log.Debug("worker canceled", "name", worker.Name())
+			return
+		case <-timer.C:
+		}
+
+		if opts.SkipIfRunning && running.Load() > 0 {
+			s.log.Warn("worker still running, skipping scheduled run", "name", worker.Name())
+			continue
+		}
+
+		running.Add(1)
+		go func() {
+			defer running.Add(-1)
+			s.run(ctx, worker)
+		}()
+	}
+}
+
+func (s *Scheduler) run(ctx context.Context, worker Worker) {
+	start := time.Now()
+
+	err := worker.Run(ctx)
+	if err != nil {
+		s.log.Error("worker failed", "name", worker.Name(), "error", err)
+	}
+
+	s.log.Debug("worker finished", "name", worker.Name(), "time", time.Since(start))
 }
@@ -47,6 +47,8 @@ HORIZONX_API_URL="http://localhost:3000"
 HORIZONX_WS_URL="ws://localhost:3000/agent/ws"
 HORIZONX_SERVER_API_TOKEN="hzx_secret"
 HORIZONX_SERVER_ID="123"
+HORIZONX_MAX_CONCURRENT_JOBS="4"
+HORIZONX_STATE_DIR="/var/horizonx/state"
 LOG_LEVEL="info"
 LOG_FORMAT="text"
 EOF